@@ -0,0 +1,142 @@
+/*
+Copyright Istio Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// This is a subset of the Istio VirtualService types that is
+// enough for okra to manage weighted HTTP routes.
+//
+// Istio defines these types in protobuf. We redefine them as plain Go structs so that
+// okra doesn't need to depend on istio.io/api and istio.io/client-go.
+//
+// Derived from https://github.com/istio/api/blob/1.12.0/networking/v1beta1/virtual_service.proto
+
+// StringMatch specifies how to match a string. Only one of the fields should be set.
+type StringMatch struct {
+	// +optional
+	Exact string `json:"exact,omitempty"`
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+	// +optional
+	Regex string `json:"regex,omitempty"`
+}
+
+// HTTPMatchRequest specifies a set of criterion to be met in order for the
+// rule to be applied to the HTTP request.
+type HTTPMatchRequest struct {
+	// +optional
+	Name string `json:"name,omitempty"`
+	// +optional
+	URI *StringMatch `json:"uri,omitempty"`
+	// +optional
+	Scheme *StringMatch `json:"scheme,omitempty"`
+	// +optional
+	Method *StringMatch `json:"method,omitempty"`
+	// +optional
+	Authority *StringMatch `json:"authority,omitempty"`
+	// +optional
+	Headers map[string]StringMatch `json:"headers,omitempty"`
+	// +optional
+	Port uint32 `json:"port,omitempty"`
+	// +optional
+	QueryParams map[string]StringMatch `json:"queryParams,omitempty"`
+	// +optional
+	IgnoreURICase bool `json:"ignoreUriCase,omitempty"`
+	// +optional
+	Gateways []string `json:"gateways,omitempty"`
+}
+
+// PortSelector specifies the number of a port to be used for matching or selection for final routing.
+type PortSelector struct {
+	Number uint32 `json:"number,omitempty"`
+}
+
+// Destination indicates the network addressable service to which the request/connection
+// will be sent after processing a routing rule.
+type Destination struct {
+	// Host is the name of a service from the service registry.
+	Host string `json:"host"`
+	// Subset is the name of a subset within the service, defined in a DestinationRule.
+	// +optional
+	Subset string `json:"subset,omitempty"`
+	// +optional
+	Port *PortSelector `json:"port,omitempty"`
+}
+
+// HTTPRouteDestination is a weighted destination of a HTTPRoute.
+type HTTPRouteDestination struct {
+	Destination Destination `json:"destination"`
+	// Weight is the proportion of traffic to be forwarded to the destination.
+	// +optional
+	Weight int32 `json:"weight,omitempty"`
+}
+
+// HTTPRoute describes match conditions and actions for routing HTTP/1.1, HTTP2, and gRPC traffic.
+type HTTPRoute struct {
+	// +optional
+	Name string `json:"name,omitempty"`
+	// +optional
+	Match []HTTPMatchRequest `json:"match,omitempty"`
+	// +optional
+	Route []HTTPRouteDestination `json:"route,omitempty"`
+}
+
+// VirtualServiceSpec defines the desired state of VirtualService.
+type VirtualServiceSpec struct {
+	// +optional
+	Hosts []string `json:"hosts,omitempty"`
+	// +optional
+	Gateways []string `json:"gateways,omitempty"`
+	// +optional
+	HTTP []HTTPRoute `json:"http,omitempty"`
+	// +optional
+	ExportTo []string `json:"exportTo,omitempty"`
+}
+
+// VirtualServiceStatus defines the observed state of VirtualService.
+//
+// okra never reads or writes the status so we have no fields here.
+type VirtualServiceStatus struct {
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+
+// VirtualService defines a set of traffic routing rules to apply when a host is addressed.
+type VirtualService struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   VirtualServiceSpec   `json:"spec,omitempty"`
+	Status VirtualServiceStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// VirtualServiceList contains a list of VirtualService.
+type VirtualServiceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VirtualService `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VirtualService{}, &VirtualServiceList{})
+}
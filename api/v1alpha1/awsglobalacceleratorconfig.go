@@ -0,0 +1,79 @@
+/*
+Copyright 2020 The Okra authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AWSGlobalAcceleratorConfigSpec defines the desired state of AWSGlobalAcceleratorConfig
+type AWSGlobalAcceleratorConfigSpec struct {
+	// ListenerARN is the ARN of the Global Accelerator listener whose endpoint groups are managed by okra
+	ListenerARN string `json:"listenerARN"`
+
+	// Endpoints is the list of endpoints, one per cluster.
+	// Endpoints are grouped into endpoint groups by their regions.
+	Endpoints []AWSGlobalAcceleratorEndpoint `json:"endpoints,omitempty"`
+}
+
+type AWSGlobalAcceleratorEndpoint struct {
+	// EndpointID is the ID of the endpoint, like the ARN of the cluster's load balancer
+	EndpointID string `json:"endpointID"`
+	// Region is the region of the endpoint group the endpoint belongs to.
+	// Defaults to the region in the endpoint ID when it is an ARN.
+	// +optional
+	Region string `json:"region,omitempty"`
+	// Weight is the weight of the endpoint within the endpoint group
+	Weight int `json:"weight,omitempty"`
+	// +optional
+	ClientIPPreservationEnabled *bool `json:"clientIPPreservationEnabled,omitempty"`
+}
+
+// AWSGlobalAcceleratorConfigStatus defines the observed state of AWSGlobalAcceleratorConfig
+type AWSGlobalAcceleratorConfigStatus struct {
+	LastSyncTime metav1.Time `json:"lastSyncTime"`
+	Phase        string      `json:"phase"`
+	Reason       string      `json:"reason"`
+	Message      string      `json:"message"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:JSONPath=".spec.listenerARN",name=Listener,type=string
+// +kubebuilder:printcolumn:JSONPath=".status.lastSyncTime",name=Last Sync,type=date
+
+// AWSGlobalAcceleratorConfig is the Schema for the AWSGlobalAcceleratorConfig API
+type AWSGlobalAcceleratorConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   AWSGlobalAcceleratorConfigSpec   `json:"spec,omitempty"`
+	Status AWSGlobalAcceleratorConfigStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// AWSGlobalAcceleratorConfigList contains a list of AWSGlobalAcceleratorConfig
+type AWSGlobalAcceleratorConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AWSGlobalAcceleratorConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&AWSGlobalAcceleratorConfig{}, &AWSGlobalAcceleratorConfigList{})
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2020 The Okra authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AWSRoute53WeightedRecordConfigSpec defines the desired state of AWSRoute53WeightedRecordConfig
+type AWSRoute53WeightedRecordConfigSpec struct {
+	HostedZoneID string `json:"hostedZoneID"`
+	// Name is the domain name of the record sets, like `web.example.com`
+	Name string `json:"name"`
+	// Type is the DNS record type of the record sets. Defaults to `CNAME`.
+	Type string `json:"type,omitempty"`
+	// TTL is the TTL of the record sets in seconds. Defaults to 60.
+	TTL int64 `json:"ttl,omitempty"`
+
+	// Records is the list of weighted record sets, one per cluster.
+	Records []AWSRoute53WeightedRecord `json:"records,omitempty"`
+}
+
+type AWSRoute53WeightedRecord struct {
+	// SetIdentifier differentiates the weighted record sets that have the same name and type
+	SetIdentifier string `json:"setIdentifier"`
+	// Value is the value of the record, like the DNS name of the cluster's load balancer
+	Value  string `json:"value"`
+	Weight int    `json:"weight,omitempty"`
+	// HealthCheckID is the ID of the Route53 health check associated to the record set
+	HealthCheckID string `json:"healthCheckID,omitempty"`
+}
+
+// AWSRoute53WeightedRecordConfigStatus defines the observed state of AWSRoute53WeightedRecordConfig
+type AWSRoute53WeightedRecordConfigStatus struct {
+	LastSyncTime metav1.Time `json:"lastSyncTime"`
+	Phase        string      `json:"phase"`
+	Reason       string      `json:"reason"`
+	Message      string      `json:"message"`
+
+	// Managed is the weighted record sets created by the controller in the last successful sync.
+	// Only these record sets are deleted when they are removed from the spec, or when the spec's name or type changed.
+	Managed *AWSRoute53ManagedRecordSets `json:"managed,omitempty"`
+}
+
+// AWSRoute53ManagedRecordSets identifies the weighted record sets created by the controller
+type AWSRoute53ManagedRecordSets struct {
+	HostedZoneID   string   `json:"hostedZoneID"`
+	Name           string   `json:"name"`
+	Type           string   `json:"type"`
+	SetIdentifiers []string `json:"setIdentifiers,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:JSONPath=".spec.name",name=Name,type=string
+// +kubebuilder:printcolumn:JSONPath=".status.lastSyncTime",name=Last Sync,type=date
+
+// AWSRoute53WeightedRecordConfig is the Schema for the AWSRoute53WeightedRecordConfig API
+type AWSRoute53WeightedRecordConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   AWSRoute53WeightedRecordConfigSpec   `json:"spec,omitempty"`
+	Status AWSRoute53WeightedRecordConfigStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// AWSRoute53WeightedRecordConfigList contains a list of AWSRoute53WeightedRecordConfig
+type AWSRoute53WeightedRecordConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AWSRoute53WeightedRecordConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&AWSRoute53WeightedRecordConfig{}, &AWSRoute53WeightedRecordConfigList{})
+}
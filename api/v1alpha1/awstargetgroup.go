@@ -32,10 +32,35 @@ type AWSTargetGroupStatus struct {
 	Phase        string                   `json:"phase"`
 	Reason       string                   `json:"reason"`
 	Message      string                   `json:"message"`
+
+	// TargetHealth is the health of the targets registered to the target group, observed periodically
+	// +optional
+	TargetHealth *AWSTargetGroupTargetHealth `json:"targetHealth,omitempty"`
+
+	// Conditions contains the Decommissionable condition while the target group is at zero weight
+	// in the loadbalancer config of a cell with decommission enabled
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// AWSTargetGroupTargetHealth is the number of the targets per ELBv2 target health state
+type AWSTargetGroupTargetHealth struct {
+	Healthy     int32 `json:"healthy"`
+	Unhealthy   int32 `json:"unhealthy"`
+	Draining    int32 `json:"draining"`
+	Initial     int32 `json:"initial"`
+	Unused      int32 `json:"unused"`
+	Unavailable int32 `json:"unavailable"`
+	// Total is the number of all the registered targets
+	Total int32 `json:"total"`
+
+	LastProbeTime metav1.Time `json:"lastProbeTime"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:JSONPath=".status.targetHealth.healthy",name=Healthy,type=integer
+// +kubebuilder:printcolumn:JSONPath=".status.targetHealth.total",name=Total,type=integer
 // +kubebuilder:printcolumn:JSONPath=".status.lastSyncTime",name=Last Sync,type=date
 
 // AWSTargetGroup is the Schema for the AWSTargetGroup API
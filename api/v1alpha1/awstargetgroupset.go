@@ -18,6 +18,7 @@ package v1alpha1
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // AWSTargetGroupSpec defines the desired state of AWSTargetGroupp
@@ -27,8 +28,95 @@ type AWSTargetGroupSetSpec struct {
 	Template   AWSTargetGroupTemplate    `json:"template,omitempty"`
 }
 
+// AWSTargetGroupGenerator generates AWSTargetGroups.
+// Exactly one of the fields should be set.
 type AWSTargetGroupGenerator struct {
-	AWSEKS AWSTargetGroupGeneratorAWSEKS `json:"awseks,omitempty"`
+	AWSTargetGroupBaseGenerator `json:",inline"`
+
+	// Matrix generates AWSTargetGroups generated by all the generators,
+	// with the labels merged in order.
+	Matrix *AWSTargetGroupCombinationGenerator `json:"matrix,omitempty"`
+	// Merge generates AWSTargetGroups generated by the first generator,
+	// with the labels merged with the ones of the same AWSTargetGroups generated by the rest of the generators.
+	Merge *AWSTargetGroupCombinationGenerator `json:"merge,omitempty"`
+	// Provision creates an ELBv2 target group and a TargetGroupBinding per cluster, and generates AWSTargetGroups for them.
+	// Both are deleted once the cluster is gone and the target group has zero weight in all the AWSApplicationLoadBalancerConfigs.
+	Provision *AWSTargetGroupProvisionGenerator `json:"provision,omitempty"`
+}
+
+// AWSTargetGroupProvisionGenerator provisions target groups for the clusters selected by the cluster selector.
+type AWSTargetGroupProvisionGenerator struct {
+	ClusterSelector TargetGroupClusterSelector      `json:"clusterSelector"`
+	TargetGroup     AWSTargetGroupProvisionTemplate `json:"targetGroup"`
+	Binding         TargetGroupBindingTemplate      `json:"binding"`
+}
+
+// AWSTargetGroupProvisionTemplate is the template of the ELBv2 target groups created per cluster.
+// Target groups are not updated once created.
+type AWSTargetGroupProvisionTemplate struct {
+	// NamePrefix is the prefix of the names of the target groups, followed by the names of the clusters.
+	// It also identifies the AWSTargetGroups and the target groups provisioned by the generator.
+	NamePrefix string `json:"namePrefix"`
+	// Region is the region of the target groups. Defaults to the region of the controller.
+	// +optional
+	Region   string `json:"region,omitempty"`
+	Protocol string `json:"protocol"`
+	Port     int64  `json:"port"`
+	VPCID    string `json:"vpcID"`
+	// TargetType is either instance or ip. Defaults to instance.
+	// +optional
+	TargetType string `json:"targetType,omitempty"`
+	// +optional
+	HealthCheck *AWSTargetGroupHealthCheck `json:"healthCheck,omitempty"`
+	// +optional
+	DeregistrationDelaySeconds *int64 `json:"deregistrationDelaySeconds,omitempty"`
+}
+
+type AWSTargetGroupHealthCheck struct {
+	// +optional
+	Protocol string `json:"protocol,omitempty"`
+	// +optional
+	Port string `json:"port,omitempty"`
+	// +optional
+	Path string `json:"path,omitempty"`
+	// +optional
+	IntervalSeconds *int64 `json:"intervalSeconds,omitempty"`
+	// +optional
+	TimeoutSeconds *int64 `json:"timeoutSeconds,omitempty"`
+	// +optional
+	HealthyThresholdCount *int64 `json:"healthyThresholdCount,omitempty"`
+	// +optional
+	UnhealthyThresholdCount *int64 `json:"unhealthyThresholdCount,omitempty"`
+	// HTTPCode is the HTTP codes to use when checking for a successful response, like `200-299`.
+	// +optional
+	HTTPCode string `json:"httpCode,omitempty"`
+}
+
+// TargetGroupBindingTemplate is the template of the TargetGroupBindings created per cluster.
+type TargetGroupBindingTemplate struct {
+	Metadata TargetGroupBindingTemplateMetadata `json:"metadata"`
+	// ServiceName is the name of the service in the namespace of the binding to register as targets
+	ServiceName string             `json:"serviceName"`
+	ServicePort intstr.IntOrString `json:"servicePort"`
+}
+
+type TargetGroupBindingTemplateMetadata struct {
+	Namespace string `json:"namespace"`
+	// Name defaults to the name of the target group.
+	// +optional
+	Name string `json:"name,omitempty"`
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// AWSTargetGroupBaseGenerator is an AWSTargetGroup generator that can be nested in a matrix or merge generator.
+type AWSTargetGroupBaseGenerator struct {
+	AWSEKS  *AWSTargetGroupGeneratorAWSEKS  `json:"awseks,omitempty"`
+	AWSTags *AWSTargetGroupGeneratorAWSTags `json:"awsTags,omitempty"`
+}
+
+type AWSTargetGroupCombinationGenerator struct {
+	Generators []AWSTargetGroupBaseGenerator `json:"generators"`
 }
 
 type AWSTargetGroupGeneratorAWSEKS struct {
@@ -36,6 +124,21 @@ type AWSTargetGroupGeneratorAWSEKS struct {
 	BindingSelector TargetGroupBindingSelector `json:"bindingSelector,omitempty"`
 }
 
+// AWSTargetGroupGeneratorAWSTags generates AWSTargetGroups from the ELBv2 target groups that have all the tags.
+// Unlike awseks, it does not need to access the remote clusters.
+type AWSTargetGroupGeneratorAWSTags struct {
+	// Region is the region of the target groups. Defaults to the region of the controller.
+	// +optional
+	Region string `json:"region,omitempty"`
+	// MatchTags are the tags the target groups must have, like `okra.mumo.co/cell: web`.
+	MatchTags map[string]string `json:"matchTags"`
+	// LabelsFromTags maps the label keys of the generated AWSTargetGroups to the tag keys of the target groups,
+	// like `okra.mumo.co/version: version`.
+	// Tags whose keys and values are valid label keys and values are copied as labels as-is, too.
+	// +optional
+	LabelsFromTags map[string]string `json:"labelsFromTags,omitempty"`
+}
+
 type TargetGroupClusterSelector struct {
 	MatchLabels map[string]string `json:"matchLabels,omitempty"`
 }
@@ -46,6 +149,12 @@ type TargetGroupBindingSelector struct {
 
 type AWSTargetGroupTemplate struct {
 	Metadata AWSTargetGroupTemplateMetadata `json:"metadata,omitempty"`
+	// LabelsFromCluster are the keys of the labels to copy from the cluster secret onto the AWSTargetGroups
+	// generated for the cluster, like `okra.mumo.co/version`.
+	// They take precedence over the labels of the TargetGroupBindings, and are overridden by the template labels.
+	// Labels missing on the cluster secret are skipped.
+	// +optional
+	LabelsFromCluster []string `json:"labelsFromCluster,omitempty"`
 }
 
 type AWSTargetGroupTemplateMetadata struct {
@@ -54,14 +163,39 @@ type AWSTargetGroupTemplateMetadata struct {
 
 // AWSTargetGroupSetStatus defines the observed state of AWSTargetGroupSet
 type AWSTargetGroupSetStatus struct {
-	LastSyncTime metav1.Time `json:"lastSyncTime"`
-	Phase        string      `json:"phase"`
-	Reason       string      `json:"reason"`
-	Message      string      `json:"message"`
+	// +optional
+	TargetGroups AWSTargetGroupSetStatusTargetGroups `json:"targetGroups,omitempty"`
+	LastSyncTime metav1.Time                         `json:"lastSyncTime"`
+	Phase        string                              `json:"phase"`
+	Reason       string                              `json:"reason"`
+	Message      string                              `json:"message"`
+
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// AWSTargetGroupSetStatusTargetGroups contains the AWSTargetGroups observed in the last sync
+type AWSTargetGroupSetStatusTargetGroups struct {
+	// Names are the names of all the discovered AWSTargetGroups
+	Names []string `json:"names,omitempty"`
+
+	// +optional
+	Created []string `json:"created,omitempty"`
+	// +optional
+	Updated []string `json:"updated,omitempty"`
+	// +optional
+	Unchanged []string `json:"unchanged,omitempty"`
+	// +optional
+	Deleted []string `json:"deleted,omitempty"`
+
+	// Errors are the errors occurred while syncing the members
+	// +optional
+	Errors []MemberError `json:"errors,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:JSONPath=".status.conditions[?(@.type=='Ready')].status",name=Ready,type=string
 // +kubebuilder:printcolumn:JSONPath=".status.lastSyncTime",name=Last Sync,type=date
 
 // AWSTargetGroupSet is the Schema for the AWSTargetGroupSet API
@@ -21,8 +21,11 @@ import (
 	"strings"
 
 	"github.com/pkg/errors"
+	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	gatewayv1beta1 "github.com/mumoshu/okra/api/gateway/v1beta1"
+	istiov1beta1 "github.com/mumoshu/okra/api/istio/v1beta1"
 	rolloutsv1alpha1 "github.com/mumoshu/okra/api/rollouts/v1alpha1"
 )
 
@@ -35,12 +38,37 @@ type CellSpec struct {
 	// ASAP, so that a manual rollback can be done immediately.
 	Version        string             `json:"version,omitempty"`
 	UpdateStrategy CellUpdateStrategy `json:"updateStrategy,omitempty"`
+	// Decommission tracks the target groups drained after rollouts, removes them from the loadbalancer config,
+	// and marks their clusters decommissionable once all of their target groups are drained.
+	// Only the AWSApplicationLoadBalancer ingress is supported.
+	// +optional
+	Decommission *CellDecommission `json:"decommission,omitempty"`
+}
+
+// CellDecommission configures how drained clusters are decommissioned.
+type CellDecommission struct {
+	// BakeTime is how long a target group stays at zero weight, in addition to its deregistration delay,
+	// before it is considered drained. Defaults to 10 minutes.
+	// +optional
+	BakeTime *metav1.Duration `json:"bakeTime,omitempty"`
+	// EKSClusterTags are set to the EKS cluster once it becomes decommissionable, like `okra.mumo.co/decommissionable: "true"`
+	// +optional
+	EKSClusterTags map[string]string `json:"eksClusterTags,omitempty"`
+	// WebhookURL receives a POST request with a JSON body once a cluster becomes decommissionable
+	// +optional
+	WebhookURL string `json:"webhookURL,omitempty"`
 }
 
 type CellIngress struct {
 	Type                       CellIngressType                        `json:"type,omitempty"`
 	AWSApplicationLoadBalancer *CellIngressAWSApplicationLoadBalancer `json:"awsApplicationLoadBalancer,omitempty"`
 	AWSNetworkLoadBalancer     *CellIngressAWSNetworkLoadBalancer     `json:"awsNetworkLoadBalancer,omitempty"`
+	GatewayAPIHTTPRoute        *CellIngressGatewayAPIHTTPRoute        `json:"gatewayAPIHTTPRoute,omitempty"`
+	IstioVirtualService        *CellIngressIstioVirtualService        `json:"istioVirtualService,omitempty"`
+	IngressNginx               *CellIngressIngressNginx               `json:"ingressNginx,omitempty"`
+	Envoy                      *CellIngressEnvoy                      `json:"envoy,omitempty"`
+	Route53WeightedRecord      *CellIngressRoute53WeightedRecord      `json:"route53WeightedRecord,omitempty"`
+	AWSGlobalAccelerator       *CellIngressAWSGlobalAccelerator       `json:"awsGlobalAccelerator,omitempty"`
 }
 
 type CellIngressType string
@@ -53,7 +81,7 @@ func (v CellIngressType) String() string {
 
 func (v CellIngressType) Valid() error {
 	switch v {
-	case CellIngressTypeAWSApplicationLoadBalancer:
+	case CellIngressTypeAWSApplicationLoadBalancer, CellIngressTypeGatewayAPIHTTPRoute, CellIngressTypeIstioVirtualService, CellIngressTypeIngressNginx, CellIngressTypeEnvoy, CellIngressTypeRoute53WeightedRecord, CellIngressTypeAWSGlobalAccelerator:
 		return nil
 	default:
 		return errors.Wrapf(ErrInvalidCellIngressType, "get %s", v)
@@ -68,6 +96,12 @@ func (v *CellIngressType) UnmarshalJSON(b []byte) error {
 const (
 	CellIngressTypeAWSApplicationLoadBalancer CellIngressType = "AWSApplicationLoadBalancer"
 	CellIngressTypeAWSNetworkLoadBalancer     CellIngressType = "AWSNetworkLoadBalancer"
+	CellIngressTypeGatewayAPIHTTPRoute        CellIngressType = "GatewayAPIHTTPRoute"
+	CellIngressTypeIstioVirtualService        CellIngressType = "IstioVirtualService"
+	CellIngressTypeIngressNginx               CellIngressType = "IngressNginx"
+	CellIngressTypeEnvoy                      CellIngressType = "Envoy"
+	CellIngressTypeRoute53WeightedRecord      CellIngressType = "Route53WeightedRecord"
+	CellIngressTypeAWSGlobalAccelerator       CellIngressType = "AWSGlobalAccelerator"
 )
 
 type CellIngressAWSApplicationLoadBalancer struct {
@@ -82,6 +116,124 @@ type CellIngressAWSNetworkLoadBalancer struct {
 	TargetGroupSelector TargetGroupSelector `json:"targetGroupSelector,omitempty"`
 }
 
+// CellIngressGatewayAPIHTTPRoute is the configuration for a Gateway API HTTPRoute
+// that lives in the management cluster and is named after the cell.
+// Okra creates the HTTPRoute with a single rule and manages weights of its backendRefs,
+// one per Service that represents the endpoint of a cluster.
+type CellIngressGatewayAPIHTTPRoute struct {
+	// ParentRefs is the list of Gateways the HTTPRoute is attached to
+	ParentRefs []gatewayv1beta1.ParentReference `json:"parentRefs,omitempty"`
+	Hostnames  []gatewayv1beta1.Hostname        `json:"hostnames,omitempty"`
+	Matches    []gatewayv1beta1.HTTPRouteMatch  `json:"matches,omitempty"`
+	// Port is the port number of the backend services the traffic is routed to
+	Port int32 `json:"port,omitempty"`
+	// BackendSelector selects Services in the namespace of the cell.
+	// Each Service is supposed to represent the endpoint of a cluster, like
+	// an ExternalName service that points to the loadbalancer in front of the cluster.
+	BackendSelector ServiceSelector `json:"backendSelector,omitempty"`
+}
+
+// CellIngressIstioVirtualService is the configuration for an Istio VirtualService
+// that lives in the management cluster and is named after the cell.
+// Okra creates the VirtualService with a single HTTP route and manages weights of its destinations,
+// one per ClusterEndpoint.
+type CellIngressIstioVirtualService struct {
+	Hosts    []string                        `json:"hosts,omitempty"`
+	Gateways []string                        `json:"gateways,omitempty"`
+	Match    []istiov1beta1.HTTPMatchRequest `json:"match,omitempty"`
+	// EndpointSelector selects ClusterEndpoints in the namespace of the cell.
+	EndpointSelector ClusterEndpointSelector `json:"endpointSelector,omitempty"`
+}
+
+// CellIngressIngressNginx is the configuration for a pair of Ingresses managed by ingress-nginx.
+// Okra creates the stable Ingress named after the cell and the canary Ingress named `<cell>-canary`,
+// and shifts traffic by updating the backend services and the `nginx.ingress.kubernetes.io/canary-weight`
+// annotation.
+//
+// As ingress-nginx supports only one canary backend per Ingress, the cell can route traffic to
+// at most two services at once. Use `replicas: 1` with this ingress type.
+type CellIngressIngressNginx struct {
+	IngressClassName *string `json:"ingressClassName,omitempty"`
+	Host             string  `json:"host,omitempty"`
+	// Path defaults to `/`
+	Path     string                 `json:"path,omitempty"`
+	PathType *networkingv1.PathType `json:"pathType,omitempty"`
+	// Port is the port number of the backend services the traffic is routed to
+	Port int32                     `json:"port,omitempty"`
+	TLS  []networkingv1.IngressTLS `json:"tls,omitempty"`
+	// Annotations are added to both the stable and the canary Ingresses
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// CanaryByHeader, CanaryByHeaderValue, CanaryByHeaderPattern, and CanaryByCookie are set to
+	// the respective `nginx.ingress.kubernetes.io/canary-by-*` annotations on the canary Ingress,
+	// so that requests with the header or cookie are always routed to the canary.
+	CanaryByHeader        string `json:"canaryByHeader,omitempty"`
+	CanaryByHeaderValue   string `json:"canaryByHeaderValue,omitempty"`
+	CanaryByHeaderPattern string `json:"canaryByHeaderPattern,omitempty"`
+	CanaryByCookie        string `json:"canaryByCookie,omitempty"`
+	// BackendSelector selects Services in the namespace of the cell.
+	BackendSelector ServiceSelector `json:"backendSelector,omitempty"`
+}
+
+// CellIngressEnvoy is the configuration for Envoy front proxies that subscribe to okrad's xDS server.
+// Okra creates an EnvoyRouteConfig named after the cell and manages weights of its clusters,
+// one per AWSTargetGroup or ClusterEndpoint.
+type CellIngressEnvoy struct {
+	// NodeCluster is the cluster name of Envoy nodes that receive the route config
+	NodeCluster string        `json:"nodeCluster"`
+	Listener    EnvoyListener `json:"listener,omitempty"`
+	// TargetGroupSelector selects AWSTargetGroups in the namespace of the cell.
+	// The targets of each target group are used as the endpoints of the Envoy cluster.
+	// Exactly one of TargetGroupSelector or EndpointSelector must be specified.
+	TargetGroupSelector *TargetGroupSelector `json:"targetGroupSelector,omitempty"`
+	// EndpointSelector selects ClusterEndpoints in the namespace of the cell.
+	// The addresses of each ClusterEndpoint are used as the endpoints of the Envoy cluster.
+	EndpointSelector *ClusterEndpointSelector `json:"endpointSelector,omitempty"`
+}
+
+// CellIngressRoute53WeightedRecord is the configuration for a set of Route53 weighted record sets
+// that share the same name and type, one per cluster.
+// Okra creates an AWSRoute53WeightedRecordConfig named after the cell and manages weights of its records,
+// one per ClusterEndpoint whose host is the DNS name of the cluster's load balancer.
+type CellIngressRoute53WeightedRecord struct {
+	HostedZoneID string `json:"hostedZoneID"`
+	// Name is the domain name of the record sets, like `web.example.com`
+	Name string `json:"name"`
+	// Type is the DNS record type of the record sets. Defaults to `CNAME`.
+	Type string `json:"type,omitempty"`
+	// TTL is the TTL of the record sets in seconds. Defaults to 60.
+	TTL int64 `json:"ttl,omitempty"`
+	// MinStepDuration is the minimum duration okra waits after every setWeight step,
+	// so that DNS resolvers have a chance to observe the new weights before the next step.
+	// Defaults to the TTL.
+	MinStepDuration *metav1.Duration `json:"minStepDuration,omitempty"`
+	// EndpointSelector selects ClusterEndpoints in the namespace of the cell.
+	// The `okra.mumo.co/route53-health-check-id` annotation on a ClusterEndpoint associates
+	// the health check to the record set.
+	EndpointSelector ClusterEndpointSelector `json:"endpointSelector,omitempty"`
+}
+
+// CellIngressAWSGlobalAccelerator is the configuration for the endpoint groups of an AWS Global Accelerator listener.
+// Okra creates an AWSGlobalAcceleratorConfig named after the cell and manages weights of its endpoints,
+// one per ClusterEndpoint whose awsLoadBalancerARN is the ARN of the cluster's load balancer.
+// Endpoint groups whose endpoints all have zero weights are drained by setting their traffic dials to 0.
+type CellIngressAWSGlobalAccelerator struct {
+	ListenerARN string `json:"listenerARN"`
+	// +optional
+	ClientIPPreservationEnabled *bool `json:"clientIPPreservationEnabled,omitempty"`
+	// EndpointSelector selects ClusterEndpoints in the namespace of the cell.
+	EndpointSelector ClusterEndpointSelector `json:"endpointSelector,omitempty"`
+}
+
+type ClusterEndpointSelector struct {
+	MatchLabels   map[string]string `json:"matchLabels,omitempty"`
+	VersionLabels []string          `json:"versionLabels,omitempty"`
+}
+
+type ServiceSelector struct {
+	MatchLabels   map[string]string `json:"matchLabels,omitempty"`
+	VersionLabels []string          `json:"versionLabels,omitempty"`
+}
+
 type TargetGroupSelector struct {
 	MatchLabels   map[string]string `json:"matchLabels,omitempty"`
 	VersionLabels []string          `json:"versionLabels,omitempty"`
@@ -106,6 +258,44 @@ type CellUpdateStrategyCanary struct {
 	Steps []rolloutsv1alpha1.CanaryStep `json:"steps,omitempty" protobuf:"bytes,3,rep,name=steps"`
 	// Analysis runs a separate analysisRun while all the steps execute. This is intended to be a continuous validation of the new set of clusters
 	Analysis *rolloutsv1alpha1.RolloutAnalysisBackground `json:"analysis,omitempty" protobuf:"bytes,7,opt,name=analysis"`
+	// RequireReachableClusters prevents okra from shifting traffic toward target groups on clusters
+	// whose last connectivity check recorded in ClusterSets in the namespace of the cell failed.
+	// +optional
+	RequireReachableClusters bool `json:"requireReachableClusters,omitempty"`
+	// TargetHealth prevents okra from increasing the canary weight until every canary target group meets the requirement,
+	// and aborts the rollout when any of them stops meeting it in the middle of the rollout.
+	// It is supported only by ingresses routing traffic to AWSTargetGroups, that is, awsApplicationLoadBalancer and envoy with targetGroupSelector.
+	// +optional
+	TargetHealth *CanaryTargetHealth `json:"targetHealth,omitempty"`
+	// Replacement gradually moves the share of clusters replaced by new clusters of the current stable version,
+	// instead of shifting the weight instantly.
+	// +optional
+	Replacement *CanaryReplacement `json:"replacement,omitempty"`
+}
+
+// CanaryReplacement is the mini-canary run when target groups of the current stable version replace
+// the ones that are removed while still receiving traffic, like when hot-swapping a cluster.
+type CanaryReplacement struct {
+	// Steps define the order of phases to move the share of the replaced target groups to the new ones.
+	// Each setWeight is the percentage of the share to move on the step.
+	// The share is moved at once when no steps are specified.
+	// +optional
+	Steps []rolloutsv1alpha1.CanaryStep `json:"steps,omitempty"`
+	// Analysis runs a separate analysisRun while all the steps execute
+	// +optional
+	Analysis *rolloutsv1alpha1.RolloutAnalysisBackground `json:"analysis,omitempty"`
+}
+
+// CanaryTargetHealth is the requirement on the health of the targets of every canary target group,
+// observed in the status of the AWSTargetGroups.
+// When both are omitted, at least one healthy target is required.
+type CanaryTargetHealth struct {
+	// MinHealthyTargets is the minimum number of healthy targets
+	// +optional
+	MinHealthyTargets *int32 `json:"minHealthyTargets,omitempty"`
+	// MinHealthyPercentage is the minimum percentage of healthy targets out of all the registered targets
+	// +optional
+	MinHealthyPercentage *int32 `json:"minHealthyPercentage,omitempty"`
 }
 
 type CellUpdateStrategyBlueGreen struct {
@@ -119,6 +309,10 @@ type CellStatus struct {
 	Phase          string                   `json:"phase"`
 	Reason         string                   `json:"reason"`
 	Message        string                   `json:"message"`
+	// StableVersion is the version of the backends that received all the traffic as of the last sync.
+	// It tells the version of a backend that is gone while still receiving traffic, like the one of a hot-swapped cluster.
+	// +optional
+	StableVersion string `json:"stableVersion,omitempty"`
 }
 
 // +kubebuilder:object:root=true
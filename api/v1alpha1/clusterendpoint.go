@@ -0,0 +1,75 @@
+/*
+Copyright 2020 The Okra authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterEndpointSpec defines the desired state of ClusterEndpoint
+type ClusterEndpointSpec struct {
+	// Host is the hostname that routes traffic to the cluster.
+	// For Istio, this is the host of the destination, like `web.default.svc.cluster.local`.
+	Host string `json:"host"`
+	// Subset is the name of the subset that selects the endpoints in the cluster,
+	// like a subset in an Istio DestinationRule that matches the `topology.istio.io/cluster` label.
+	// +optional
+	Subset string `json:"subset,omitempty"`
+	// Port is the port number of the destination.
+	// +optional
+	Port int32 `json:"port,omitempty"`
+	// Addresses is the static list of the addresses of the cluster in the form of `host:port`.
+	// It is used by the Envoy cell ingress. When empty, `host` and `port` are used instead.
+	// +optional
+	Addresses []string `json:"addresses,omitempty"`
+	// AWSLoadBalancerARN is the ARN of the AWS load balancer that routes traffic to the cluster.
+	// It is used by the AWSGlobalAccelerator cell ingress.
+	// +optional
+	AWSLoadBalancerARN string `json:"awsLoadBalancerARN,omitempty"`
+}
+
+// ClusterEndpointStatus defines the observed state of ClusterEndpoint
+type ClusterEndpointStatus struct {
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:JSONPath=".spec.host",name=Host,type=string
+// +kubebuilder:printcolumn:JSONPath=".spec.subset",name=Subset,type=string
+
+// ClusterEndpoint is a loadbalancer-agnostic endpoint of a cluster.
+// It is labeled with the version of the cluster like AWSTargetGroup is.
+type ClusterEndpoint struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterEndpointSpec   `json:"spec,omitempty"`
+	Status ClusterEndpointStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ClusterEndpointList contains a list of ClusterEndpoint
+type ClusterEndpointList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterEndpoint `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterEndpoint{}, &ClusterEndpointList{})
+}
@@ -6,4 +6,38 @@ const (
 	AWSTargetGroupLabelBindingCluster   = "okra.mumo.co/target-group-binding-cluster"
 	AWSTargetGroupLabelBindingNamespace = "okra.mumo.co/target-group-binding-namespace"
 	AWSTargetGroupLabelBindingName      = "okra.mumo.co/target-group-binding-name"
+	// AWSTargetGroupLabelProvisioner is set to the name prefix of the provision generator that created the target group,
+	// telling okra to delete the target group and its TargetGroupBinding along with the AWSTargetGroup.
+	AWSTargetGroupLabelProvisioner = "okra.mumo.co/provisioner"
+	// AWSTargetGroupLabelSet is set to the name of the AWSTargetGroupSet that generated the AWSTargetGroup,
+	// so that the set deletes only the outdated AWSTargetGroups it generated.
+	AWSTargetGroupLabelSet = "okra.mumo.co/awstargetgroupset"
+
+	ClusterEndpointAnnotationRoute53HealthCheckID = "okra.mumo.co/route53-health-check-id"
+
+	// AnnotationForceDelete allows okra to delete the annotated cluster secret or AWSTargetGroup
+	// even when its target group still receives traffic
+	AnnotationForceDelete = "okra.mumo.co/force-delete"
+
+	// FinalizerTrafficGuard is set on the cluster secrets and AWSTargetGroups generated by okra.
+	// okra removes it only when the cluster or the target group no longer receives traffic, or the deletion is forced,
+	// so that deleting them by hand never drops live traffic.
+	FinalizerTrafficGuard = "okra.mumo.co/traffic-guard"
+
+	// ConditionTypeReady is the type of the condition that tells if the last sync succeeded for all the members
+	ConditionTypeReady = "Ready"
+
+	// ConditionTypeDecommissionable is the type of the condition that tells if the target group has been drained,
+	// by staying at zero weight for the bake time plus its deregistration delay
+	ConditionTypeDecommissionable = "Decommissionable"
+
+	// LabelDecommissionable is set to "true" on the cluster secret whose target groups are all decommissionable
+	LabelDecommissionable = "okra.mumo.co/decommissionable"
+	// AnnotationDecommissionableSince is the time the cluster secret became decommissionable, in RFC 3339
+	AnnotationDecommissionableSince = "okra.mumo.co/decommissionable-since"
+
+	// ClusterConnectionStatusSuccessful means that the last connectivity check against the cluster succeeded
+	ClusterConnectionStatusSuccessful = "Successful"
+	// ClusterConnectionStatusFailed means that the last connectivity check against the cluster failed
+	ClusterConnectionStatusFailed = "Failed"
 )
@@ -0,0 +1,91 @@
+/*
+Copyright 2020 The Okra authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EnvoyRouteConfigSpec defines the desired state of EnvoyRouteConfig
+type EnvoyRouteConfigSpec struct {
+	// NodeCluster is the cluster name of Envoy nodes, specified via `--service-cluster` or `node.cluster`,
+	// that should receive this route config via xDS.
+	NodeCluster string `json:"nodeCluster"`
+
+	Listener EnvoyListener `json:"listener,omitempty"`
+
+	// Clusters is the list of weighted Envoy clusters the traffic is routed to.
+	// Each Envoy cluster corresponds to an okra cluster.
+	Clusters []EnvoyCluster `json:"clusters,omitempty"`
+}
+
+type EnvoyListener struct {
+	// Port is the port number Envoy listens on for the route.
+	// Route configs for the same node cluster and port are served from the same listener.
+	Port int32 `json:"port,omitempty"`
+	// Domains is the list of domains of the virtual host. Defaults to `*`.
+	Domains []string `json:"domains,omitempty"`
+	// PathPrefix is the prefix of the path to be matched. Defaults to `/`.
+	PathPrefix string `json:"pathPrefix,omitempty"`
+}
+
+type EnvoyCluster struct {
+	Name   string `json:"name"`
+	Weight int    `json:"weight,omitempty"`
+	// TargetGroupARN is the ARN of the AWS target group whose targets are used as the endpoints of the cluster.
+	// Only targets registered by IP addresses are supported.
+	TargetGroupARN string `json:"targetGroupARN,omitempty"`
+	// Addresses is the static list of the endpoints of the cluster in the form of `host:port`.
+	Addresses []string `json:"addresses,omitempty"`
+}
+
+// EnvoyRouteConfigStatus defines the observed state of EnvoyRouteConfig
+type EnvoyRouteConfigStatus struct {
+	LastSyncTime metav1.Time `json:"lastSyncTime"`
+	// Version is the version of the xDS snapshot that contains this route config
+	Version string `json:"version"`
+	Phase   string `json:"phase"`
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:JSONPath=".spec.nodeCluster",name=Node Cluster,type=string
+// +kubebuilder:printcolumn:JSONPath=".status.lastSyncTime",name=Last Sync,type=date
+
+// EnvoyRouteConfig is the Schema for the EnvoyRouteConfig API
+type EnvoyRouteConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   EnvoyRouteConfigSpec   `json:"spec,omitempty"`
+	Status EnvoyRouteConfigStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// EnvoyRouteConfigList contains a list of EnvoyRouteConfig
+type EnvoyRouteConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []EnvoyRouteConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&EnvoyRouteConfig{}, &EnvoyRouteConfigList{})
+}
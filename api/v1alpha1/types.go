@@ -24,26 +24,111 @@ import (
 type ClusterSetSpec struct {
 	Generators []ClusterGenerator    `json:"generators,omitempty"`
 	Template   ClusterSecretTemplate `json:"template"`
+
+	// HealthCheck enables periodic connectivity checks against the generated clusters.
+	// +optional
+	HealthCheck *ClusterSetHealthCheck `json:"healthCheck,omitempty"`
+}
+
+// ClusterSetHealthCheck configures the connectivity checks against the clusters of a ClusterSet.
+// Each check requests `/readyz` and the server version of the cluster with the generated cluster secret.
+type ClusterSetHealthCheck struct {
+	// Interval is the interval between checks. Defaults to 1m.
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+	// Timeout is the timeout of the requests to each cluster. Defaults to 10s.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
 }
 
+// ClusterGenerator generates cluster secrets.
+// Exactly one of the fields should be set.
 type ClusterGenerator struct {
-	AWSEKS AWSEKSClusterGenerator `json:"awseks,omitempty"`
+	ClusterBaseGenerator `json:",inline"`
+
+	// Matrix generates cluster secrets for the clusters generated by all the generators,
+	// with the labels merged in order.
+	Matrix *ClusterCombinationGenerator `json:"matrix,omitempty"`
+	// Merge generates cluster secrets for the clusters generated by the first generator,
+	// with the labels merged with the ones of the same clusters generated by the rest of the generators.
+	Merge *ClusterCombinationGenerator `json:"merge,omitempty"`
+}
+
+// ClusterBaseGenerator is a cluster generator that can be nested in a matrix or merge generator.
+type ClusterBaseGenerator struct {
+	AWSEKS     *AWSEKSClusterGenerator     `json:"awseks,omitempty"`
+	ClusterAPI *ClusterAPIClusterGenerator `json:"clusterAPI,omitempty"`
+}
+
+type ClusterCombinationGenerator struct {
+	Generators []ClusterBaseGenerator `json:"generators"`
 }
 
 type AWSEKSClusterGenerator struct {
 	Selector AWSEKSClusterSelector `json:"selector,omitempty"`
+	// Sources are the regions and the accounts to discover EKS clusters.
+	// When empty, clusters are discovered in the region and the account of the controller.
+	// +optional
+	Sources []AWSEKSClusterSource `json:"sources,omitempty"`
+}
+
+// AWSEKSClusterSource is a region and an optional IAM role to discover EKS clusters.
+type AWSEKSClusterSource struct {
+	// Region is the region to discover EKS clusters. Defaults to the region of the controller.
+	// +optional
+	Region string `json:"region,omitempty"`
+	// RoleARN is the ARN of the IAM role to assume for discovering EKS clusters in another account.
+	// It is also set to the awsAuthConfig of the generated cluster secrets, so that ArgoCD and okra
+	// assume the role to authenticate to the clusters.
+	// +optional
+	RoleARN string `json:"roleARN,omitempty"`
+	// ExternalID is the external ID to assume the role.
+	// +optional
+	ExternalID string `json:"externalID,omitempty"`
 }
 
 type AWSEKSClusterSelector struct {
 	MatchTags map[string]string `json:"matchTags,omitempty"`
 }
 
+// ClusterAPIClusterGenerator generates cluster secrets from Cluster API `Cluster` objects
+// and their `<name>-kubeconfig` secrets.
+type ClusterAPIClusterGenerator struct {
+	// Namespace is the namespace of the Cluster API clusters.
+	// Defaults to the namespace of the ClusterSet.
+	// +optional
+	Namespace string                    `json:"namespace,omitempty"`
+	Selector  ClusterAPIClusterSelector `json:"selector,omitempty"`
+}
+
+type ClusterAPIClusterSelector struct {
+	MatchLabels map[string]string `json:"matchLabels,omitempty"`
+}
+
+// ClusterSecretTemplate is the template of the cluster secrets.
+// Every value can be a Go template that is evaluated against the attributes of the discovered cluster,
+// like `{{.awseks.cluster.tags.version}}`.
 type ClusterSecretTemplate struct {
 	Metadata ClusterSecretTemplateMetadata `json:"metadata"`
+
+	// Project is the ArgoCD project the cluster belongs to.
+	// +optional
+	Project string `json:"project,omitempty"`
+
+	// Namespaces is the list of namespaces ArgoCD is allowed to manage in the cluster.
+	// +optional
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// ClusterResources allows ArgoCD to manage cluster-scoped resources when Namespaces is set.
+	// +optional
+	ClusterResources bool `json:"clusterResources,omitempty"`
 }
 
 type ClusterSecretTemplateMetadata struct {
 	Labels map[string]string `json:"labels"`
+
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
 }
 
 // ClusterSetStatus defines the observed state of ClusterSet
@@ -53,15 +138,70 @@ type ClusterSetStatus struct {
 	Phase        string                   `json:"phase"`
 	Reason       string                   `json:"reason"`
 	Message      string                   `json:"message"`
+
+	// Discovery is the stats of the EKS API calls made in the last sync
+	// +optional
+	Discovery *ClusterSetStatusDiscovery `json:"discovery,omitempty"`
+
+	// ClusterHealth is the result of the last connectivity check per cluster
+	// +optional
+	ClusterHealth []ClusterHealth `json:"clusterHealth,omitempty"`
+
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
-// ClusterSetStatusClusters contains runner registration status
+// ClusterSetStatusDiscovery contains the stats of the EKS API calls made to discover clusters
+type ClusterSetStatusDiscovery struct {
+	ListClustersCalls    int64           `json:"listClustersCalls"`
+	DescribeClusterCalls int64           `json:"describeClusterCalls"`
+	CacheHits            int64           `json:"cacheHits"`
+	Throttles            int64           `json:"throttles"`
+	Duration             metav1.Duration `json:"duration"`
+}
+
+// ClusterSetStatusClusters contains the clusters observed in the last sync
 type ClusterSetStatusClusters struct {
+	// Names are the names of all the discovered clusters
 	Names []string `json:"names,omitempty"`
+
+	// +optional
+	Created []string `json:"created,omitempty"`
+	// +optional
+	Updated []string `json:"updated,omitempty"`
+	// +optional
+	Unchanged []string `json:"unchanged,omitempty"`
+	// +optional
+	Deleted []string `json:"deleted,omitempty"`
+
+	// Errors are the errors occurred while syncing the members
+	// +optional
+	Errors []MemberError `json:"errors,omitempty"`
+}
+
+// ClusterHealth is the result of the last connectivity check against a cluster
+type ClusterHealth struct {
+	Name string `json:"name"`
+	// Status is either Successful or Failed
+	Status string `json:"status"`
+	// +optional
+	Message string `json:"message,omitempty"`
+	// +optional
+	ServerVersion string      `json:"serverVersion,omitempty"`
+	LastProbeTime metav1.Time `json:"lastProbeTime"`
+	// LastTransitionTime is the time the status changed last time
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+}
+
+// MemberError is the error occurred while syncing a member of a ClusterSet or an AWSTargetGroupSet
+type MemberError struct {
+	Name    string `json:"name"`
+	Message string `json:"message"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:JSONPath=".status.conditions[?(@.type=='Ready')].status",name=Ready,type=string
 // +kubebuilder:printcolumn:JSONPath=".status.lastSyncTime",name=Last Sync,type=date
 
 // ClusterSet is the Schema for the ClusterSet API
@@ -22,7 +22,11 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"github.com/mumoshu/okra/api/gateway/v1beta1"
+	istiov1beta1 "github.com/mumoshu/okra/api/istio/v1beta1"
 	rolloutsv1alpha1 "github.com/mumoshu/okra/api/rollouts/v1alpha1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -121,6 +125,11 @@ func (in *AWSApplicationLoadBalancerConfigStatus) DeepCopy() *AWSApplicationLoad
 func (in *AWSEKSClusterGenerator) DeepCopyInto(out *AWSEKSClusterGenerator) {
 	*out = *in
 	in.Selector.DeepCopyInto(&out.Selector)
+	if in.Sources != nil {
+		in, out := &in.Sources, &out.Sources
+		*out = make([]AWSEKSClusterSource, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSEKSClusterGenerator.
@@ -156,99 +165,22 @@ func (in *AWSEKSClusterSelector) DeepCopy() *AWSEKSClusterSelector {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *AWSTargetGroup) DeepCopyInto(out *AWSTargetGroup) {
-	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
-	in.Status.DeepCopyInto(&out.Status)
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSTargetGroup.
-func (in *AWSTargetGroup) DeepCopy() *AWSTargetGroup {
-	if in == nil {
-		return nil
-	}
-	out := new(AWSTargetGroup)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *AWSTargetGroup) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *AWSTargetGroupGenerator) DeepCopyInto(out *AWSTargetGroupGenerator) {
-	*out = *in
-	in.AWSEKS.DeepCopyInto(&out.AWSEKS)
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSTargetGroupGenerator.
-func (in *AWSTargetGroupGenerator) DeepCopy() *AWSTargetGroupGenerator {
-	if in == nil {
-		return nil
-	}
-	out := new(AWSTargetGroupGenerator)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *AWSTargetGroupGeneratorAWSEKS) DeepCopyInto(out *AWSTargetGroupGeneratorAWSEKS) {
-	*out = *in
-	in.ClusterSelector.DeepCopyInto(&out.ClusterSelector)
-	in.BindingSelector.DeepCopyInto(&out.BindingSelector)
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSTargetGroupGeneratorAWSEKS.
-func (in *AWSTargetGroupGeneratorAWSEKS) DeepCopy() *AWSTargetGroupGeneratorAWSEKS {
-	if in == nil {
-		return nil
-	}
-	out := new(AWSTargetGroupGeneratorAWSEKS)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *AWSTargetGroupList) DeepCopyInto(out *AWSTargetGroupList) {
+func (in *AWSEKSClusterSource) DeepCopyInto(out *AWSEKSClusterSource) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ListMeta.DeepCopyInto(&out.ListMeta)
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]AWSTargetGroup, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSTargetGroupList.
-func (in *AWSTargetGroupList) DeepCopy() *AWSTargetGroupList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSEKSClusterSource.
+func (in *AWSEKSClusterSource) DeepCopy() *AWSEKSClusterSource {
 	if in == nil {
 		return nil
 	}
-	out := new(AWSTargetGroupList)
+	out := new(AWSEKSClusterSource)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *AWSTargetGroupList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *AWSTargetGroupSet) DeepCopyInto(out *AWSTargetGroupSet) {
+func (in *AWSGlobalAcceleratorConfig) DeepCopyInto(out *AWSGlobalAcceleratorConfig) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
@@ -256,18 +188,18 @@ func (in *AWSTargetGroupSet) DeepCopyInto(out *AWSTargetGroupSet) {
 	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSTargetGroupSet.
-func (in *AWSTargetGroupSet) DeepCopy() *AWSTargetGroupSet {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSGlobalAcceleratorConfig.
+func (in *AWSGlobalAcceleratorConfig) DeepCopy() *AWSGlobalAcceleratorConfig {
 	if in == nil {
 		return nil
 	}
-	out := new(AWSTargetGroupSet)
+	out := new(AWSGlobalAcceleratorConfig)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *AWSTargetGroupSet) DeepCopyObject() runtime.Object {
+func (in *AWSGlobalAcceleratorConfig) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -275,31 +207,31 @@ func (in *AWSTargetGroupSet) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *AWSTargetGroupSetList) DeepCopyInto(out *AWSTargetGroupSetList) {
+func (in *AWSGlobalAcceleratorConfigList) DeepCopyInto(out *AWSGlobalAcceleratorConfigList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]AWSTargetGroupSet, len(*in))
+		*out = make([]AWSGlobalAcceleratorConfig, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSTargetGroupSetList.
-func (in *AWSTargetGroupSetList) DeepCopy() *AWSTargetGroupSetList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSGlobalAcceleratorConfigList.
+func (in *AWSGlobalAcceleratorConfigList) DeepCopy() *AWSGlobalAcceleratorConfigList {
 	if in == nil {
 		return nil
 	}
-	out := new(AWSTargetGroupSetList)
+	out := new(AWSGlobalAcceleratorConfigList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *AWSTargetGroupSetList) DeepCopyObject() runtime.Object {
+func (in *AWSGlobalAcceleratorConfigList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -307,116 +239,100 @@ func (in *AWSTargetGroupSetList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *AWSTargetGroupSetSpec) DeepCopyInto(out *AWSTargetGroupSetSpec) {
+func (in *AWSGlobalAcceleratorConfigSpec) DeepCopyInto(out *AWSGlobalAcceleratorConfigSpec) {
 	*out = *in
-	if in.Generators != nil {
-		in, out := &in.Generators, &out.Generators
-		*out = make([]AWSTargetGroupGenerator, len(*in))
+	if in.Endpoints != nil {
+		in, out := &in.Endpoints, &out.Endpoints
+		*out = make([]AWSGlobalAcceleratorEndpoint, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-	in.Template.DeepCopyInto(&out.Template)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSTargetGroupSetSpec.
-func (in *AWSTargetGroupSetSpec) DeepCopy() *AWSTargetGroupSetSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSGlobalAcceleratorConfigSpec.
+func (in *AWSGlobalAcceleratorConfigSpec) DeepCopy() *AWSGlobalAcceleratorConfigSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(AWSTargetGroupSetSpec)
+	out := new(AWSGlobalAcceleratorConfigSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *AWSTargetGroupSetStatus) DeepCopyInto(out *AWSTargetGroupSetStatus) {
+func (in *AWSGlobalAcceleratorConfigStatus) DeepCopyInto(out *AWSGlobalAcceleratorConfigStatus) {
 	*out = *in
 	in.LastSyncTime.DeepCopyInto(&out.LastSyncTime)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSTargetGroupSetStatus.
-func (in *AWSTargetGroupSetStatus) DeepCopy() *AWSTargetGroupSetStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSGlobalAcceleratorConfigStatus.
+func (in *AWSGlobalAcceleratorConfigStatus) DeepCopy() *AWSGlobalAcceleratorConfigStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(AWSTargetGroupSetStatus)
+	out := new(AWSGlobalAcceleratorConfigStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *AWSTargetGroupSpec) DeepCopyInto(out *AWSTargetGroupSpec) {
+func (in *AWSGlobalAcceleratorEndpoint) DeepCopyInto(out *AWSGlobalAcceleratorEndpoint) {
 	*out = *in
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSTargetGroupSpec.
-func (in *AWSTargetGroupSpec) DeepCopy() *AWSTargetGroupSpec {
-	if in == nil {
-		return nil
+	if in.ClientIPPreservationEnabled != nil {
+		in, out := &in.ClientIPPreservationEnabled, &out.ClientIPPreservationEnabled
+		*out = new(bool)
+		**out = **in
 	}
-	out := new(AWSTargetGroupSpec)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *AWSTargetGroupStatus) DeepCopyInto(out *AWSTargetGroupStatus) {
-	*out = *in
-	in.Clusters.DeepCopyInto(&out.Clusters)
-	in.LastSyncTime.DeepCopyInto(&out.LastSyncTime)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSTargetGroupStatus.
-func (in *AWSTargetGroupStatus) DeepCopy() *AWSTargetGroupStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSGlobalAcceleratorEndpoint.
+func (in *AWSGlobalAcceleratorEndpoint) DeepCopy() *AWSGlobalAcceleratorEndpoint {
 	if in == nil {
 		return nil
 	}
-	out := new(AWSTargetGroupStatus)
+	out := new(AWSGlobalAcceleratorEndpoint)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *AWSTargetGroupTemplate) DeepCopyInto(out *AWSTargetGroupTemplate) {
+func (in *AWSRoute53ManagedRecordSets) DeepCopyInto(out *AWSRoute53ManagedRecordSets) {
 	*out = *in
-	in.Metadata.DeepCopyInto(&out.Metadata)
+	if in.SetIdentifiers != nil {
+		in, out := &in.SetIdentifiers, &out.SetIdentifiers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSTargetGroupTemplate.
-func (in *AWSTargetGroupTemplate) DeepCopy() *AWSTargetGroupTemplate {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSRoute53ManagedRecordSets.
+func (in *AWSRoute53ManagedRecordSets) DeepCopy() *AWSRoute53ManagedRecordSets {
 	if in == nil {
 		return nil
 	}
-	out := new(AWSTargetGroupTemplate)
+	out := new(AWSRoute53ManagedRecordSets)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *AWSTargetGroupTemplateMetadata) DeepCopyInto(out *AWSTargetGroupTemplateMetadata) {
+func (in *AWSRoute53WeightedRecord) DeepCopyInto(out *AWSRoute53WeightedRecord) {
 	*out = *in
-	if in.Labels != nil {
-		in, out := &in.Labels, &out.Labels
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSTargetGroupTemplateMetadata.
-func (in *AWSTargetGroupTemplateMetadata) DeepCopy() *AWSTargetGroupTemplateMetadata {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSRoute53WeightedRecord.
+func (in *AWSRoute53WeightedRecord) DeepCopy() *AWSRoute53WeightedRecord {
 	if in == nil {
 		return nil
 	}
-	out := new(AWSTargetGroupTemplateMetadata)
+	out := new(AWSRoute53WeightedRecord)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Cell) DeepCopyInto(out *Cell) {
+func (in *AWSRoute53WeightedRecordConfig) DeepCopyInto(out *AWSRoute53WeightedRecordConfig) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
@@ -424,18 +340,18 @@ func (in *Cell) DeepCopyInto(out *Cell) {
 	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Cell.
-func (in *Cell) DeepCopy() *Cell {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSRoute53WeightedRecordConfig.
+func (in *AWSRoute53WeightedRecordConfig) DeepCopy() *AWSRoute53WeightedRecordConfig {
 	if in == nil {
 		return nil
 	}
-	out := new(Cell)
+	out := new(AWSRoute53WeightedRecordConfig)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *Cell) DeepCopyObject() runtime.Object {
+func (in *AWSRoute53WeightedRecordConfig) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -443,89 +359,99 @@ func (in *Cell) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CellIngress) DeepCopyInto(out *CellIngress) {
+func (in *AWSRoute53WeightedRecordConfigList) DeepCopyInto(out *AWSRoute53WeightedRecordConfigList) {
 	*out = *in
-	if in.AWSApplicationLoadBalancer != nil {
-		in, out := &in.AWSApplicationLoadBalancer, &out.AWSApplicationLoadBalancer
-		*out = new(CellIngressAWSApplicationLoadBalancer)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.AWSNetworkLoadBalancer != nil {
-		in, out := &in.AWSNetworkLoadBalancer, &out.AWSNetworkLoadBalancer
-		*out = new(CellIngressAWSNetworkLoadBalancer)
-		(*in).DeepCopyInto(*out)
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]AWSRoute53WeightedRecordConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CellIngress.
-func (in *CellIngress) DeepCopy() *CellIngress {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSRoute53WeightedRecordConfigList.
+func (in *AWSRoute53WeightedRecordConfigList) DeepCopy() *AWSRoute53WeightedRecordConfigList {
 	if in == nil {
 		return nil
 	}
-	out := new(CellIngress)
+	out := new(AWSRoute53WeightedRecordConfigList)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AWSRoute53WeightedRecordConfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CellIngressAWSApplicationLoadBalancer) DeepCopyInto(out *CellIngressAWSApplicationLoadBalancer) {
+func (in *AWSRoute53WeightedRecordConfigSpec) DeepCopyInto(out *AWSRoute53WeightedRecordConfigSpec) {
 	*out = *in
-	in.Listener.DeepCopyInto(&out.Listener)
-	in.TargetGroupSelector.DeepCopyInto(&out.TargetGroupSelector)
+	if in.Records != nil {
+		in, out := &in.Records, &out.Records
+		*out = make([]AWSRoute53WeightedRecord, len(*in))
+		copy(*out, *in)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CellIngressAWSApplicationLoadBalancer.
-func (in *CellIngressAWSApplicationLoadBalancer) DeepCopy() *CellIngressAWSApplicationLoadBalancer {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSRoute53WeightedRecordConfigSpec.
+func (in *AWSRoute53WeightedRecordConfigSpec) DeepCopy() *AWSRoute53WeightedRecordConfigSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(CellIngressAWSApplicationLoadBalancer)
+	out := new(AWSRoute53WeightedRecordConfigSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CellIngressAWSNetworkLoadBalancer) DeepCopyInto(out *CellIngressAWSNetworkLoadBalancer) {
+func (in *AWSRoute53WeightedRecordConfigStatus) DeepCopyInto(out *AWSRoute53WeightedRecordConfigStatus) {
 	*out = *in
-	in.TargetGroupSelector.DeepCopyInto(&out.TargetGroupSelector)
+	in.LastSyncTime.DeepCopyInto(&out.LastSyncTime)
+	if in.Managed != nil {
+		in, out := &in.Managed, &out.Managed
+		*out = new(AWSRoute53ManagedRecordSets)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CellIngressAWSNetworkLoadBalancer.
-func (in *CellIngressAWSNetworkLoadBalancer) DeepCopy() *CellIngressAWSNetworkLoadBalancer {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSRoute53WeightedRecordConfigStatus.
+func (in *AWSRoute53WeightedRecordConfigStatus) DeepCopy() *AWSRoute53WeightedRecordConfigStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(CellIngressAWSNetworkLoadBalancer)
+	out := new(AWSRoute53WeightedRecordConfigStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CellList) DeepCopyInto(out *CellList) {
+func (in *AWSTargetGroup) DeepCopyInto(out *AWSTargetGroup) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
-	in.ListMeta.DeepCopyInto(&out.ListMeta)
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]Cell, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CellList.
-func (in *CellList) DeepCopy() *CellList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSTargetGroup.
+func (in *AWSTargetGroup) DeepCopy() *AWSTargetGroup {
 	if in == nil {
 		return nil
 	}
-	out := new(CellList)
+	out := new(AWSTargetGroup)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *CellList) DeepCopyObject() runtime.Object {
+func (in *AWSTargetGroup) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -533,123 +459,1261 @@ func (in *CellList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CellSpec) DeepCopyInto(out *CellSpec) {
+func (in *AWSTargetGroupBaseGenerator) DeepCopyInto(out *AWSTargetGroupBaseGenerator) {
 	*out = *in
-	in.Ingress.DeepCopyInto(&out.Ingress)
-	if in.Replicas != nil {
-		in, out := &in.Replicas, &out.Replicas
-		*out = new(int32)
-		**out = **in
+	if in.AWSEKS != nil {
+		in, out := &in.AWSEKS, &out.AWSEKS
+		*out = new(AWSTargetGroupGeneratorAWSEKS)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AWSTags != nil {
+		in, out := &in.AWSTags, &out.AWSTags
+		*out = new(AWSTargetGroupGeneratorAWSTags)
+		(*in).DeepCopyInto(*out)
 	}
-	in.UpdateStrategy.DeepCopyInto(&out.UpdateStrategy)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CellSpec.
-func (in *CellSpec) DeepCopy() *CellSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSTargetGroupBaseGenerator.
+func (in *AWSTargetGroupBaseGenerator) DeepCopy() *AWSTargetGroupBaseGenerator {
 	if in == nil {
 		return nil
 	}
-	out := new(CellSpec)
+	out := new(AWSTargetGroupBaseGenerator)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CellStatus) DeepCopyInto(out *CellStatus) {
+func (in *AWSTargetGroupCombinationGenerator) DeepCopyInto(out *AWSTargetGroupCombinationGenerator) {
 	*out = *in
-	in.Clusters.DeepCopyInto(&out.Clusters)
-	in.LastSyncTime.DeepCopyInto(&out.LastSyncTime)
+	if in.Generators != nil {
+		in, out := &in.Generators, &out.Generators
+		*out = make([]AWSTargetGroupBaseGenerator, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CellStatus.
-func (in *CellStatus) DeepCopy() *CellStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSTargetGroupCombinationGenerator.
+func (in *AWSTargetGroupCombinationGenerator) DeepCopy() *AWSTargetGroupCombinationGenerator {
 	if in == nil {
 		return nil
 	}
-	out := new(CellStatus)
+	out := new(AWSTargetGroupCombinationGenerator)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CellUpdateStrategy) DeepCopyInto(out *CellUpdateStrategy) {
+func (in *AWSTargetGroupGenerator) DeepCopyInto(out *AWSTargetGroupGenerator) {
 	*out = *in
-	if in.Canary != nil {
-		in, out := &in.Canary, &out.Canary
-		*out = new(CellUpdateStrategyCanary)
+	in.AWSTargetGroupBaseGenerator.DeepCopyInto(&out.AWSTargetGroupBaseGenerator)
+	if in.Matrix != nil {
+		in, out := &in.Matrix, &out.Matrix
+		*out = new(AWSTargetGroupCombinationGenerator)
 		(*in).DeepCopyInto(*out)
 	}
-	if in.BlueGreen != nil {
-		in, out := &in.BlueGreen, &out.BlueGreen
-		*out = new(CellUpdateStrategyBlueGreen)
-		**out = **in
+	if in.Merge != nil {
+		in, out := &in.Merge, &out.Merge
+		*out = new(AWSTargetGroupCombinationGenerator)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Provision != nil {
+		in, out := &in.Provision, &out.Provision
+		*out = new(AWSTargetGroupProvisionGenerator)
+		(*in).DeepCopyInto(*out)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CellUpdateStrategy.
-func (in *CellUpdateStrategy) DeepCopy() *CellUpdateStrategy {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSTargetGroupGenerator.
+func (in *AWSTargetGroupGenerator) DeepCopy() *AWSTargetGroupGenerator {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSTargetGroupGenerator)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSTargetGroupGeneratorAWSEKS) DeepCopyInto(out *AWSTargetGroupGeneratorAWSEKS) {
+	*out = *in
+	in.ClusterSelector.DeepCopyInto(&out.ClusterSelector)
+	in.BindingSelector.DeepCopyInto(&out.BindingSelector)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSTargetGroupGeneratorAWSEKS.
+func (in *AWSTargetGroupGeneratorAWSEKS) DeepCopy() *AWSTargetGroupGeneratorAWSEKS {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSTargetGroupGeneratorAWSEKS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSTargetGroupGeneratorAWSTags) DeepCopyInto(out *AWSTargetGroupGeneratorAWSTags) {
+	*out = *in
+	if in.MatchTags != nil {
+		in, out := &in.MatchTags, &out.MatchTags
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.LabelsFromTags != nil {
+		in, out := &in.LabelsFromTags, &out.LabelsFromTags
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSTargetGroupGeneratorAWSTags.
+func (in *AWSTargetGroupGeneratorAWSTags) DeepCopy() *AWSTargetGroupGeneratorAWSTags {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSTargetGroupGeneratorAWSTags)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSTargetGroupHealthCheck) DeepCopyInto(out *AWSTargetGroupHealthCheck) {
+	*out = *in
+	if in.IntervalSeconds != nil {
+		in, out := &in.IntervalSeconds, &out.IntervalSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.TimeoutSeconds != nil {
+		in, out := &in.TimeoutSeconds, &out.TimeoutSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.HealthyThresholdCount != nil {
+		in, out := &in.HealthyThresholdCount, &out.HealthyThresholdCount
+		*out = new(int64)
+		**out = **in
+	}
+	if in.UnhealthyThresholdCount != nil {
+		in, out := &in.UnhealthyThresholdCount, &out.UnhealthyThresholdCount
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSTargetGroupHealthCheck.
+func (in *AWSTargetGroupHealthCheck) DeepCopy() *AWSTargetGroupHealthCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSTargetGroupHealthCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSTargetGroupList) DeepCopyInto(out *AWSTargetGroupList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]AWSTargetGroup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSTargetGroupList.
+func (in *AWSTargetGroupList) DeepCopy() *AWSTargetGroupList {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSTargetGroupList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AWSTargetGroupList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSTargetGroupProvisionGenerator) DeepCopyInto(out *AWSTargetGroupProvisionGenerator) {
+	*out = *in
+	in.ClusterSelector.DeepCopyInto(&out.ClusterSelector)
+	in.TargetGroup.DeepCopyInto(&out.TargetGroup)
+	in.Binding.DeepCopyInto(&out.Binding)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSTargetGroupProvisionGenerator.
+func (in *AWSTargetGroupProvisionGenerator) DeepCopy() *AWSTargetGroupProvisionGenerator {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSTargetGroupProvisionGenerator)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSTargetGroupProvisionTemplate) DeepCopyInto(out *AWSTargetGroupProvisionTemplate) {
+	*out = *in
+	if in.HealthCheck != nil {
+		in, out := &in.HealthCheck, &out.HealthCheck
+		*out = new(AWSTargetGroupHealthCheck)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DeregistrationDelaySeconds != nil {
+		in, out := &in.DeregistrationDelaySeconds, &out.DeregistrationDelaySeconds
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSTargetGroupProvisionTemplate.
+func (in *AWSTargetGroupProvisionTemplate) DeepCopy() *AWSTargetGroupProvisionTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSTargetGroupProvisionTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSTargetGroupSet) DeepCopyInto(out *AWSTargetGroupSet) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSTargetGroupSet.
+func (in *AWSTargetGroupSet) DeepCopy() *AWSTargetGroupSet {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSTargetGroupSet)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AWSTargetGroupSet) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSTargetGroupSetList) DeepCopyInto(out *AWSTargetGroupSetList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]AWSTargetGroupSet, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSTargetGroupSetList.
+func (in *AWSTargetGroupSetList) DeepCopy() *AWSTargetGroupSetList {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSTargetGroupSetList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AWSTargetGroupSetList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSTargetGroupSetSpec) DeepCopyInto(out *AWSTargetGroupSetSpec) {
+	*out = *in
+	if in.Generators != nil {
+		in, out := &in.Generators, &out.Generators
+		*out = make([]AWSTargetGroupGenerator, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.Template.DeepCopyInto(&out.Template)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSTargetGroupSetSpec.
+func (in *AWSTargetGroupSetSpec) DeepCopy() *AWSTargetGroupSetSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSTargetGroupSetSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSTargetGroupSetStatus) DeepCopyInto(out *AWSTargetGroupSetStatus) {
+	*out = *in
+	in.TargetGroups.DeepCopyInto(&out.TargetGroups)
+	in.LastSyncTime.DeepCopyInto(&out.LastSyncTime)
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSTargetGroupSetStatus.
+func (in *AWSTargetGroupSetStatus) DeepCopy() *AWSTargetGroupSetStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSTargetGroupSetStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSTargetGroupSetStatusTargetGroups) DeepCopyInto(out *AWSTargetGroupSetStatusTargetGroups) {
+	*out = *in
+	if in.Names != nil {
+		in, out := &in.Names, &out.Names
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Created != nil {
+		in, out := &in.Created, &out.Created
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Updated != nil {
+		in, out := &in.Updated, &out.Updated
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Unchanged != nil {
+		in, out := &in.Unchanged, &out.Unchanged
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Deleted != nil {
+		in, out := &in.Deleted, &out.Deleted
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Errors != nil {
+		in, out := &in.Errors, &out.Errors
+		*out = make([]MemberError, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSTargetGroupSetStatusTargetGroups.
+func (in *AWSTargetGroupSetStatusTargetGroups) DeepCopy() *AWSTargetGroupSetStatusTargetGroups {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSTargetGroupSetStatusTargetGroups)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSTargetGroupSpec) DeepCopyInto(out *AWSTargetGroupSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSTargetGroupSpec.
+func (in *AWSTargetGroupSpec) DeepCopy() *AWSTargetGroupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSTargetGroupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSTargetGroupStatus) DeepCopyInto(out *AWSTargetGroupStatus) {
+	*out = *in
+	in.Clusters.DeepCopyInto(&out.Clusters)
+	in.LastSyncTime.DeepCopyInto(&out.LastSyncTime)
+	if in.TargetHealth != nil {
+		in, out := &in.TargetHealth, &out.TargetHealth
+		*out = new(AWSTargetGroupTargetHealth)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSTargetGroupStatus.
+func (in *AWSTargetGroupStatus) DeepCopy() *AWSTargetGroupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSTargetGroupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSTargetGroupTargetHealth) DeepCopyInto(out *AWSTargetGroupTargetHealth) {
+	*out = *in
+	in.LastProbeTime.DeepCopyInto(&out.LastProbeTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSTargetGroupTargetHealth.
+func (in *AWSTargetGroupTargetHealth) DeepCopy() *AWSTargetGroupTargetHealth {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSTargetGroupTargetHealth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSTargetGroupTemplate) DeepCopyInto(out *AWSTargetGroupTemplate) {
+	*out = *in
+	in.Metadata.DeepCopyInto(&out.Metadata)
+	if in.LabelsFromCluster != nil {
+		in, out := &in.LabelsFromCluster, &out.LabelsFromCluster
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSTargetGroupTemplate.
+func (in *AWSTargetGroupTemplate) DeepCopy() *AWSTargetGroupTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSTargetGroupTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSTargetGroupTemplateMetadata) DeepCopyInto(out *AWSTargetGroupTemplateMetadata) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSTargetGroupTemplateMetadata.
+func (in *AWSTargetGroupTemplateMetadata) DeepCopy() *AWSTargetGroupTemplateMetadata {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSTargetGroupTemplateMetadata)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CanaryReplacement) DeepCopyInto(out *CanaryReplacement) {
+	*out = *in
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]rolloutsv1alpha1.CanaryStep, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Analysis != nil {
+		in, out := &in.Analysis, &out.Analysis
+		*out = new(rolloutsv1alpha1.RolloutAnalysisBackground)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryReplacement.
+func (in *CanaryReplacement) DeepCopy() *CanaryReplacement {
+	if in == nil {
+		return nil
+	}
+	out := new(CanaryReplacement)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CanaryTargetHealth) DeepCopyInto(out *CanaryTargetHealth) {
+	*out = *in
+	if in.MinHealthyTargets != nil {
+		in, out := &in.MinHealthyTargets, &out.MinHealthyTargets
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MinHealthyPercentage != nil {
+		in, out := &in.MinHealthyPercentage, &out.MinHealthyPercentage
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryTargetHealth.
+func (in *CanaryTargetHealth) DeepCopy() *CanaryTargetHealth {
+	if in == nil {
+		return nil
+	}
+	out := new(CanaryTargetHealth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Cell) DeepCopyInto(out *Cell) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Cell.
+func (in *Cell) DeepCopy() *Cell {
+	if in == nil {
+		return nil
+	}
+	out := new(Cell)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Cell) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CellDecommission) DeepCopyInto(out *CellDecommission) {
+	*out = *in
+	if in.BakeTime != nil {
+		in, out := &in.BakeTime, &out.BakeTime
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.EKSClusterTags != nil {
+		in, out := &in.EKSClusterTags, &out.EKSClusterTags
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CellDecommission.
+func (in *CellDecommission) DeepCopy() *CellDecommission {
+	if in == nil {
+		return nil
+	}
+	out := new(CellDecommission)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CellIngress) DeepCopyInto(out *CellIngress) {
+	*out = *in
+	if in.AWSApplicationLoadBalancer != nil {
+		in, out := &in.AWSApplicationLoadBalancer, &out.AWSApplicationLoadBalancer
+		*out = new(CellIngressAWSApplicationLoadBalancer)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AWSNetworkLoadBalancer != nil {
+		in, out := &in.AWSNetworkLoadBalancer, &out.AWSNetworkLoadBalancer
+		*out = new(CellIngressAWSNetworkLoadBalancer)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GatewayAPIHTTPRoute != nil {
+		in, out := &in.GatewayAPIHTTPRoute, &out.GatewayAPIHTTPRoute
+		*out = new(CellIngressGatewayAPIHTTPRoute)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.IstioVirtualService != nil {
+		in, out := &in.IstioVirtualService, &out.IstioVirtualService
+		*out = new(CellIngressIstioVirtualService)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.IngressNginx != nil {
+		in, out := &in.IngressNginx, &out.IngressNginx
+		*out = new(CellIngressIngressNginx)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Envoy != nil {
+		in, out := &in.Envoy, &out.Envoy
+		*out = new(CellIngressEnvoy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Route53WeightedRecord != nil {
+		in, out := &in.Route53WeightedRecord, &out.Route53WeightedRecord
+		*out = new(CellIngressRoute53WeightedRecord)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AWSGlobalAccelerator != nil {
+		in, out := &in.AWSGlobalAccelerator, &out.AWSGlobalAccelerator
+		*out = new(CellIngressAWSGlobalAccelerator)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CellIngress.
+func (in *CellIngress) DeepCopy() *CellIngress {
+	if in == nil {
+		return nil
+	}
+	out := new(CellIngress)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CellIngressAWSApplicationLoadBalancer) DeepCopyInto(out *CellIngressAWSApplicationLoadBalancer) {
+	*out = *in
+	in.Listener.DeepCopyInto(&out.Listener)
+	in.TargetGroupSelector.DeepCopyInto(&out.TargetGroupSelector)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CellIngressAWSApplicationLoadBalancer.
+func (in *CellIngressAWSApplicationLoadBalancer) DeepCopy() *CellIngressAWSApplicationLoadBalancer {
+	if in == nil {
+		return nil
+	}
+	out := new(CellIngressAWSApplicationLoadBalancer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CellIngressAWSGlobalAccelerator) DeepCopyInto(out *CellIngressAWSGlobalAccelerator) {
+	*out = *in
+	if in.ClientIPPreservationEnabled != nil {
+		in, out := &in.ClientIPPreservationEnabled, &out.ClientIPPreservationEnabled
+		*out = new(bool)
+		**out = **in
+	}
+	in.EndpointSelector.DeepCopyInto(&out.EndpointSelector)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CellIngressAWSGlobalAccelerator.
+func (in *CellIngressAWSGlobalAccelerator) DeepCopy() *CellIngressAWSGlobalAccelerator {
+	if in == nil {
+		return nil
+	}
+	out := new(CellIngressAWSGlobalAccelerator)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CellIngressAWSNetworkLoadBalancer) DeepCopyInto(out *CellIngressAWSNetworkLoadBalancer) {
+	*out = *in
+	in.TargetGroupSelector.DeepCopyInto(&out.TargetGroupSelector)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CellIngressAWSNetworkLoadBalancer.
+func (in *CellIngressAWSNetworkLoadBalancer) DeepCopy() *CellIngressAWSNetworkLoadBalancer {
+	if in == nil {
+		return nil
+	}
+	out := new(CellIngressAWSNetworkLoadBalancer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CellIngressEnvoy) DeepCopyInto(out *CellIngressEnvoy) {
+	*out = *in
+	in.Listener.DeepCopyInto(&out.Listener)
+	if in.TargetGroupSelector != nil {
+		in, out := &in.TargetGroupSelector, &out.TargetGroupSelector
+		*out = new(TargetGroupSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.EndpointSelector != nil {
+		in, out := &in.EndpointSelector, &out.EndpointSelector
+		*out = new(ClusterEndpointSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CellIngressEnvoy.
+func (in *CellIngressEnvoy) DeepCopy() *CellIngressEnvoy {
+	if in == nil {
+		return nil
+	}
+	out := new(CellIngressEnvoy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CellIngressGatewayAPIHTTPRoute) DeepCopyInto(out *CellIngressGatewayAPIHTTPRoute) {
+	*out = *in
+	if in.ParentRefs != nil {
+		in, out := &in.ParentRefs, &out.ParentRefs
+		*out = make([]v1beta1.ParentReference, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Hostnames != nil {
+		in, out := &in.Hostnames, &out.Hostnames
+		*out = make([]v1beta1.Hostname, len(*in))
+		copy(*out, *in)
+	}
+	if in.Matches != nil {
+		in, out := &in.Matches, &out.Matches
+		*out = make([]v1beta1.HTTPRouteMatch, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.BackendSelector.DeepCopyInto(&out.BackendSelector)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CellIngressGatewayAPIHTTPRoute.
+func (in *CellIngressGatewayAPIHTTPRoute) DeepCopy() *CellIngressGatewayAPIHTTPRoute {
+	if in == nil {
+		return nil
+	}
+	out := new(CellIngressGatewayAPIHTTPRoute)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CellIngressIngressNginx) DeepCopyInto(out *CellIngressIngressNginx) {
+	*out = *in
+	if in.IngressClassName != nil {
+		in, out := &in.IngressClassName, &out.IngressClassName
+		*out = new(string)
+		**out = **in
+	}
+	if in.PathType != nil {
+		in, out := &in.PathType, &out.PathType
+		*out = new(networkingv1.PathType)
+		**out = **in
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = make([]networkingv1.IngressTLS, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	in.BackendSelector.DeepCopyInto(&out.BackendSelector)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CellIngressIngressNginx.
+func (in *CellIngressIngressNginx) DeepCopy() *CellIngressIngressNginx {
+	if in == nil {
+		return nil
+	}
+	out := new(CellIngressIngressNginx)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CellIngressIstioVirtualService) DeepCopyInto(out *CellIngressIstioVirtualService) {
+	*out = *in
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Gateways != nil {
+		in, out := &in.Gateways, &out.Gateways
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Match != nil {
+		in, out := &in.Match, &out.Match
+		*out = make([]istiov1beta1.HTTPMatchRequest, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.EndpointSelector.DeepCopyInto(&out.EndpointSelector)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CellIngressIstioVirtualService.
+func (in *CellIngressIstioVirtualService) DeepCopy() *CellIngressIstioVirtualService {
+	if in == nil {
+		return nil
+	}
+	out := new(CellIngressIstioVirtualService)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CellIngressRoute53WeightedRecord) DeepCopyInto(out *CellIngressRoute53WeightedRecord) {
+	*out = *in
+	if in.MinStepDuration != nil {
+		in, out := &in.MinStepDuration, &out.MinStepDuration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	in.EndpointSelector.DeepCopyInto(&out.EndpointSelector)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CellIngressRoute53WeightedRecord.
+func (in *CellIngressRoute53WeightedRecord) DeepCopy() *CellIngressRoute53WeightedRecord {
+	if in == nil {
+		return nil
+	}
+	out := new(CellIngressRoute53WeightedRecord)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CellList) DeepCopyInto(out *CellList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Cell, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CellList.
+func (in *CellList) DeepCopy() *CellList {
+	if in == nil {
+		return nil
+	}
+	out := new(CellList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CellList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CellSpec) DeepCopyInto(out *CellSpec) {
+	*out = *in
+	in.Ingress.DeepCopyInto(&out.Ingress)
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+	in.UpdateStrategy.DeepCopyInto(&out.UpdateStrategy)
+	if in.Decommission != nil {
+		in, out := &in.Decommission, &out.Decommission
+		*out = new(CellDecommission)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CellSpec.
+func (in *CellSpec) DeepCopy() *CellSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CellSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CellStatus) DeepCopyInto(out *CellStatus) {
+	*out = *in
+	in.Clusters.DeepCopyInto(&out.Clusters)
+	in.LastSyncTime.DeepCopyInto(&out.LastSyncTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CellStatus.
+func (in *CellStatus) DeepCopy() *CellStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CellStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CellUpdateStrategy) DeepCopyInto(out *CellUpdateStrategy) {
+	*out = *in
+	if in.Canary != nil {
+		in, out := &in.Canary, &out.Canary
+		*out = new(CellUpdateStrategyCanary)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BlueGreen != nil {
+		in, out := &in.BlueGreen, &out.BlueGreen
+		*out = new(CellUpdateStrategyBlueGreen)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CellUpdateStrategy.
+func (in *CellUpdateStrategy) DeepCopy() *CellUpdateStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(CellUpdateStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CellUpdateStrategyBlueGreen) DeepCopyInto(out *CellUpdateStrategyBlueGreen) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CellUpdateStrategyBlueGreen.
+func (in *CellUpdateStrategyBlueGreen) DeepCopy() *CellUpdateStrategyBlueGreen {
+	if in == nil {
+		return nil
+	}
+	out := new(CellUpdateStrategyBlueGreen)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CellUpdateStrategyCanary) DeepCopyInto(out *CellUpdateStrategyCanary) {
+	*out = *in
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]rolloutsv1alpha1.CanaryStep, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Analysis != nil {
+		in, out := &in.Analysis, &out.Analysis
+		*out = new(rolloutsv1alpha1.RolloutAnalysisBackground)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TargetHealth != nil {
+		in, out := &in.TargetHealth, &out.TargetHealth
+		*out = new(CanaryTargetHealth)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Replacement != nil {
+		in, out := &in.Replacement, &out.Replacement
+		*out = new(CanaryReplacement)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CellUpdateStrategyCanary.
+func (in *CellUpdateStrategyCanary) DeepCopy() *CellUpdateStrategyCanary {
+	if in == nil {
+		return nil
+	}
+	out := new(CellUpdateStrategyCanary)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterAPIClusterGenerator) DeepCopyInto(out *ClusterAPIClusterGenerator) {
+	*out = *in
+	in.Selector.DeepCopyInto(&out.Selector)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterAPIClusterGenerator.
+func (in *ClusterAPIClusterGenerator) DeepCopy() *ClusterAPIClusterGenerator {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterAPIClusterGenerator)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterAPIClusterSelector) DeepCopyInto(out *ClusterAPIClusterSelector) {
+	*out = *in
+	if in.MatchLabels != nil {
+		in, out := &in.MatchLabels, &out.MatchLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterAPIClusterSelector.
+func (in *ClusterAPIClusterSelector) DeepCopy() *ClusterAPIClusterSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterAPIClusterSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterBaseGenerator) DeepCopyInto(out *ClusterBaseGenerator) {
+	*out = *in
+	if in.AWSEKS != nil {
+		in, out := &in.AWSEKS, &out.AWSEKS
+		*out = new(AWSEKSClusterGenerator)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ClusterAPI != nil {
+		in, out := &in.ClusterAPI, &out.ClusterAPI
+		*out = new(ClusterAPIClusterGenerator)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterBaseGenerator.
+func (in *ClusterBaseGenerator) DeepCopy() *ClusterBaseGenerator {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterBaseGenerator)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterCombinationGenerator) DeepCopyInto(out *ClusterCombinationGenerator) {
+	*out = *in
+	if in.Generators != nil {
+		in, out := &in.Generators, &out.Generators
+		*out = make([]ClusterBaseGenerator, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterCombinationGenerator.
+func (in *ClusterCombinationGenerator) DeepCopy() *ClusterCombinationGenerator {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterCombinationGenerator)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterEndpoint) DeepCopyInto(out *ClusterEndpoint) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	out.Status = in.Status
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterEndpoint.
+func (in *ClusterEndpoint) DeepCopy() *ClusterEndpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterEndpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterEndpoint) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterEndpointList) DeepCopyInto(out *ClusterEndpointList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterEndpoint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterEndpointList.
+func (in *ClusterEndpointList) DeepCopy() *ClusterEndpointList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterEndpointList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterEndpointList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterEndpointSelector) DeepCopyInto(out *ClusterEndpointSelector) {
+	*out = *in
+	if in.MatchLabels != nil {
+		in, out := &in.MatchLabels, &out.MatchLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.VersionLabels != nil {
+		in, out := &in.VersionLabels, &out.VersionLabels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterEndpointSelector.
+func (in *ClusterEndpointSelector) DeepCopy() *ClusterEndpointSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterEndpointSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterEndpointSpec) DeepCopyInto(out *ClusterEndpointSpec) {
+	*out = *in
+	if in.Addresses != nil {
+		in, out := &in.Addresses, &out.Addresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterEndpointSpec.
+func (in *ClusterEndpointSpec) DeepCopy() *ClusterEndpointSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(CellUpdateStrategy)
+	out := new(ClusterEndpointSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CellUpdateStrategyBlueGreen) DeepCopyInto(out *CellUpdateStrategyBlueGreen) {
+func (in *ClusterEndpointStatus) DeepCopyInto(out *ClusterEndpointStatus) {
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CellUpdateStrategyBlueGreen.
-func (in *CellUpdateStrategyBlueGreen) DeepCopy() *CellUpdateStrategyBlueGreen {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterEndpointStatus.
+func (in *ClusterEndpointStatus) DeepCopy() *ClusterEndpointStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(CellUpdateStrategyBlueGreen)
+	out := new(ClusterEndpointStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CellUpdateStrategyCanary) DeepCopyInto(out *CellUpdateStrategyCanary) {
+func (in *ClusterGenerator) DeepCopyInto(out *ClusterGenerator) {
 	*out = *in
-	if in.Steps != nil {
-		in, out := &in.Steps, &out.Steps
-		*out = make([]rolloutsv1alpha1.CanaryStep, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	in.ClusterBaseGenerator.DeepCopyInto(&out.ClusterBaseGenerator)
+	if in.Matrix != nil {
+		in, out := &in.Matrix, &out.Matrix
+		*out = new(ClusterCombinationGenerator)
+		(*in).DeepCopyInto(*out)
 	}
-	if in.Analysis != nil {
-		in, out := &in.Analysis, &out.Analysis
-		*out = new(rolloutsv1alpha1.RolloutAnalysisBackground)
+	if in.Merge != nil {
+		in, out := &in.Merge, &out.Merge
+		*out = new(ClusterCombinationGenerator)
 		(*in).DeepCopyInto(*out)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CellUpdateStrategyCanary.
-func (in *CellUpdateStrategyCanary) DeepCopy() *CellUpdateStrategyCanary {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterGenerator.
+func (in *ClusterGenerator) DeepCopy() *ClusterGenerator {
 	if in == nil {
 		return nil
 	}
-	out := new(CellUpdateStrategyCanary)
+	out := new(ClusterGenerator)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ClusterGenerator) DeepCopyInto(out *ClusterGenerator) {
+func (in *ClusterHealth) DeepCopyInto(out *ClusterHealth) {
 	*out = *in
-	in.AWSEKS.DeepCopyInto(&out.AWSEKS)
+	in.LastProbeTime.DeepCopyInto(&out.LastProbeTime)
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterGenerator.
-func (in *ClusterGenerator) DeepCopy() *ClusterGenerator {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterHealth.
+func (in *ClusterHealth) DeepCopy() *ClusterHealth {
 	if in == nil {
 		return nil
 	}
-	out := new(ClusterGenerator)
+	out := new(ClusterHealth)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -658,6 +1722,11 @@ func (in *ClusterGenerator) DeepCopy() *ClusterGenerator {
 func (in *ClusterSecretTemplate) DeepCopyInto(out *ClusterSecretTemplate) {
 	*out = *in
 	in.Metadata.DeepCopyInto(&out.Metadata)
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterSecretTemplate.
@@ -680,6 +1749,13 @@ func (in *ClusterSecretTemplateMetadata) DeepCopyInto(out *ClusterSecretTemplate
 			(*out)[key] = val
 		}
 	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterSecretTemplateMetadata.
@@ -719,6 +1795,31 @@ func (in *ClusterSet) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterSetHealthCheck) DeepCopyInto(out *ClusterSetHealthCheck) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterSetHealthCheck.
+func (in *ClusterSetHealthCheck) DeepCopy() *ClusterSetHealthCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterSetHealthCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterSetList) DeepCopyInto(out *ClusterSetList) {
 	*out = *in
@@ -762,6 +1863,11 @@ func (in *ClusterSetSpec) DeepCopyInto(out *ClusterSetSpec) {
 		}
 	}
 	in.Template.DeepCopyInto(&out.Template)
+	if in.HealthCheck != nil {
+		in, out := &in.HealthCheck, &out.HealthCheck
+		*out = new(ClusterSetHealthCheck)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterSetSpec.
@@ -779,6 +1885,25 @@ func (in *ClusterSetStatus) DeepCopyInto(out *ClusterSetStatus) {
 	*out = *in
 	in.Clusters.DeepCopyInto(&out.Clusters)
 	in.LastSyncTime.DeepCopyInto(&out.LastSyncTime)
+	if in.Discovery != nil {
+		in, out := &in.Discovery, &out.Discovery
+		*out = new(ClusterSetStatusDiscovery)
+		**out = **in
+	}
+	if in.ClusterHealth != nil {
+		in, out := &in.ClusterHealth, &out.ClusterHealth
+		*out = make([]ClusterHealth, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterSetStatus.
@@ -799,6 +1924,31 @@ func (in *ClusterSetStatusClusters) DeepCopyInto(out *ClusterSetStatusClusters)
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Created != nil {
+		in, out := &in.Created, &out.Created
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Updated != nil {
+		in, out := &in.Updated, &out.Updated
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Unchanged != nil {
+		in, out := &in.Unchanged, &out.Unchanged
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Deleted != nil {
+		in, out := &in.Deleted, &out.Deleted
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Errors != nil {
+		in, out := &in.Errors, &out.Errors
+		*out = make([]MemberError, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterSetStatusClusters.
@@ -811,6 +1961,160 @@ func (in *ClusterSetStatusClusters) DeepCopy() *ClusterSetStatusClusters {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterSetStatusDiscovery) DeepCopyInto(out *ClusterSetStatusDiscovery) {
+	*out = *in
+	out.Duration = in.Duration
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterSetStatusDiscovery.
+func (in *ClusterSetStatusDiscovery) DeepCopy() *ClusterSetStatusDiscovery {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterSetStatusDiscovery)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvoyCluster) DeepCopyInto(out *EnvoyCluster) {
+	*out = *in
+	if in.Addresses != nil {
+		in, out := &in.Addresses, &out.Addresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvoyCluster.
+func (in *EnvoyCluster) DeepCopy() *EnvoyCluster {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvoyCluster)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvoyListener) DeepCopyInto(out *EnvoyListener) {
+	*out = *in
+	if in.Domains != nil {
+		in, out := &in.Domains, &out.Domains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvoyListener.
+func (in *EnvoyListener) DeepCopy() *EnvoyListener {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvoyListener)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvoyRouteConfig) DeepCopyInto(out *EnvoyRouteConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvoyRouteConfig.
+func (in *EnvoyRouteConfig) DeepCopy() *EnvoyRouteConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvoyRouteConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EnvoyRouteConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvoyRouteConfigList) DeepCopyInto(out *EnvoyRouteConfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]EnvoyRouteConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvoyRouteConfigList.
+func (in *EnvoyRouteConfigList) DeepCopy() *EnvoyRouteConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvoyRouteConfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EnvoyRouteConfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvoyRouteConfigSpec) DeepCopyInto(out *EnvoyRouteConfigSpec) {
+	*out = *in
+	in.Listener.DeepCopyInto(&out.Listener)
+	if in.Clusters != nil {
+		in, out := &in.Clusters, &out.Clusters
+		*out = make([]EnvoyCluster, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvoyRouteConfigSpec.
+func (in *EnvoyRouteConfigSpec) DeepCopy() *EnvoyRouteConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvoyRouteConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvoyRouteConfigStatus) DeepCopyInto(out *EnvoyRouteConfigStatus) {
+	*out = *in
+	in.LastSyncTime.DeepCopyInto(&out.LastSyncTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvoyRouteConfigStatus.
+func (in *EnvoyRouteConfigStatus) DeepCopy() *EnvoyRouteConfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvoyRouteConfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Forward) DeepCopyInto(out *Forward) {
 	*out = *in
@@ -920,6 +2224,21 @@ func (in *ListenerRule) DeepCopy() *ListenerRule {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MemberError) DeepCopyInto(out *MemberError) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemberError.
+func (in *MemberError) DeepCopy() *MemberError {
+	if in == nil {
+		return nil
+	}
+	out := new(MemberError)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Pause) DeepCopyInto(out *Pause) {
 	*out = *in
@@ -1011,6 +2330,33 @@ func (in *PauseStatus) DeepCopy() *PauseStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceSelector) DeepCopyInto(out *ServiceSelector) {
+	*out = *in
+	if in.MatchLabels != nil {
+		in, out := &in.MatchLabels, &out.MatchLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.VersionLabels != nil {
+		in, out := &in.VersionLabels, &out.VersionLabels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceSelector.
+func (in *ServiceSelector) DeepCopy() *ServiceSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TargetGroupBindingSelector) DeepCopyInto(out *TargetGroupBindingSelector) {
 	*out = *in
@@ -1033,6 +2379,45 @@ func (in *TargetGroupBindingSelector) DeepCopy() *TargetGroupBindingSelector {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TargetGroupBindingTemplate) DeepCopyInto(out *TargetGroupBindingTemplate) {
+	*out = *in
+	in.Metadata.DeepCopyInto(&out.Metadata)
+	out.ServicePort = in.ServicePort
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TargetGroupBindingTemplate.
+func (in *TargetGroupBindingTemplate) DeepCopy() *TargetGroupBindingTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(TargetGroupBindingTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TargetGroupBindingTemplateMetadata) DeepCopyInto(out *TargetGroupBindingTemplateMetadata) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TargetGroupBindingTemplateMetadata.
+func (in *TargetGroupBindingTemplateMetadata) DeepCopy() *TargetGroupBindingTemplateMetadata {
+	if in == nil {
+		return nil
+	}
+	out := new(TargetGroupBindingTemplateMetadata)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TargetGroupClusterSelector) DeepCopyInto(out *TargetGroupClusterSelector) {
 	*out = *in
@@ -52,7 +52,7 @@ func Delete(d *SyncInput) error {
 				appendix = fmt.Sprintf("\nOUTPUT:\n%v", *res)
 			}
 
-			log.Printf("Error: deleting rule: %w\nINPUT:\n%v%s", err, *input, appendix)
+			log.Printf("Error: deleting rule: %v\nINPUT:\n%v%s", err, *input, appendix)
 
 			return fmt.Errorf("deleting rule: %w", err)
 		}
@@ -0,0 +1,233 @@
+package awsglobalaccelerator
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/globalaccelerator"
+	"github.com/mumoshu/okra/api/v1alpha1"
+	"github.com/mumoshu/okra/pkg/awsclicompat"
+	"golang.org/x/xerrors"
+)
+
+// APIRegion is the only region that serves the Global Accelerator API
+const APIRegion = "us-west-2"
+
+type SyncInput struct {
+	Spec v1alpha1.AWSGlobalAcceleratorConfigSpec
+
+	Session *session.Session
+	// Address is the endpoint of the Global Accelerator API. Used only for testing.
+	Address string
+}
+
+func newClient(d SyncInput) *globalaccelerator.GlobalAccelerator {
+	sess := d.Session
+	if sess == nil {
+		sess = awsclicompat.NewSession("", "")
+	}
+
+	config := aws.NewConfig().WithRegion(APIRegion)
+
+	if d.Address != "" {
+		config = config.WithEndpoint(d.Address)
+	}
+
+	return globalaccelerator.New(sess, config)
+}
+
+// endpointRegion returns the region of the endpoint group the endpoint belongs to.
+func endpointRegion(e v1alpha1.AWSGlobalAcceleratorEndpoint) (string, error) {
+	if e.Region != "" {
+		return e.Region, nil
+	}
+
+	a, err := arn.Parse(e.EndpointID)
+	if err != nil {
+		return "", fmt.Errorf("endpoint %s: region is required for an endpoint whose ID is not an ARN", e.EndpointID)
+	}
+
+	return a.Region, nil
+}
+
+func listEndpointGroups(svc *globalaccelerator.GlobalAccelerator, listenerARN string) (map[string]*globalaccelerator.EndpointGroup, error) {
+	groups := map[string]*globalaccelerator.EndpointGroup{}
+
+	input := &globalaccelerator.ListEndpointGroupsInput{
+		ListenerArn: aws.String(listenerARN),
+	}
+
+	for {
+		result, err := svc.ListEndpointGroups(input)
+		if err != nil {
+			return nil, xerrors.Errorf("calling globalaccelerator.ListEndpointGroups: %w", err)
+		}
+
+		for _, g := range result.EndpointGroups {
+			groups[aws.StringValue(g.EndpointGroupRegion)] = g
+		}
+
+		if result.NextToken == nil {
+			return groups, nil
+		}
+
+		input.NextToken = result.NextToken
+	}
+}
+
+// trafficDial returns the traffic dial percentage of the endpoint group, which is the share of the weights of its endpoints
+// out of the total weight of all the endpoints.
+// Global Accelerator routes a client to the nearest endpoint group, and the traffic dial lets only the percentage of it stay in the group,
+// sending the rest to the other groups. So a region receives its share of the traffic from clients near it, and
+// the rest of the regions receive the rest, which honors the weights across regions.
+// An endpoint group is drained when all its endpoints have zero weights.
+func trafficDial(endpoints []*globalaccelerator.EndpointConfiguration, totalWeight int64) float64 {
+	if totalWeight == 0 {
+		return 0
+	}
+
+	var weight int64
+	for _, e := range endpoints {
+		weight += aws.Int64Value(e.Weight)
+	}
+
+	return math.Round(float64(weight) * 100 / float64(totalWeight))
+}
+
+func endpointGroupChanged(current *globalaccelerator.EndpointGroup, desired []*globalaccelerator.EndpointConfiguration, dial float64) bool {
+	if aws.Float64Value(current.TrafficDialPercentage) != dial {
+		return true
+	}
+
+	if len(current.EndpointDescriptions) != len(desired) {
+		return true
+	}
+
+	currentEndpoints := map[string]*globalaccelerator.EndpointDescription{}
+	for _, e := range current.EndpointDescriptions {
+		currentEndpoints[aws.StringValue(e.EndpointId)] = e
+	}
+
+	for _, e := range desired {
+		c, ok := currentEndpoints[aws.StringValue(e.EndpointId)]
+		if !ok {
+			return true
+		}
+
+		if aws.Int64Value(c.Weight) != aws.Int64Value(e.Weight) {
+			return true
+		}
+
+		if e.ClientIPPreservationEnabled != nil && aws.BoolValue(c.ClientIPPreservationEnabled) != *e.ClientIPPreservationEnabled {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Sync updates the endpoints and the traffic dials of the endpoint groups of the listener.
+// An endpoint group is created for a region that has endpoints but no endpoint group yet.
+// An existing endpoint group for a region that has no endpoints in the spec is drained.
+// It returns true when it changed any endpoint group.
+func Sync(d SyncInput) (bool, error) {
+	svc := newClient(d)
+
+	var changed bool
+
+	desired := map[string][]*globalaccelerator.EndpointConfiguration{}
+
+	var totalWeight int64
+
+	for _, e := range d.Spec.Endpoints {
+		totalWeight += int64(e.Weight)
+
+		region, err := endpointRegion(e)
+		if err != nil {
+			return false, err
+		}
+
+		desired[region] = append(desired[region], &globalaccelerator.EndpointConfiguration{
+			EndpointId:                  aws.String(e.EndpointID),
+			Weight:                      aws.Int64(int64(e.Weight)),
+			ClientIPPreservationEnabled: e.ClientIPPreservationEnabled,
+		})
+	}
+
+	current, err := listEndpointGroups(svc, d.Spec.ListenerARN)
+	if err != nil {
+		return false, err
+	}
+
+	var regions []string
+	for region := range desired {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+
+	for _, region := range regions {
+		endpoints := desired[region]
+		dial := trafficDial(endpoints, totalWeight)
+
+		g, ok := current[region]
+		if !ok {
+			_, err := svc.CreateEndpointGroup(&globalaccelerator.CreateEndpointGroupInput{
+				ListenerArn:            aws.String(d.Spec.ListenerARN),
+				EndpointGroupRegion:    aws.String(region),
+				EndpointConfigurations: endpoints,
+				TrafficDialPercentage:  aws.Float64(dial),
+			})
+			if err != nil {
+				return false, xerrors.Errorf("calling globalaccelerator.CreateEndpointGroup: %w", err)
+			}
+
+			changed = true
+
+			log.Printf("Created endpoint group in %s with %d endpoints and traffic dial %v", region, len(endpoints), dial)
+
+			continue
+		}
+
+		if !endpointGroupChanged(g, endpoints, dial) {
+			continue
+		}
+
+		_, err := svc.UpdateEndpointGroup(&globalaccelerator.UpdateEndpointGroupInput{
+			EndpointGroupArn:       g.EndpointGroupArn,
+			EndpointConfigurations: endpoints,
+			TrafficDialPercentage:  aws.Float64(dial),
+		})
+		if err != nil {
+			return false, xerrors.Errorf("calling globalaccelerator.UpdateEndpointGroup: %w", err)
+		}
+
+		changed = true
+
+		log.Printf("Updated endpoint group %s with %d endpoints and traffic dial %v", *g.EndpointGroupArn, len(endpoints), dial)
+	}
+
+	for region, g := range current {
+		if _, ok := desired[region]; ok || aws.Float64Value(g.TrafficDialPercentage) == 0 {
+			continue
+		}
+
+		_, err := svc.UpdateEndpointGroup(&globalaccelerator.UpdateEndpointGroupInput{
+			EndpointGroupArn:      g.EndpointGroupArn,
+			TrafficDialPercentage: aws.Float64(0),
+		})
+		if err != nil {
+			return false, xerrors.Errorf("calling globalaccelerator.UpdateEndpointGroup: %w", err)
+		}
+
+		changed = true
+
+		log.Printf("Drained endpoint group %s", *g.EndpointGroupArn)
+	}
+
+	return changed, nil
+}
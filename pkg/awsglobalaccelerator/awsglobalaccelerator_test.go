@@ -0,0 +1,204 @@
+package awsglobalaccelerator
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/globalaccelerator"
+	"github.com/google/go-cmp/cmp"
+	okrav1alpha1 "github.com/mumoshu/okra/api/v1alpha1"
+)
+
+const (
+	listenerARN = "arn:aws:globalaccelerator::123456789012:accelerator/1/listener/1"
+	albUSEast1  = "arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/app/web-1/1"
+	albUSEast1b = "arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/app/web-2/2"
+	albEUWest1  = "arn:aws:elasticloadbalancing:eu-west-1:123456789012:loadbalancer/app/web-3/3"
+)
+
+// fakeGlobalAccelerator is an in-memory Global Accelerator API that supports just enough of
+// the endpoint group operations for testing.
+type fakeGlobalAccelerator struct {
+	mu     sync.Mutex
+	groups map[string]*globalaccelerator.EndpointGroup
+	calls  []string
+}
+
+func (f *fakeGlobalAccelerator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	op := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "GlobalAccelerator_V20180706.")
+
+	f.calls = append(f.calls, op)
+
+	var out interface{}
+
+	switch op {
+	case "ListEndpointGroups":
+		var groups []*globalaccelerator.EndpointGroup
+		for _, g := range f.groups {
+			groups = append(groups, g)
+		}
+
+		out = &globalaccelerator.ListEndpointGroupsOutput{EndpointGroups: groups}
+	case "CreateEndpointGroup":
+		var in globalaccelerator.CreateEndpointGroupInput
+		json.NewDecoder(r.Body).Decode(&in)
+
+		g := &globalaccelerator.EndpointGroup{
+			EndpointGroupArn:    aws.String(fmt.Sprintf("%s/endpoint-group/%s", *in.ListenerArn, *in.EndpointGroupRegion)),
+			EndpointGroupRegion: in.EndpointGroupRegion,
+		}
+		f.apply(g, in.EndpointConfigurations, in.TrafficDialPercentage)
+		f.groups[*g.EndpointGroupRegion] = g
+
+		out = &globalaccelerator.CreateEndpointGroupOutput{EndpointGroup: g}
+	case "UpdateEndpointGroup":
+		var in globalaccelerator.UpdateEndpointGroupInput
+		json.NewDecoder(r.Body).Decode(&in)
+
+		for _, g := range f.groups {
+			if *g.EndpointGroupArn == *in.EndpointGroupArn {
+				f.apply(g, in.EndpointConfigurations, in.TrafficDialPercentage)
+				out = &globalaccelerator.UpdateEndpointGroupOutput{EndpointGroup: g}
+			}
+		}
+	default:
+		http.Error(w, "unexpected operation "+op, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	json.NewEncoder(w).Encode(out)
+}
+
+func (f *fakeGlobalAccelerator) apply(g *globalaccelerator.EndpointGroup, endpoints []*globalaccelerator.EndpointConfiguration, dial *float64) {
+	if endpoints != nil {
+		g.EndpointDescriptions = nil
+		for _, e := range endpoints {
+			g.EndpointDescriptions = append(g.EndpointDescriptions, &globalaccelerator.EndpointDescription{
+				EndpointId: e.EndpointId,
+				Weight:     e.Weight,
+			})
+		}
+	}
+
+	if dial != nil {
+		g.TrafficDialPercentage = dial
+	}
+}
+
+type groupState struct {
+	Dial    float64
+	Weights map[string]int64
+}
+
+func (f *fakeGlobalAccelerator) state() map[string]groupState {
+	s := map[string]groupState{}
+	for region, g := range f.groups {
+		weights := map[string]int64{}
+		for _, e := range g.EndpointDescriptions {
+			weights[*e.EndpointId] = *e.Weight
+		}
+		s[region] = groupState{Dial: *g.TrafficDialPercentage, Weights: weights}
+	}
+
+	return s
+}
+
+func TestSync(t *testing.T) {
+	fake := &fakeGlobalAccelerator{
+		groups: map[string]*globalaccelerator.EndpointGroup{},
+	}
+
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	sess := session.Must(session.NewSession(&aws.Config{
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+	}))
+
+	sync := func(endpoints ...okrav1alpha1.AWSGlobalAcceleratorEndpoint) bool {
+		t.Helper()
+
+		changed, err := Sync(SyncInput{
+			Spec: okrav1alpha1.AWSGlobalAcceleratorConfigSpec{
+				ListenerARN: listenerARN,
+				Endpoints:   endpoints,
+			},
+			Session: sess,
+			Address: server.URL,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		return changed
+	}
+
+	sync(
+		okrav1alpha1.AWSGlobalAcceleratorEndpoint{EndpointID: albUSEast1, Weight: 80},
+		okrav1alpha1.AWSGlobalAcceleratorEndpoint{EndpointID: albUSEast1b, Weight: 20},
+		okrav1alpha1.AWSGlobalAcceleratorEndpoint{EndpointID: albEUWest1, Weight: 0},
+	)
+
+	want := map[string]groupState{
+		"us-east-1": {Dial: 100, Weights: map[string]int64{albUSEast1: 80, albUSEast1b: 20}},
+		"eu-west-1": {Dial: 0, Weights: map[string]int64{albEUWest1: 0}},
+	}
+
+	if d := cmp.Diff(want, fake.state()); d != "" {
+		t.Fatalf("unexpected endpoint groups: %s", d)
+	}
+
+	fake.calls = nil
+
+	if sync(
+		okrav1alpha1.AWSGlobalAcceleratorEndpoint{EndpointID: albUSEast1, Weight: 80},
+		okrav1alpha1.AWSGlobalAcceleratorEndpoint{EndpointID: albUSEast1b, Weight: 20},
+		okrav1alpha1.AWSGlobalAcceleratorEndpoint{EndpointID: albEUWest1, Weight: 0},
+	) {
+		t.Errorf("expected no change to be reported")
+	}
+
+	if d := cmp.Diff([]string{"ListEndpointGroups"}, fake.calls); d != "" {
+		t.Errorf("unexpected calls on no change: %s", d)
+	}
+
+	sync(
+		okrav1alpha1.AWSGlobalAcceleratorEndpoint{EndpointID: albEUWest1, Weight: 100},
+	)
+
+	want = map[string]groupState{
+		"us-east-1": {Dial: 0, Weights: map[string]int64{albUSEast1: 80, albUSEast1b: 20}},
+		"eu-west-1": {Dial: 100, Weights: map[string]int64{albEUWest1: 100}},
+	}
+
+	if d := cmp.Diff(want, fake.state()); d != "" {
+		t.Fatalf("unexpected endpoint groups after switching regions: %s", d)
+	}
+
+	// A canary in another region receives its share via the traffic dials
+	sync(
+		okrav1alpha1.AWSGlobalAcceleratorEndpoint{EndpointID: albUSEast1, Weight: 90},
+		okrav1alpha1.AWSGlobalAcceleratorEndpoint{EndpointID: albEUWest1, Weight: 10},
+	)
+
+	want = map[string]groupState{
+		"us-east-1": {Dial: 90, Weights: map[string]int64{albUSEast1: 90}},
+		"eu-west-1": {Dial: 10, Weights: map[string]int64{albEUWest1: 10}},
+	}
+
+	if d := cmp.Diff(want, fake.state()); d != "" {
+		t.Fatalf("unexpected endpoint groups on a canary across regions: %s", d)
+	}
+}
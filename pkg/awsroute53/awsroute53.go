@@ -0,0 +1,304 @@
+package awsroute53
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/mumoshu/okra/api/v1alpha1"
+	"github.com/mumoshu/okra/pkg/awsclicompat"
+	"golang.org/x/xerrors"
+)
+
+const (
+	DefaultType = route53.RRTypeCname
+	DefaultTTL  = 60
+)
+
+type SyncInput struct {
+	Spec v1alpha1.AWSRoute53WeightedRecordConfigSpec
+	// Managed is the record sets recorded by the last successful Sync.
+	// Nil means no record set is known to be created by okra.
+	Managed *v1alpha1.AWSRoute53ManagedRecordSets
+
+	Session *session.Session
+	// Address is the endpoint of the Route53 API. Used only for testing.
+	Address string
+}
+
+func newClient(d SyncInput) *route53.Route53 {
+	sess := d.Session
+	if sess == nil {
+		sess = awsclicompat.NewSession("", "")
+	}
+
+	if d.Address != "" {
+		sess.Config.Endpoint = &d.Address
+	}
+
+	return route53.New(sess)
+}
+
+func recordType(spec v1alpha1.AWSRoute53WeightedRecordConfigSpec) string {
+	if spec.Type == "" {
+		return DefaultType
+	}
+
+	return spec.Type
+}
+
+func ttl(spec v1alpha1.AWSRoute53WeightedRecordConfigSpec) int64 {
+	if spec.TTL == 0 {
+		return DefaultTTL
+	}
+
+	return spec.TTL
+}
+
+// fqdn returns the name with the trailing dot, as Route53 returns names in that form.
+func fqdn(name string) string {
+	return strings.TrimSuffix(name, ".") + "."
+}
+
+// listWeightedRecordSets returns the weighted record sets that have the name and the type,
+// keyed by their set identifiers.
+func listWeightedRecordSets(svc *route53.Route53, hostedZoneID, name, typ string) (map[string]*route53.ResourceRecordSet, error) {
+	recordSets := map[string]*route53.ResourceRecordSet{}
+
+	input := &route53.ListResourceRecordSetsInput{
+		HostedZoneId:    aws.String(hostedZoneID),
+		StartRecordName: aws.String(name),
+		StartRecordType: aws.String(typ),
+	}
+
+	for {
+		result, err := svc.ListResourceRecordSets(input)
+		if err != nil {
+			return nil, xerrors.Errorf("calling route53.ListResourceRecordSets: %w", err)
+		}
+
+		for _, rs := range result.ResourceRecordSets {
+			if aws.StringValue(rs.Name) != name || aws.StringValue(rs.Type) != typ {
+				// Record sets are sorted by name and type, so we've seen all the record sets we need
+				return recordSets, nil
+			}
+
+			if rs.SetIdentifier == nil || rs.Weight == nil {
+				continue
+			}
+
+			recordSets[*rs.SetIdentifier] = rs
+		}
+
+		if !aws.BoolValue(result.IsTruncated) {
+			return recordSets, nil
+		}
+
+		input.StartRecordName = result.NextRecordName
+		input.StartRecordType = result.NextRecordType
+		input.StartRecordIdentifier = result.NextRecordIdentifier
+	}
+}
+
+// Managed returns the record sets that Sync manages for the spec.
+// Record it after a successful Sync and pass it to the next Sync and Delete as SyncInput.Managed.
+func Managed(spec v1alpha1.AWSRoute53WeightedRecordConfigSpec) *v1alpha1.AWSRoute53ManagedRecordSets {
+	m := &v1alpha1.AWSRoute53ManagedRecordSets{
+		HostedZoneID: spec.HostedZoneID,
+		Name:         fqdn(spec.Name),
+		Type:         recordType(spec),
+	}
+
+	for _, r := range spec.Records {
+		m.SetIdentifiers = append(m.SetIdentifiers, r.SetIdentifier)
+	}
+
+	sort.Strings(m.SetIdentifiers)
+
+	return m
+}
+
+// deleteChanges returns the changes to delete the managed record sets, except the ones in keep.
+// current is reused as the existing record sets when the managed record sets have the same zone, name, and type.
+func deleteChanges(svc *route53.Route53, managed *v1alpha1.AWSRoute53ManagedRecordSets, current map[string]*route53.ResourceRecordSet, keep map[string]bool) ([]*route53.Change, error) {
+	if current == nil {
+		var err error
+
+		current, err = listWeightedRecordSets(svc, managed.HostedZoneID, fqdn(managed.Name), managed.Type)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var changes []*route53.Change
+
+	for _, id := range managed.SetIdentifiers {
+		rs, ok := current[id]
+		if !ok || keep[id] {
+			continue
+		}
+
+		changes = append(changes, &route53.Change{
+			Action:            aws.String(route53.ChangeActionDelete),
+			ResourceRecordSet: rs,
+		})
+	}
+
+	return changes, nil
+}
+
+func sameRecordSets(managed *v1alpha1.AWSRoute53ManagedRecordSets, spec v1alpha1.AWSRoute53WeightedRecordConfigSpec) bool {
+	return managed.HostedZoneID == spec.HostedZoneID && fqdn(managed.Name) == fqdn(spec.Name) && managed.Type == recordType(spec)
+}
+
+func desiredRecordSet(spec v1alpha1.AWSRoute53WeightedRecordConfigSpec, r v1alpha1.AWSRoute53WeightedRecord) *route53.ResourceRecordSet {
+	rs := &route53.ResourceRecordSet{
+		Name:          aws.String(fqdn(spec.Name)),
+		Type:          aws.String(recordType(spec)),
+		TTL:           aws.Int64(ttl(spec)),
+		SetIdentifier: aws.String(r.SetIdentifier),
+		Weight:        aws.Int64(int64(r.Weight)),
+		ResourceRecords: []*route53.ResourceRecord{
+			{Value: aws.String(r.Value)},
+		},
+	}
+
+	if r.HealthCheckID != "" {
+		rs.HealthCheckId = aws.String(r.HealthCheckID)
+	}
+
+	return rs
+}
+
+func recordSetChanged(current, desired *route53.ResourceRecordSet) bool {
+	if aws.Int64Value(current.Weight) != aws.Int64Value(desired.Weight) ||
+		aws.Int64Value(current.TTL) != aws.Int64Value(desired.TTL) ||
+		aws.StringValue(current.HealthCheckId) != aws.StringValue(desired.HealthCheckId) ||
+		len(current.ResourceRecords) != len(desired.ResourceRecords) {
+		return true
+	}
+
+	for i := range current.ResourceRecords {
+		if aws.StringValue(current.ResourceRecords[i].Value) != aws.StringValue(desired.ResourceRecords[i].Value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Sync upserts the weighted record sets in the spec and deletes the managed record sets that are no longer in the spec,
+// including the ones under the previous hosted zone, name, or type. Weighted record sets not created by okra are left untouched.
+// It returns true when it changed any record set.
+func Sync(d SyncInput) (bool, error) {
+	svc := newClient(d)
+
+	current, err := listWeightedRecordSets(svc, d.Spec.HostedZoneID, fqdn(d.Spec.Name), recordType(d.Spec))
+	if err != nil {
+		return false, err
+	}
+
+	var changes []*route53.Change
+
+	desired := map[string]bool{}
+
+	for _, r := range d.Spec.Records {
+		desired[r.SetIdentifier] = true
+
+		rs := desiredRecordSet(d.Spec, r)
+
+		if c, ok := current[r.SetIdentifier]; ok && !recordSetChanged(c, rs) {
+			continue
+		}
+
+		changes = append(changes, &route53.Change{
+			Action:            aws.String(route53.ChangeActionUpsert),
+			ResourceRecordSet: rs,
+		})
+	}
+
+	var staleChanges []*route53.Change
+
+	if d.Managed != nil {
+		if sameRecordSets(d.Managed, d.Spec) {
+			staleChanges, err = deleteChanges(svc, d.Managed, current, desired)
+		} else {
+			staleChanges, err = deleteChanges(svc, d.Managed, nil, nil)
+		}
+
+		if err != nil {
+			return false, err
+		}
+	}
+
+	// A change batch is per hosted zone, so the record sets in the previous hosted zone are deleted
+	// in another batch after the new ones are in place
+	if d.Managed == nil || d.Managed.HostedZoneID == d.Spec.HostedZoneID {
+		changes = append(changes, staleChanges...)
+		staleChanges = nil
+	}
+
+	if len(changes) == 0 && len(staleChanges) == 0 {
+		log.Printf("No change detected on Route53 record sets %s %s", d.Spec.Name, recordType(d.Spec))
+		return false, nil
+	}
+
+	if len(changes) > 0 {
+		if err := changeResourceRecordSets(svc, d.Spec.HostedZoneID, d.Spec.Name, changes); err != nil {
+			return false, err
+		}
+	}
+
+	if len(staleChanges) > 0 {
+		if err := changeResourceRecordSets(svc, d.Managed.HostedZoneID, d.Managed.Name, staleChanges); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// Delete deletes all the managed record sets, or the record sets in the spec when nothing is recorded as managed yet.
+func Delete(d SyncInput) error {
+	managed := d.Managed
+	if managed == nil {
+		managed = Managed(d.Spec)
+	}
+
+	svc := newClient(d)
+
+	changes, err := deleteChanges(svc, managed, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+
+	return changeResourceRecordSets(svc, managed.HostedZoneID, managed.Name, changes)
+}
+
+func changeResourceRecordSets(svc *route53.Route53, hostedZoneID, name string, changes []*route53.Change) error {
+	input := &route53.ChangeResourceRecordSetsInput{
+		HostedZoneId: aws.String(hostedZoneID),
+		ChangeBatch: &route53.ChangeBatch{
+			Comment: aws.String(fmt.Sprintf("Updated by okra for %s", name)),
+			Changes: changes,
+		},
+	}
+
+	if _, err := svc.ChangeResourceRecordSets(input); err != nil {
+		return xerrors.Errorf("calling route53.ChangeResourceRecordSets: %w", err)
+	}
+
+	for _, c := range changes {
+		log.Printf("%s record set %s %s %s with weight %d", *c.Action, *c.ResourceRecordSet.Name, *c.ResourceRecordSet.Type, *c.ResourceRecordSet.SetIdentifier, aws.Int64Value(c.ResourceRecordSet.Weight))
+	}
+
+	return nil
+}
@@ -0,0 +1,245 @@
+package awsroute53
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/google/go-cmp/cmp"
+	okrav1alpha1 "github.com/mumoshu/okra/api/v1alpha1"
+)
+
+type fakeResourceRecord struct {
+	Value string `xml:"Value"`
+}
+
+type fakeRecordSet struct {
+	Name            string               `xml:"Name"`
+	Type            string               `xml:"Type"`
+	SetIdentifier   string               `xml:"SetIdentifier"`
+	Weight          int64                `xml:"Weight"`
+	TTL             int64                `xml:"TTL"`
+	ResourceRecords []fakeResourceRecord `xml:"ResourceRecords>ResourceRecord"`
+	HealthCheckID   string               `xml:"HealthCheckId,omitempty"`
+}
+
+type fakeChange struct {
+	Action            string        `xml:"Action"`
+	ResourceRecordSet fakeRecordSet `xml:"ResourceRecordSet"`
+}
+
+type fakeChangeRequest struct {
+	Changes []fakeChange `xml:"ChangeBatch>Changes>Change"`
+}
+
+type fakeListResponse struct {
+	XMLName            xml.Name        `xml:"https://route53.amazonaws.com/doc/2013-04-01/ ListResourceRecordSetsResponse"`
+	ResourceRecordSets []fakeRecordSet `xml:"ResourceRecordSets>ResourceRecordSet"`
+	IsTruncated        bool            `xml:"IsTruncated"`
+	MaxItems           string          `xml:"MaxItems"`
+}
+
+// fakeRoute53 is an in-memory Route53 API that supports just enough of
+// ListResourceRecordSets and ChangeResourceRecordSets for testing.
+type fakeRoute53 struct {
+	mu         sync.Mutex
+	recordSets map[string]fakeRecordSet
+	changes    int
+}
+
+func (f *fakeRoute53) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !strings.HasPrefix(r.URL.Path, "/2013-04-01/hostedzone/ZONE1/rrset") {
+		http.Error(w, "unexpected path "+r.URL.Path, http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/xml")
+
+	switch r.Method {
+	case http.MethodGet:
+		resp := fakeListResponse{MaxItems: "100"}
+
+		var keys []string
+		for key := range f.recordSets {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		start := r.URL.Query().Get("name") + " " + r.URL.Query().Get("type")
+
+		for _, key := range keys {
+			rs := f.recordSets[key]
+			if rs.Name+" "+rs.Type < start {
+				continue
+			}
+
+			resp.ResourceRecordSets = append(resp.ResourceRecordSets, rs)
+		}
+
+		xml.NewEncoder(w).Encode(resp)
+	case http.MethodPost:
+		var req fakeChangeRequest
+		if err := xml.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		for _, c := range req.Changes {
+			switch c.Action {
+			case "UPSERT":
+				f.recordSets[recordSetKey(c.ResourceRecordSet)] = c.ResourceRecordSet
+			case "DELETE":
+				delete(f.recordSets, recordSetKey(c.ResourceRecordSet))
+			}
+		}
+
+		f.changes++
+
+		w.Write([]byte(`<ChangeResourceRecordSetsResponse xmlns="https://route53.amazonaws.com/doc/2013-04-01/"><ChangeInfo><Id>/change/1</Id><Status>PENDING</Status><SubmittedAt>2020-01-01T00:00:00Z</SubmittedAt></ChangeInfo></ChangeResourceRecordSetsResponse>`))
+	}
+}
+
+func recordSetKey(rs fakeRecordSet) string {
+	return rs.Name + " " + rs.Type + " " + rs.SetIdentifier
+}
+
+// weights returns the weights of all the record sets keyed by their names and set identifiers
+func (f *fakeRoute53) weights() map[string]int64 {
+	weights := map[string]int64{}
+	for _, rs := range f.recordSets {
+		weights[rs.Name+" "+rs.SetIdentifier] = rs.Weight
+	}
+
+	return weights
+}
+
+func newFakeRecordSet(name, id string, weight int64) fakeRecordSet {
+	return fakeRecordSet{
+		Name:            name,
+		Type:            "CNAME",
+		SetIdentifier:   id,
+		Weight:          weight,
+		TTL:             60,
+		ResourceRecords: []fakeResourceRecord{{Value: id + ".example.com"}},
+	}
+}
+
+func newSyncInput(address, name string, records ...okrav1alpha1.AWSRoute53WeightedRecord) SyncInput {
+	return SyncInput{
+		Spec: okrav1alpha1.AWSRoute53WeightedRecordConfigSpec{
+			HostedZoneID: "ZONE1",
+			Name:         name,
+			Records:      records,
+		},
+		Session: session.Must(session.NewSession(&aws.Config{
+			Region:      aws.String("us-east-1"),
+			Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+		})),
+		Address: address,
+	}
+}
+
+func TestSync(t *testing.T) {
+	web0 := newFakeRecordSet("web.example.com.", "web-0", 100)
+
+	fake := &fakeRoute53{
+		recordSets: map[string]fakeRecordSet{recordSetKey(web0): web0},
+	}
+
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	input := newSyncInput(server.URL, "web.example.com",
+		okrav1alpha1.AWSRoute53WeightedRecord{SetIdentifier: "web-1", Value: "web-1.example.com", Weight: 80, HealthCheckID: "hc-1"},
+		okrav1alpha1.AWSRoute53WeightedRecord{SetIdentifier: "web-2", Value: "web-2.example.com", Weight: 20},
+	)
+
+	if changed, err := Sync(input); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if !changed {
+		t.Errorf("expected the first sync to change record sets")
+	}
+
+	// web-0 is not created by okra so it is kept as is
+	if d := cmp.Diff(map[string]int64{"web.example.com. web-0": 100, "web.example.com. web-1": 80, "web.example.com. web-2": 20}, fake.weights()); d != "" {
+		t.Errorf("unexpected weights: %s", d)
+	}
+
+	if got := fake.recordSets["web.example.com. CNAME web-1"].HealthCheckID; got != "hc-1" {
+		t.Errorf("unexpected health check id: %q", got)
+	}
+
+	input.Managed = Managed(input.Spec)
+
+	if changed, err := Sync(input); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if changed {
+		t.Errorf("expected the second sync to change nothing")
+	}
+
+	if fake.changes != 1 {
+		t.Errorf("expected no change on the second sync, but got %d change batches in total", fake.changes)
+	}
+
+	if err := Delete(input); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if d := cmp.Diff(map[string]int64{"web.example.com. web-0": 100}, fake.weights()); d != "" {
+		t.Errorf("expected only the record sets created by okra to be deleted: %s", d)
+	}
+}
+
+func TestSyncDeletesOnlyManagedRecordSets(t *testing.T) {
+	web0 := newFakeRecordSet("web.example.com.", "web-0", 100)
+
+	fake := &fakeRoute53{
+		recordSets: map[string]fakeRecordSet{recordSetKey(web0): web0},
+	}
+
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	input := newSyncInput(server.URL, "web.example.com",
+		okrav1alpha1.AWSRoute53WeightedRecord{SetIdentifier: "web-1", Value: "web-1.example.com", Weight: 50},
+		okrav1alpha1.AWSRoute53WeightedRecord{SetIdentifier: "web-2", Value: "web-2.example.com", Weight: 50},
+	)
+
+	if _, err := Sync(input); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Removing web-2 from the spec deletes its record set
+	input.Managed = Managed(input.Spec)
+	input.Spec.Records = input.Spec.Records[:1]
+
+	if _, err := Sync(input); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if d := cmp.Diff(map[string]int64{"web.example.com. web-0": 100, "web.example.com. web-1": 50}, fake.weights()); d != "" {
+		t.Errorf("unexpected weights after removing web-2: %s", d)
+	}
+
+	// Renaming the spec deletes the record sets under the previous name
+	input.Managed = Managed(input.Spec)
+	input.Spec.Name = "www.example.com"
+
+	if _, err := Sync(input); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if d := cmp.Diff(map[string]int64{"web.example.com. web-0": 100, "www.example.com. web-1": 50}, fake.weights()); d != "" {
+		t.Errorf("unexpected weights after the rename: %s", d)
+	}
+}
@@ -0,0 +1,70 @@
+package awstargetgroup
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
+	okrav1alpha1 "github.com/mumoshu/okra/api/v1alpha1"
+	"github.com/mumoshu/okra/pkg/awsclicompat"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultTargetHealthInterval is the default interval between DescribeTargetHealth calls per AWSTargetGroup
+const DefaultTargetHealthInterval = 30 * time.Second
+
+type DescribeTargetHealthInput struct {
+	ARN string
+	Now metav1.Time
+
+	// Client defaults to the client for the region of the target group
+	Client elbv2iface.ELBV2API
+}
+
+// DescribeTargetHealth returns the number of the targets per health state of the target group.
+func DescribeTargetHealth(input DescribeTargetHealthInput) (*okrav1alpha1.AWSTargetGroupTargetHealth, error) {
+	svc := input.Client
+	if svc == nil {
+		parsed, err := arn.Parse(input.ARN)
+		if err != nil {
+			return nil, fmt.Errorf("parsing target group arn: %w", err)
+		}
+
+		svc = elbv2.New(awsclicompat.NewSession(parsed.Region, ""))
+	}
+
+	out, err := svc.DescribeTargetHealth(&elbv2.DescribeTargetHealthInput{TargetGroupArn: aws.String(input.ARN)})
+	if err != nil {
+		return nil, fmt.Errorf("describing target health of %s: %w", input.ARN, err)
+	}
+
+	h := &okrav1alpha1.AWSTargetGroupTargetHealth{LastProbeTime: input.Now}
+
+	for _, d := range out.TargetHealthDescriptions {
+		if d.TargetHealth == nil {
+			continue
+		}
+
+		h.Total++
+
+		switch aws.StringValue(d.TargetHealth.State) {
+		case elbv2.TargetHealthStateEnumHealthy:
+			h.Healthy++
+		case elbv2.TargetHealthStateEnumUnhealthy:
+			h.Unhealthy++
+		case elbv2.TargetHealthStateEnumDraining:
+			h.Draining++
+		case elbv2.TargetHealthStateEnumInitial:
+			h.Initial++
+		case elbv2.TargetHealthStateEnumUnused:
+			h.Unused++
+		case elbv2.TargetHealthStateEnumUnavailable:
+			h.Unavailable++
+		}
+	}
+
+	return h, nil
+}
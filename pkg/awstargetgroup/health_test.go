@@ -0,0 +1,45 @@
+package awstargetgroup
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
+	"github.com/google/go-cmp/cmp"
+	okrav1alpha1 "github.com/mumoshu/okra/api/v1alpha1"
+)
+
+type fakeELBV2 struct {
+	elbv2iface.ELBV2API
+
+	states []string
+}
+
+func (f *fakeELBV2) DescribeTargetHealth(in *elbv2.DescribeTargetHealthInput) (*elbv2.DescribeTargetHealthOutput, error) {
+	var out elbv2.DescribeTargetHealthOutput
+
+	for _, s := range f.states {
+		out.TargetHealthDescriptions = append(out.TargetHealthDescriptions, &elbv2.TargetHealthDescription{
+			TargetHealth: &elbv2.TargetHealth{State: aws.String(s)},
+		})
+	}
+
+	return &out, nil
+}
+
+func TestDescribeTargetHealth(t *testing.T) {
+	got, err := DescribeTargetHealth(DescribeTargetHealthInput{
+		ARN:    "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/web/1",
+		Client: &fakeELBV2{states: []string{"healthy", "healthy", "unhealthy", "draining", "initial"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := &okrav1alpha1.AWSTargetGroupTargetHealth{Healthy: 2, Unhealthy: 1, Draining: 1, Initial: 1, Total: 5}
+
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("unexpected target health: %s", d)
+	}
+}
@@ -0,0 +1,180 @@
+package awstargetgroupset
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
+	okrav1alpha1 "github.com/mumoshu/okra/api/v1alpha1"
+	"github.com/mumoshu/okra/pkg/awsclicompat"
+	"golang.org/x/xerrors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// describeTagsMaxARNs is the maximum number of resources per DescribeTags call
+const describeTagsMaxARNs = 20
+
+// AWSTagsGenerator generates AWSTargetGroups from the ELBv2 target groups that have all the tags.
+type AWSTagsGenerator struct {
+	Region         string
+	MatchTags      map[string]string
+	LabelsFromTags map[string]string
+}
+
+// selector returns the selector for the AWSTargetGroups generated from the tags.
+// generateFromAWSTags rejects the tags that are not valid labels, so that the selector never selects more than the generated ones.
+func (g AWSTagsGenerator) selector() string {
+	return labels.SelectorFromSet(g.MatchTags).String()
+}
+
+func newELBV2(region string) elbv2iface.ELBV2API {
+	return elbv2.New(awsclicompat.NewSession(region, ""))
+}
+
+func (g *targetGroupGenerator) generateFromAWSTags(gen AWSTagsGenerator) ([]metav1.Object, error) {
+	if len(gen.MatchTags) == 0 {
+		return nil, xerrors.Errorf("awsTags generator requires at least one tag to match")
+	}
+
+	for k, v := range gen.MatchTags {
+		if !isValidLabel(k, v) {
+			return nil, xerrors.Errorf("awsTags generator requires tags to match to be valid labels: %s=%s", k, v)
+		}
+	}
+
+	newClient := g.newELBV2
+	if newClient == nil {
+		newClient = newELBV2
+	}
+
+	svc := newClient(gen.Region)
+
+	var tgs []*elbv2.TargetGroup
+
+	if err := svc.DescribeTargetGroupsPages(&elbv2.DescribeTargetGroupsInput{}, func(page *elbv2.DescribeTargetGroupsOutput, lastPage bool) bool {
+		tgs = append(tgs, page.TargetGroups...)
+
+		return true
+	}); err != nil {
+		return nil, xerrors.Errorf("describing target groups: %w", err)
+	}
+
+	tags, err := describeTargetGroupTags(svc, tgs)
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []metav1.Object
+
+	for _, tg := range tgs {
+		arn := aws.StringValue(tg.TargetGroupArn)
+		tgTags := tags[arn]
+
+		all := true
+		for k, v := range gen.MatchTags {
+			value, ok := tgTags[k]
+
+			all = all && ok && value == v
+		}
+
+		if !all {
+			continue
+		}
+
+		labels := map[string]string{}
+
+		for k, v := range tgTags {
+			if isValidLabel(k, v) {
+				labels[k] = v
+			}
+		}
+
+		for labelKey, tagKey := range gen.LabelsFromTags {
+			if v, ok := tgTags[tagKey]; ok {
+				labels[labelKey] = v
+			}
+		}
+
+		for k, v := range g.labels {
+			labels[k] = v
+		}
+
+		name := strings.ToLower(aws.StringValue(tg.TargetGroupName))
+
+		// Target group names are unique only within a region, so the same name from another region or generator
+		// would silently overwrite the other AWSTargetGroup
+		if other, ok := g.awsTagsARNs[name]; ok && other != arn {
+			return nil, xerrors.Errorf("awstargetgroup %s is generated from both %s and %s: target group names must be unique across awsTags generators", name, other, arn)
+		}
+
+		if g.awsTagsARNs == nil {
+			g.awsTagsARNs = map[string]string{}
+		}
+
+		g.awsTagsARNs[name] = arn
+
+		objects = append(objects, &okrav1alpha1.AWSTargetGroup{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: okrav1alpha1.GroupVersion.String(),
+				Kind:       "AWSTargetGroup",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: g.ns,
+				Labels:    labels,
+			},
+			Spec: okrav1alpha1.AWSTargetGroupSpec{
+				ARN: arn,
+			},
+		})
+	}
+
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].GetName() < objects[j].GetName()
+	})
+
+	return objects, nil
+}
+
+// describeTargetGroupTags returns the tags of the target groups keyed by their ARNs.
+func describeTargetGroupTags(svc elbv2iface.ELBV2API, tgs []*elbv2.TargetGroup) (map[string]map[string]string, error) {
+	tags := map[string]map[string]string{}
+
+	for i := 0; i < len(tgs); i += describeTagsMaxARNs {
+		end := i + describeTagsMaxARNs
+		if end > len(tgs) {
+			end = len(tgs)
+		}
+
+		var arns []*string
+
+		for _, tg := range tgs[i:end] {
+			arns = append(arns, tg.TargetGroupArn)
+		}
+
+		out, err := svc.DescribeTags(&elbv2.DescribeTagsInput{ResourceArns: arns})
+		if err != nil {
+			return nil, xerrors.Errorf("describing tags: %w", err)
+		}
+
+		for _, d := range out.TagDescriptions {
+			m := map[string]string{}
+
+			for _, t := range d.Tags {
+				m[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+			}
+
+			tags[aws.StringValue(d.ResourceArn)] = m
+		}
+	}
+
+	return tags, nil
+}
+
+func isValidLabel(k, v string) bool {
+	return len(validation.IsQualifiedName(k)) == 0 && len(validation.IsValidLabelValue(v)) == 0
+}
@@ -0,0 +1,227 @@
+package awstargetgroupset
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
+	"github.com/google/go-cmp/cmp"
+	okrav1alpha1 "github.com/mumoshu/okra/api/v1alpha1"
+)
+
+type fakeELBV2 struct {
+	elbv2iface.ELBV2API
+
+	// pages are the names of the target groups returned by DescribeTargetGroups per page
+	pages [][]string
+	tags  map[string]map[string]string
+
+	describeTagsCalls int
+}
+
+func fakeTargetGroupARN(name string) string {
+	return "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/" + name + "/1"
+}
+
+func (f *fakeELBV2) DescribeTargetGroupsPages(in *elbv2.DescribeTargetGroupsInput, fn func(*elbv2.DescribeTargetGroupsOutput, bool) bool) error {
+	for i, page := range f.pages {
+		var out elbv2.DescribeTargetGroupsOutput
+
+		for _, name := range page {
+			out.TargetGroups = append(out.TargetGroups, &elbv2.TargetGroup{
+				TargetGroupName: aws.String(name),
+				TargetGroupArn:  aws.String(fakeTargetGroupARN(name)),
+			})
+		}
+
+		if !fn(&out, i+1 == len(f.pages)) {
+			break
+		}
+	}
+
+	return nil
+}
+
+func (f *fakeELBV2) DescribeTags(in *elbv2.DescribeTagsInput) (*elbv2.DescribeTagsOutput, error) {
+	f.describeTagsCalls++
+
+	if len(in.ResourceArns) > describeTagsMaxARNs {
+		return nil, fmt.Errorf("too many resource arns: %d", len(in.ResourceArns))
+	}
+
+	var out elbv2.DescribeTagsOutput
+
+	for _, arn := range in.ResourceArns {
+		d := &elbv2.TagDescription{ResourceArn: arn}
+
+		for name, tags := range f.tags {
+			if fakeTargetGroupARN(name) != aws.StringValue(arn) {
+				continue
+			}
+
+			for k, v := range tags {
+				d.Tags = append(d.Tags, &elbv2.Tag{Key: aws.String(k), Value: aws.String(v)})
+			}
+		}
+
+		out.TagDescriptions = append(out.TagDescriptions, d)
+	}
+
+	return &out, nil
+}
+
+func TestGenerateFromAWSTags(t *testing.T) {
+	var untagged []string
+	for i := 0; i < 25; i++ {
+		untagged = append(untagged, fmt.Sprintf("other%d", i))
+	}
+
+	svc := &fakeELBV2{
+		pages: [][]string{{"Web-v1"}, append(untagged, "web-v2", "api-v1")},
+		tags: map[string]map[string]string{
+			"Web-v1": {"okra.mumo.co/cell": "web", "version": "1.2.3", "Name": "web v1"},
+			"web-v2": {"okra.mumo.co/cell": "web", "version": "1.3.0"},
+			"api-v1": {"okra.mumo.co/cell": "api", "version": "1.0.0"},
+		},
+	}
+
+	g := &targetGroupGenerator{
+		ns:     "default",
+		labels: map[string]string{"set": "web"},
+		newELBV2: func(region string) elbv2iface.ELBV2API {
+			return svc
+		},
+	}
+
+	gen := AWSTagsGenerator{
+		MatchTags:      map[string]string{"okra.mumo.co/cell": "web"},
+		LabelsFromTags: map[string]string{okrav1alpha1.DefaultVersionLabelKey: "version"},
+	}
+
+	groups, err := g.generateUnion([]Generator{{AWSTags: &gen}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := map[string]map[string]string{}
+
+	for _, tg := range groups {
+		if tg.Spec.ARN == "" {
+			t.Errorf("missing arn: %s", tg.Name)
+		}
+
+		got[tg.Name] = tg.Labels
+	}
+
+	want := map[string]map[string]string{
+		// The Name tag is not copied as its value is not a valid label value
+		"web-v1": {"okra.mumo.co/cell": "web", "version": "1.2.3", okrav1alpha1.DefaultVersionLabelKey: "1.2.3", "set": "web"},
+		"web-v2": {"okra.mumo.co/cell": "web", "version": "1.3.0", okrav1alpha1.DefaultVersionLabelKey: "1.3.0", "set": "web"},
+	}
+
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("unexpected awstargetgroups: %s", d)
+	}
+
+	if svc.describeTagsCalls != 2 {
+		t.Errorf("expected tags to be described in 2 batches, got %d", svc.describeTagsCalls)
+	}
+
+	if sels := bindingSelectors([]Generator{{AWSTags: &gen}}); !cmp.Equal(sels, []string{"okra.mumo.co/cell=web"}) {
+		t.Errorf("unexpected selectors: %v", sels)
+	}
+}
+
+func TestGenerateFromAWSTagsErrors(t *testing.T) {
+	svc := &fakeELBV2{
+		pages: [][]string{{"web-v1"}},
+		tags: map[string]map[string]string{
+			"web-v1": {"okra.mumo.co/cell": "web", "Name": "web v1"},
+		},
+	}
+
+	testcases := []struct {
+		name string
+		gens []Generator
+		err  string
+	}{
+		{
+			name: "invalid label",
+			gens: []Generator{
+				{AWSTags: &AWSTagsGenerator{MatchTags: map[string]string{"Name": "web v1"}}},
+			},
+			err: "awsTags generator requires tags to match to be valid labels: Name=web v1",
+		},
+		{
+			name: "duplicate name across regions",
+			gens: []Generator{
+				{AWSTags: &AWSTagsGenerator{Region: "us-east-1", MatchTags: map[string]string{"okra.mumo.co/cell": "web"}}},
+				{AWSTags: &AWSTagsGenerator{Region: "us-west-2", MatchTags: map[string]string{"okra.mumo.co/cell": "web"}}},
+			},
+			err: "awstargetgroup web-v1 is generated from both " + fakeTargetGroupARN("web-v1") + " and " + fakeTargetGroupARN("web-v1") + "-us-west-2: target group names must be unique across awsTags generators",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := &targetGroupGenerator{
+				ns: "default",
+				newELBV2: func(region string) elbv2iface.ELBV2API {
+					if region == "us-west-2" {
+						return &regionalELBV2{fakeELBV2: svc, suffix: "-us-west-2"}
+					}
+
+					return svc
+				},
+			}
+
+			_, err := g.generateUnion(tc.gens)
+			if err == nil {
+				t.Fatal("expected error, got none")
+			}
+
+			if err.Error() != tc.err {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// regionalELBV2 returns the target groups of fakeELBV2 with the ARNs suffixed, like the ones of the same names in another region.
+type regionalELBV2 struct {
+	*fakeELBV2
+
+	suffix string
+}
+
+func (f *regionalELBV2) DescribeTargetGroupsPages(in *elbv2.DescribeTargetGroupsInput, fn func(*elbv2.DescribeTargetGroupsOutput, bool) bool) error {
+	return f.fakeELBV2.DescribeTargetGroupsPages(in, func(out *elbv2.DescribeTargetGroupsOutput, lastPage bool) bool {
+		for _, tg := range out.TargetGroups {
+			tg.TargetGroupArn = aws.String(aws.StringValue(tg.TargetGroupArn) + f.suffix)
+		}
+
+		return fn(out, lastPage)
+	})
+}
+
+func (f *regionalELBV2) DescribeTags(in *elbv2.DescribeTagsInput) (*elbv2.DescribeTagsOutput, error) {
+	var arns []*string
+
+	for _, arn := range in.ResourceArns {
+		arns = append(arns, aws.String(strings.TrimSuffix(aws.StringValue(arn), f.suffix)))
+	}
+
+	out, err := f.fakeELBV2.DescribeTags(&elbv2.DescribeTagsInput{ResourceArns: arns})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, d := range out.TagDescriptions {
+		d.ResourceArn = aws.String(aws.StringValue(d.ResourceArn) + f.suffix)
+	}
+
+	return out, nil
+}
@@ -6,10 +6,11 @@ import (
 	"os"
 
 	"github.com/blang/semver"
-	"github.com/mumoshu/okra/api/elbv2/v1beta1"
 	okrav1alpha1 "github.com/mumoshu/okra/api/v1alpha1"
+	"github.com/mumoshu/okra/pkg/cell"
 	"github.com/mumoshu/okra/pkg/clclient"
 	"github.com/mumoshu/okra/pkg/okraerror"
+	"github.com/mumoshu/okra/pkg/targetgroupbinding"
 	"golang.org/x/xerrors"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -19,6 +20,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/yaml"
 )
 
@@ -44,6 +46,17 @@ type CreateTargetGroupInput struct {
 	Name   string
 	ARN    string
 	Labels map[string]string
+
+	// Context defaults to context.Background()
+	Context context.Context
+}
+
+func (config CreateTargetGroupInput) context() context.Context {
+	if config.Context != nil {
+		return config.Context
+	}
+
+	return context.Background()
 }
 
 type SyncInput struct {
@@ -53,6 +66,32 @@ type SyncInput struct {
 	ClusterSelector string
 	BindingSelector string
 	Labels          map[string]string
+	// LabelsFromCluster are the keys of the labels copied from the cluster secret onto the AWSTargetGroups generated for the cluster
+	LabelsFromCluster []string
+	// Generators generate AWSTargetGroups. The union of the generated AWSTargetGroups are synced.
+	// When empty, ClusterName, ClusterSelector, and BindingSelector are used instead.
+	Generators []Generator
+	// SetName is the name of the AWSTargetGroupSet. When set, the generated AWSTargetGroups are labeled with it,
+	// and only the AWSTargetGroups labeled with it are deleted when outdated.
+	// Otherwise, outdated AWSTargetGroups are selected by the binding selectors of the generators.
+	SetName string
+	// ForceDelete deletes outdated AWSTargetGroups even when their target groups still receive traffic.
+	// An AWSTargetGroup annotated with okra.mumo.co/force-delete=true is deleted regardless of this.
+	ForceDelete bool
+
+	// Context defaults to context.Background()
+	Context context.Context
+	// Client is the client for the management cluster, like the cached client of the controller manager.
+	// Defaults to an uncached client created from the kubeconfig or the in-cluster config.
+	Client client.Client
+}
+
+func (config SyncInput) context() context.Context {
+	if config.Context != nil {
+		return config.Context
+	}
+
+	return context.Background()
 }
 
 type DeleteInput struct {
@@ -82,10 +121,12 @@ func (p *Provider) CreateTargetGroup(config CreateTargetGroupInput) error {
 		return okraerror.New(fmt.Errorf("name is required"))
 	}
 
+	ctx := config.context()
+
 	var object okrav1alpha1.AWSTargetGroup
 	var groupExists bool
 
-	if err := p.Client.Get(context.TODO(), types.NamespacedName{Namespace: ns, Name: name}, &object); err != nil {
+	if err := p.Client.Get(ctx, types.NamespacedName{Namespace: ns, Name: name}, &object); err != nil {
 		if !errors.IsNotFound(err) {
 			return okraerror.New(fmt.Errorf("getting awstargetgroup: %w", err))
 		}
@@ -99,6 +140,15 @@ func (p *Provider) CreateTargetGroup(config CreateTargetGroupInput) error {
 		}
 	} else {
 		groupExists = true
+
+		// Merge the labels so that the existing ones set by others are kept
+		for k, v := range labels {
+			if object.Labels == nil {
+				object.Labels = map[string]string{}
+			}
+
+			object.Labels[k] = v
+		}
 	}
 
 	object.Spec.ARN = arn
@@ -115,12 +165,12 @@ func (p *Provider) CreateTargetGroup(config CreateTargetGroupInput) error {
 	}
 
 	if groupExists {
-		if err := p.Client.Update(context.TODO(), &object); err != nil {
+		if err := p.Client.Update(ctx, &object); err != nil {
 			return okraerror.New(fmt.Errorf("updating targetgroup: %w", err))
 		}
 		fmt.Printf("AWSTargetGroup %q updated successfully\n", name)
 	} else {
-		if err := p.Client.Create(context.TODO(), &object); err != nil {
+		if err := p.Client.Create(ctx, &object); err != nil {
 			return okraerror.New(fmt.Errorf("creating targetgroup: %w", err))
 		}
 		fmt.Printf("AWSTargetGroup %q created successfully\n", name)
@@ -129,118 +179,128 @@ func (p *Provider) CreateTargetGroup(config CreateTargetGroupInput) error {
 	return nil
 }
 
+// CreateMissingAWSTargetGroups applies the desired AWSTargetGroups.
+// It returns an error when any of the AWSTargetGroups failed to be applied, along with the results for all the AWSTargetGroups.
 func CreateMissingAWSTargetGroups(config SyncInput) ([]SyncResult, error) {
-	ns := config.NS
-	dryRun := config.DryRun
-
-	clientset, err := clclient.NewClientSet()
+	results, err := createMissingAWSTargetGroups(config)
 	if err != nil {
-		return nil, xerrors.Errorf("creating clientset: %w", err)
+		return nil, err
 	}
 
-	kubeclient := clientset.CoreV1().Secrets(ns)
+	return results, resultsError(results)
+}
 
-	managementClient, err := clclient.New()
+func createMissingAWSTargetGroups(config SyncInput) ([]SyncResult, error) {
+	managementClient, objects, err := desiredAWSTargetGroupsWithClient(config)
 	if err != nil {
-		return nil, xerrors.Errorf("creating cr clientset: %w", err)
+		return nil, err
 	}
 
-	var clusters []corev1.Secret
+	return applyAWSTargetGroups(config, managementClient, objects), nil
+}
 
-	if config.ClusterName != "" {
-		secret, err := kubeclient.Get(context.TODO(), config.ClusterName, metav1.GetOptions{})
-		if err != nil {
-			return nil, xerrors.Errorf("getting cluster secret: %w", err)
-		}
+// desiredAWSTargetGroupsWithClient returns the client for the management cluster along with the desired AWSTargetGroups.
+func desiredAWSTargetGroupsWithClient(config SyncInput) (client.Client, []okrav1alpha1.AWSTargetGroup, error) {
+	managementClient, err := newManagementClient(config.Client)
+	if err != nil {
+		return nil, nil, xerrors.Errorf("creating cr clientset: %w", err)
+	}
 
-		clusters = append(clusters, *secret)
-	} else if config.ClusterSelector != "" {
-		secretList, err := kubeclient.List(context.TODO(), metav1.ListOptions{LabelSelector: config.ClusterSelector})
-		if err != nil {
-			return nil, xerrors.Errorf("listing cluster secrets: %w", err)
-		}
+	objects, err := desiredAWSTargetGroups(config, managementClient)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return managementClient, objects, nil
+}
 
-		clusters = secretList.Items
+// newManagementClient returns the client when it is given, or a new uncached client for the management cluster.
+func newManagementClient(c client.Client) (client.Client, error) {
+	if c != nil {
+		return c, nil
 	}
 
-	for _, cluster := range clusters {
-		clusterClient, err := clclient.NewFromClusterSecret(cluster)
-		if err != nil {
-			return nil, fmt.Errorf("creating cr client from cluster secret: %w", err)
-		}
+	return clclient.New()
+}
 
-		var bindings v1beta1.TargetGroupBindingList
+func applyAWSTargetGroups(config SyncInput, managementClient client.Client, objects []okrav1alpha1.AWSTargetGroup) []SyncResult {
+	ctx := config.context()
+	ns := config.NS
+	dryRun := config.DryRun
 
-		optionalNS := ""
+	var results []SyncResult
 
-		sel, err := labels.Parse(config.BindingSelector)
-		if err != nil {
-			return nil, xerrors.Errorf("parsing binding selector: %v", err)
-		}
+	for _, object := range objects {
+		object := object
 
-		if err := clusterClient.List(context.TODO(), &bindings, &runtimeclient.ListOptions{
-			Namespace:     optionalNS,
-			LabelSelector: sel,
-		}); err != nil {
-			return nil, okraerror.New(fmt.Errorf("list targetgroupbidings: %w", err))
+		result := SyncResult{
+			Cluster: object.Labels[okrav1alpha1.AWSTargetGroupLabelBindingCluster],
+			Name:    object.Name,
+			Action:  ActionCreate,
 		}
 
-		var objects []okrav1alpha1.AWSTargetGroup
-
-		for _, b := range bindings.Items {
-			labels := map[string]string{}
+		var current okrav1alpha1.AWSTargetGroup
 
-			for k, v := range b.Labels {
-				labels[k] = v
+		if err := managementClient.Get(ctx, types.NamespacedName{Namespace: ns, Name: object.Name}, &current); err != nil {
+			if !kerrors.IsNotFound(err) {
+				result.Err = okraerror.New(fmt.Errorf("get awstargetgroup: %w", err))
+				results = append(results, result)
+				continue
 			}
+		} else if !current.DeletionTimestamp.IsZero() {
+			// The AWSTargetGroup was deleted by hand. It is recreated in the next sync once the deletion is done.
+			continue
+		} else {
+			result.Action = ActionUpdate
+		}
 
-			for k, v := range config.Labels {
-				labels[k] = v
-			}
+		// The finalizer is removed only when the target group no longer receives traffic, or the deletion is forced
+		controllerutil.AddFinalizer(&object, okrav1alpha1.FinalizerTrafficGuard)
 
-			labels[okrav1alpha1.AWSTargetGroupLabelBindingCluster] = config.ClusterName
-			labels[okrav1alpha1.AWSTargetGroupLabelBindingNamespace] = b.Namespace
-			labels[okrav1alpha1.AWSTargetGroupLabelBindingName] = b.Name
-
-			objects = append(objects, okrav1alpha1.AWSTargetGroup{
-				TypeMeta: metav1.TypeMeta{
-					APIVersion: okrav1alpha1.GroupVersion.String(),
-					Kind:       "AWSTargetGroup",
-				},
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      fmt.Sprintf("%s-%s", b.Namespace, b.Name),
-					Namespace: ns,
-					Labels:    labels,
-				},
-				Spec: okrav1alpha1.AWSTargetGroupSpec{
-					ARN: b.Spec.TargetGroupARN,
-				},
-			})
-		}
+		// Manage resource
+		if !dryRun {
+			err := managementClient.Patch(ctx, &object, runtimeclient.Apply, client.ForceOwnership, client.FieldOwner("okra"))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed creating object: %+v\n", object)
 
-		for _, object := range objects {
-			// Manage resource
-			if !dryRun {
-				err := managementClient.Patch(context.TODO(), &object, runtimeclient.Apply, client.ForceOwnership, client.FieldOwner("okra"))
-				if err != nil {
-					if kerrors.IsAlreadyExists(err) {
-						fmt.Printf("AWSTargetGroup %q has no change\n", object.Name)
-					} else {
-						fmt.Fprintf(os.Stderr, "Failed creating object: %+v\n", object)
-						return nil, okraerror.New(fmt.Errorf("create awstargetgroup: %w", err))
-					}
-				} else {
-					fmt.Printf("AWSTargetGroup %q applied successfully\n", object.Name)
-				}
+				result.Err = okraerror.New(fmt.Errorf("create awstargetgroup: %w", err))
+			} else if result.Action == ActionUpdate && object.ResourceVersion == current.ResourceVersion {
+				fmt.Printf("AWSTargetGroup %q has no change\n", object.Name)
+
+				result.Action = ActionUnchanged
 			} else {
-				fmt.Printf("AWSTargetGroup %q applied successfully (Dry Run)\n", object.Name)
+				fmt.Printf("AWSTargetGroup %q applied successfully\n", object.Name)
+
+				result.Err = updateAWSTargetGroupStatus(ctx, managementClient, &object, result.Cluster)
 			}
+		} else {
+			fmt.Printf("AWSTargetGroup %q applied successfully (Dry Run)\n", object.Name)
 		}
+
+		results = append(results, result)
 	}
 
-	var created []SyncResult
+	return results
+}
+
+// updateAWSTargetGroupStatus records the cluster the AWSTargetGroup was generated for, to the status of the AWSTargetGroup.
+// This is called only when the AWSTargetGroup is changed, so that the status update does not trigger another sync.
+func updateAWSTargetGroupStatus(ctx context.Context, c client.Client, tg *okrav1alpha1.AWSTargetGroup, cluster string) error {
+	tg.Status.LastSyncTime = metav1.Now()
+	tg.Status.Phase = "Synced"
+	tg.Status.Reason = ""
+	tg.Status.Message = ""
+	tg.Status.Clusters.Names = nil
+
+	if cluster != "" {
+		tg.Status.Clusters.Names = []string{cluster}
+	}
 
-	return created, nil
+	if err := c.Status().Update(ctx, tg); err != nil {
+		return fmt.Errorf("update awstargetgroup status: %w", err)
+	}
+
+	return nil
 }
 
 func Delete(config DeleteInput) error {
@@ -272,99 +332,69 @@ func Delete(config DeleteInput) error {
 	return nil
 }
 
+// DeleteOutdatedAWSTargetGroups deletes the AWSTargetGroups that are no longer desired.
+// It returns an error when any of the AWSTargetGroups failed to be deleted, along with the results for all the AWSTargetGroups.
 func DeleteOutdatedAWSTargetGroups(config SyncInput) ([]SyncResult, error) {
-	ns := config.NS
-	dryRun := config.DryRun
-
-	clientset, err := clclient.NewClientSet()
+	results, err := deleteOutdatedAWSTargetGroups(config)
 	if err != nil {
-		return nil, xerrors.Errorf("creating clientset: %w", err)
+		return nil, err
 	}
 
-	kubeclient := clientset.CoreV1().Secrets(ns)
+	return results, resultsError(results)
+}
 
-	managementClient, err := clclient.New()
+func deleteOutdatedAWSTargetGroups(config SyncInput) ([]SyncResult, error) {
+	managementClient, objects, err := desiredAWSTargetGroupsWithClient(config)
 	if err != nil {
 		return nil, err
 	}
 
-	var clusters []corev1.Secret
+	return deleteAWSTargetGroupsExcept(config, managementClient, objects)
+}
 
-	if config.ClusterName != "" {
-		secret, err := kubeclient.Get(context.TODO(), config.ClusterName, metav1.GetOptions{})
-		if err != nil {
-			return nil, xerrors.Errorf("getting cluster secret: %w", err)
-		}
+// deleteAWSTargetGroupsExcept deletes the AWSTargetGroups generated by the set, or selected by the generators, except the desired ones.
+// An empty binding selector is skipped, as it would select all the AWSTargetGroups in the namespace.
+// For a provisioned AWSTargetGroup, the target group and the TargetGroupBinding are deleted before the AWSTargetGroup,
+// so that the deletion is retried in the next sync when either failed.
+func deleteAWSTargetGroupsExcept(config SyncInput, managementClient client.Client, objects []okrav1alpha1.AWSTargetGroup) ([]SyncResult, error) {
+	ctx := config.context()
+	ns := config.NS
+	dryRun := config.DryRun
 
-		clusters = append(clusters, *secret)
-	} else if config.ClusterSelector != "" {
-		secretList, err := kubeclient.List(context.TODO(), metav1.ListOptions{LabelSelector: config.ClusterSelector})
-		if err != nil {
-			return nil, xerrors.Errorf("listing cluster secrets: %w", err)
-		}
+	desiredTargetGroups := map[string]struct{}{}
 
-		clusters = secretList.Items
+	for _, obj := range objects {
+		desiredTargetGroups[obj.Name] = struct{}{}
 	}
 
-	var deleted []SyncResult
+	var (
+		deleted []SyncResult
+		guard   *cell.TrafficGuard
+	)
 
-	for _, secret := range clusters {
-		client, err := clclient.NewFromClusterSecret(secret)
-		if err != nil {
-			return nil, err
-		}
-
-		var bindings v1beta1.TargetGroupBindingList
+	seen := map[string]struct{}{}
 
-		optionalNS := ""
+	selectors := bindingSelectors(config.generators())
+	if config.SetName != "" {
+		selectors = []string{labels.SelectorFromSet(labels.Set{okrav1alpha1.AWSTargetGroupLabelSet: config.SetName}).String()}
+	}
 
-		sel, err := labels.Parse(config.BindingSelector)
+	for _, bindingSelector := range selectors {
+		sel, err := labels.Parse(bindingSelector)
 		if err != nil {
 			return nil, xerrors.Errorf("parsing binding selector: %v", err)
 		}
 
-		if err := client.List(context.TODO(), &bindings, &runtimeclient.ListOptions{
-			Namespace:     optionalNS,
-			LabelSelector: sel,
-		}); err != nil {
-			return nil, okraerror.New(fmt.Errorf("list bindings: %w", err))
-		}
-
-		var objects []okrav1alpha1.AWSTargetGroup
-
-		for _, b := range bindings.Items {
-			labels := map[string]string{}
-
-			for k, v := range b.Labels {
-				labels[k] = v
-			}
-
-			for k, v := range config.Labels {
-				labels[k] = v
-			}
-
-			objects = append(objects, okrav1alpha1.AWSTargetGroup{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      fmt.Sprintf("%s-%s", b.Namespace, b.Name),
-					Namespace: ns,
-					Labels:    labels,
-				},
-				Spec: okrav1alpha1.AWSTargetGroupSpec{
-					ARN: b.Spec.TargetGroupARN,
-				},
-			})
-		}
-
-		desiredTargetGroups := map[string]struct{}{}
+		if sel.Empty() {
+			fmt.Printf("Skipped deleting outdated AWSTargetGroups for the empty binding selector\n")
 
-		for _, obj := range objects {
-			desiredTargetGroups[obj.Name] = struct{}{}
+			continue
 		}
 
 		var current okrav1alpha1.AWSTargetGroupList
 
-		if err := managementClient.List(context.TODO(), &current, &runtimeclient.ListOptions{
-			Namespace:     optionalNS,
+		if err := managementClient.List(ctx, &current, &runtimeclient.ListOptions{
+			Namespace:     ns,
 			LabelSelector: sel,
 		}); err != nil {
 			return nil, okraerror.New(fmt.Errorf("list awstargetgroups: %w", err))
@@ -373,50 +403,123 @@ func DeleteOutdatedAWSTargetGroups(config SyncInput) ([]SyncResult, error) {
 		for _, item := range current.Items {
 			name := item.Name
 
-			if _, desired := desiredTargetGroups[name]; !desired {
-				if dryRun {
-					fmt.Printf("AWSTargetGroup %q deleted successfully (Dry Run)\n", name)
-				} else {
-					// Manage resource
-					var awstg okrav1alpha1.AWSTargetGroup
+			_, desired := desiredTargetGroups[name]
+			terminating := !item.DeletionTimestamp.IsZero()
 
-					if err := managementClient.Get(context.TODO(), types.NamespacedName{Namespace: ns, Name: name}, &awstg); err != nil {
-						return nil, fmt.Errorf("getting awstargetgroup: %w", err)
-					}
+			// A desired AWSTargetGroup is deleted only when someone deleted it by hand.
+			if (desired && !terminating) || (terminating && !controllerutil.ContainsFinalizer(&item, okrav1alpha1.FinalizerTrafficGuard)) {
+				continue
+			}
+
+			if _, ok := seen[name]; ok {
+				continue
+			}
+
+			seen[name] = struct{}{}
 
-					err := managementClient.Delete(context.TODO(), &awstg)
+			result := SyncResult{
+				Cluster: item.Labels[okrav1alpha1.AWSTargetGroupLabelBindingCluster],
+				Name:    name,
+				Action:  ActionDelete,
+			}
+
+			if !config.ForceDelete && !cell.ForceDeleteRequested(item.Annotations) {
+				if guard == nil {
+					guard, err = cell.NewTrafficGuard(ctx, managementClient)
 					if err != nil {
-						return nil, fmt.Errorf("delete awstargetgroup: %w", err)
+						return nil, err
 					}
+				}
 
-					fmt.Printf("AWSTargetGroup %q deleted successfully\n", name)
+				if err := guard.CheckTargetGroup(item); err != nil {
+					fmt.Printf("AWSTargetGroup %q is not deleted: %v\n", name, err)
+
+					result.Err = err
+					deleted = append(deleted, result)
+
+					continue
 				}
+			}
+
+			// Manage resource
+			item := item
 
-				deleted = append(deleted, SyncResult{
-					Cluster: secret.Namespace + "/" + secret.Name,
-					Name:    name,
-					Action:  "Delete",
-				})
+			if dryRun {
+				fmt.Printf("AWSTargetGroup %q deleted successfully (Dry Run)\n", name)
+			} else if item.Labels[okrav1alpha1.AWSTargetGroupLabelProvisioner] != "" {
+				clusterSelected, err := selectsBindingCluster(ctx, managementClient, ns, config.generators(), item)
+				if err != nil {
+					result.Err = err
+				} else if err := deprovision(ctx, managementClient, item, ns, clusterSelected, newELBV2, targetgroupbinding.Delete); err != nil {
+					result.Err = fmt.Errorf("deprovisioning awstargetgroup: %w", err)
+				} else if err := deleteAWSTargetGroup(ctx, managementClient, &item); err != nil {
+					result.Err = err
+				} else {
+					fmt.Printf("AWSTargetGroup %q deleted successfully along with its target group\n", name)
+				}
+			} else {
+				if err := deleteAWSTargetGroup(ctx, managementClient, &item); err != nil {
+					result.Err = err
+				} else {
+					fmt.Printf("AWSTargetGroup %q deleted successfully\n", name)
+				}
 			}
+
+			deleted = append(deleted, result)
 		}
 	}
 
 	return deleted, nil
 }
 
-type SyncResult struct {
-	Cluster string
-	Name    string
-	Action  string
+// selectsBindingCluster returns true when the cluster secret of the AWSTargetGroup exists and is still selected by the generators.
+func selectsBindingCluster(ctx context.Context, c client.Client, ns string, gens []Generator, tg okrav1alpha1.AWSTargetGroup) (bool, error) {
+	var secret corev1.Secret
+
+	if err := c.Get(ctx, types.NamespacedName{Namespace: ns, Name: tg.Labels[okrav1alpha1.AWSTargetGroupLabelBindingCluster]}, &secret); err != nil {
+		if kerrors.IsNotFound(err) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("getting cluster secret: %w", err)
+	}
+
+	return SelectsCluster(gens, secret), nil
+}
+
+// deleteAWSTargetGroup removes the traffic guard finalizer from the AWSTargetGroup and deletes it, unless it is already being deleted.
+func deleteAWSTargetGroup(ctx context.Context, c client.Client, tg *okrav1alpha1.AWSTargetGroup) error {
+	if controllerutil.ContainsFinalizer(tg, okrav1alpha1.FinalizerTrafficGuard) {
+		controllerutil.RemoveFinalizer(tg, okrav1alpha1.FinalizerTrafficGuard)
+
+		if err := c.Update(ctx, tg); err != nil {
+			return fmt.Errorf("remove finalizer from awstargetgroup: %w", err)
+		}
+	}
+
+	if !tg.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	if err := c.Delete(ctx, tg); err != nil && !kerrors.IsNotFound(err) {
+		return fmt.Errorf("delete awstargetgroup: %w", err)
+	}
+
+	return nil
 }
 
+// Sync applies the desired AWSTargetGroups and deletes outdated ones.
+// It returns the results for all the AWSTargetGroups, and an error when the AWSTargetGroups could not be computed
+// or any of them failed to be synced.
 func Sync(config SyncInput) ([]SyncResult, error) {
-	created, err := CreateMissingAWSTargetGroups(config)
+	managementClient, objects, err := desiredAWSTargetGroupsWithClient(config)
 	if err != nil {
-		return nil, xerrors.Errorf("creating missing target groups: %w", err)
+		return nil, xerrors.Errorf("computing desired target groups: %w", err)
 	}
 
-	deleted, err := DeleteOutdatedAWSTargetGroups(config)
+	created := applyAWSTargetGroups(config, managementClient, objects)
+
+	deleted, err := deleteAWSTargetGroupsExcept(config, managementClient, objects)
 	if err != nil {
 		return created, xerrors.Errorf("deleting redundant target groups: %w", err)
 	}
@@ -424,7 +527,7 @@ func Sync(config SyncInput) ([]SyncResult, error) {
 	all := append([]SyncResult{}, created...)
 	all = append(all, deleted...)
 
-	return all, nil
+	return all, resultsError(all)
 }
 
 type ListLatestAWSTargetGroupsInput struct {
@@ -438,6 +541,11 @@ type ListAWSTargetGroupsInput struct {
 	NS       string
 	Selector string
 	Version  string
+
+	// Context defaults to context.Background()
+	Context context.Context
+	// Client defaults to an uncached client for the management cluster
+	Client client.Client
 }
 
 func ListLatestAWSTargetGroups(config ListLatestAWSTargetGroupsInput) (*semver.Version, []okrav1alpha1.AWSTargetGroup, error) {
@@ -515,7 +623,12 @@ func ListLatestAWSTargetGroups(config ListLatestAWSTargetGroupsInput) (*semver.V
 }
 
 func ListAWSTargetGroups(config ListAWSTargetGroupsInput) ([]okrav1alpha1.AWSTargetGroup, error) {
-	managementClient, err := clclient.New()
+	ctx := config.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	managementClient, err := newManagementClient(config.Client)
 	if err != nil {
 		return nil, err
 	}
@@ -527,7 +640,7 @@ func ListAWSTargetGroups(config ListAWSTargetGroupsInput) ([]okrav1alpha1.AWSTar
 
 	var list okrav1alpha1.AWSTargetGroupList
 
-	if err := managementClient.List(context.TODO(), &list, &runtimeclient.ListOptions{
+	if err := managementClient.List(ctx, &list, &runtimeclient.ListOptions{
 		Namespace:     config.NS,
 		LabelSelector: sel,
 	}); err != nil {
@@ -0,0 +1,126 @@
+package awstargetgroupset
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	okrav1alpha1 "github.com/mumoshu/okra/api/v1alpha1"
+	"github.com/mumoshu/okra/pkg/clclient"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	crfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCreateTargetGroupUpdatesExisting(t *testing.T) {
+	c := crfake.NewFakeClientWithScheme(clclient.Scheme(), &okrav1alpha1.AWSTargetGroup{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "web1",
+			Labels:    map[string]string{"role": "web", "owner": "someone"},
+		},
+		Spec: okrav1alpha1.AWSTargetGroupSpec{ARN: "arn:old"},
+	})
+
+	err := New(c).CreateTargetGroup(CreateTargetGroupInput{
+		NS:      "default",
+		Name:    "web1",
+		ARN:     "arn:new",
+		Labels:  map[string]string{"role": "api", okrav1alpha1.DefaultVersionLabelKey: "1.0.0"},
+		Context: context.Background(),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got okrav1alpha1.AWSTargetGroup
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "web1"}, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Spec.ARN != "arn:new" {
+		t.Errorf("unexpected arn: %s", got.Spec.ARN)
+	}
+
+	want := map[string]string{"role": "api", "owner": "someone", okrav1alpha1.DefaultVersionLabelKey: "1.0.0"}
+	if d := cmp.Diff(want, got.Labels); d != "" {
+		t.Errorf("unexpected labels: -want +got\n%s", d)
+	}
+}
+
+func TestDeleteAWSTargetGroupsExcept(t *testing.T) {
+	tg := func(name string, labels map[string]string) *okrav1alpha1.AWSTargetGroup {
+		return &okrav1alpha1.AWSTargetGroup{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      name,
+				Labels:    labels,
+			},
+			Spec: okrav1alpha1.AWSTargetGroupSpec{ARN: "arn:" + name},
+		}
+	}
+
+	testcases := []struct {
+		name    string
+		config  SyncInput
+		deleted []string
+	}{
+		{
+			name: "set name",
+			config: SyncInput{
+				SetName:    "web",
+				Generators: []Generator{{ClusterName: "web1"}},
+			},
+			deleted: []string{"web-v1"},
+		},
+		{
+			name: "empty binding selector",
+			config: SyncInput{
+				Generators: []Generator{{ClusterName: "web1"}},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := crfake.NewFakeClientWithScheme(clclient.Scheme(),
+				tg("web-v1", map[string]string{okrav1alpha1.AWSTargetGroupLabelSet: "web"}),
+				tg("web-v2", map[string]string{okrav1alpha1.AWSTargetGroupLabelSet: "web"}),
+				tg("api-v1", map[string]string{okrav1alpha1.AWSTargetGroupLabelSet: "api"}),
+				tg("manual", nil),
+			)
+
+			config := tc.config
+			config.NS = "default"
+			config.Context = context.Background()
+
+			results, err := deleteAWSTargetGroupsExcept(config, c, []okrav1alpha1.AWSTargetGroup{*tg("web-v2", nil)})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var deleted []string
+
+			for _, r := range results {
+				if r.Err != nil {
+					t.Errorf("unexpected error for %s: %v", r.Name, r.Err)
+				}
+
+				deleted = append(deleted, r.Name)
+			}
+
+			if d := cmp.Diff(tc.deleted, deleted); d != "" {
+				t.Errorf("unexpected deletions: -want +got\n%s", d)
+			}
+
+			var list okrav1alpha1.AWSTargetGroupList
+			if err := c.List(context.Background(), &list); err != nil {
+				t.Fatal(err)
+			}
+
+			if got, want := len(list.Items), 4-len(tc.deleted); got != want {
+				t.Errorf("expected %d awstargetgroups to remain, got %d", want, got)
+			}
+		})
+	}
+}
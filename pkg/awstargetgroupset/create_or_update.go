@@ -57,12 +57,14 @@ func CreateOrUpdate(input ApplyInput) (*okrav1alpha1.AWSTargetGroupSet, error) {
 	op, err := ctrl.CreateOrUpdate(ctx, client, &set, func() error {
 		set.Spec.Generators = []okrav1alpha1.AWSTargetGroupGenerator{
 			{
-				AWSEKS: okrav1alpha1.AWSTargetGroupGeneratorAWSEKS{
-					ClusterSelector: okrav1alpha1.TargetGroupClusterSelector{
-						MatchLabels: input.ClusterSelector,
-					},
-					BindingSelector: okrav1alpha1.TargetGroupBindingSelector{
-						MatchLabels: input.BindingSelector,
+				AWSTargetGroupBaseGenerator: okrav1alpha1.AWSTargetGroupBaseGenerator{
+					AWSEKS: &okrav1alpha1.AWSTargetGroupGeneratorAWSEKS{
+						ClusterSelector: okrav1alpha1.TargetGroupClusterSelector{
+							MatchLabels: input.ClusterSelector,
+						},
+						BindingSelector: okrav1alpha1.TargetGroupBindingSelector{
+							MatchLabels: input.BindingSelector,
+						},
 					},
 				},
 			},
@@ -0,0 +1,379 @@
+package awstargetgroupset
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
+	"github.com/mumoshu/okra/api/elbv2/v1beta1"
+	okrav1alpha1 "github.com/mumoshu/okra/api/v1alpha1"
+	"github.com/mumoshu/okra/pkg/clclient"
+	"github.com/mumoshu/okra/pkg/generator"
+	"github.com/mumoshu/okra/pkg/okraerror"
+	"github.com/mumoshu/okra/pkg/targetgroupbinding"
+	"golang.org/x/xerrors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Generator generates AWSTargetGroups from TargetGroupBindings in clusters, or from tagged ELBv2 target groups.
+// Either the cluster name or the cluster selector, AWSTags, or one of the combinators should be set.
+type Generator struct {
+	ClusterName     string
+	ClusterSelector string
+	BindingSelector string
+
+	AWSTags *AWSTagsGenerator
+
+	Matrix []Generator
+	Merge  []Generator
+
+	// Provision creates target groups and TargetGroupBindings for the clusters. It cannot be nested in combinators.
+	Provision *okrav1alpha1.AWSTargetGroupProvisionGenerator
+}
+
+// NewGenerators converts AWSTargetGroupSet generators into Generators.
+func NewGenerators(gens []okrav1alpha1.AWSTargetGroupGenerator) []Generator {
+	var generators []Generator
+
+	for _, g := range gens {
+		var gen Generator
+
+		if g.Matrix != nil {
+			gen.Matrix = newBaseGenerators(g.Matrix.Generators)
+		} else if g.Merge != nil {
+			gen.Merge = newBaseGenerators(g.Merge.Generators)
+		} else if g.Provision != nil {
+			gen.Provision = g.Provision
+		} else {
+			gen = newBaseGenerator(g.AWSTargetGroupBaseGenerator)
+		}
+
+		generators = append(generators, gen)
+	}
+
+	return generators
+}
+
+func newBaseGenerators(gens []okrav1alpha1.AWSTargetGroupBaseGenerator) []Generator {
+	var generators []Generator
+
+	for _, g := range gens {
+		generators = append(generators, newBaseGenerator(g))
+	}
+
+	return generators
+}
+
+func newBaseGenerator(g okrav1alpha1.AWSTargetGroupBaseGenerator) Generator {
+	var gen Generator
+
+	if g.AWSEKS != nil {
+		gen.ClusterSelector = labels.SelectorFromSet(g.AWSEKS.ClusterSelector.MatchLabels).String()
+		gen.BindingSelector = labels.SelectorFromSet(g.AWSEKS.BindingSelector.MatchLabels).String()
+	} else if g.AWSTags != nil {
+		gen.AWSTags = &AWSTagsGenerator{
+			Region:         g.AWSTags.Region,
+			MatchTags:      g.AWSTags.MatchTags,
+			LabelsFromTags: g.AWSTags.LabelsFromTags,
+		}
+	}
+
+	return gen
+}
+
+// generators returns the generators in the config.
+// When no generators are given, it falls back to the cluster name and the selectors in the config.
+func (config SyncInput) generators() []Generator {
+	if len(config.Generators) > 0 {
+		return config.Generators
+	}
+
+	return []Generator{
+		{
+			ClusterName:     config.ClusterName,
+			ClusterSelector: config.ClusterSelector,
+			BindingSelector: config.BindingSelector,
+		},
+	}
+}
+
+// bindingSelectors returns all the binding selectors of the generators, including the nested ones.
+// The selector of an awsTags generator is made of its tags, and
+// the one of a provision generator selects the AWSTargetGroups labeled with its name prefix.
+func bindingSelectors(gens []Generator) []string {
+	var sels []string
+
+	for _, g := range gens {
+		if len(g.Matrix) > 0 {
+			sels = append(sels, bindingSelectors(g.Matrix)...)
+		} else if len(g.Merge) > 0 {
+			sels = append(sels, bindingSelectors(g.Merge)...)
+		} else if g.AWSTags != nil {
+			sels = append(sels, g.AWSTags.selector())
+		} else if g.Provision != nil {
+			sels = append(sels, provisionerSelector(g.Provision))
+		} else {
+			sels = append(sels, g.BindingSelector)
+		}
+	}
+
+	return sels
+}
+
+type targetGroupGenerator struct {
+	ctx    context.Context
+	ns     string
+	labels map[string]string
+	// labelsFromCluster are the keys of the labels of cluster secrets to copy
+	labelsFromCluster []string
+	// client reads cluster secrets from the management cluster
+	client runtimeclient.Client
+	dryRun bool
+
+	newClusterClient func(corev1.Secret) (runtimeclient.Client, error)
+	// newELBV2 defaults to the client for the region with the default credentials. Overridden in tests.
+	newELBV2 func(region string) elbv2iface.ELBV2API
+	// applyBinding defaults to targetgroupbinding.Apply. Overridden in tests.
+	applyBinding func(targetgroupbinding.ApplyInput) (*v1beta1.TargetGroupBinding, error)
+
+	// awsTagsARNs are the ARNs of the target groups discovered by awsTags generators, keyed by the names of their AWSTargetGroups
+	awsTagsARNs map[string]string
+}
+
+// desiredAWSTargetGroups returns the union of the AWSTargetGroups generated by all the generators in the config.
+func desiredAWSTargetGroups(config SyncInput, managementClient runtimeclient.Client) ([]okrav1alpha1.AWSTargetGroup, error) {
+	g := &targetGroupGenerator{
+		ctx:               config.context(),
+		ns:                config.NS,
+		labels:            config.Labels,
+		labelsFromCluster: config.LabelsFromCluster,
+		client:            managementClient,
+		dryRun:            config.DryRun,
+		newClusterClient:  clclient.ClusterClient,
+	}
+
+	groups, err := g.generateUnion(config.generators())
+	if err != nil {
+		return nil, err
+	}
+
+	if config.SetName != "" {
+		for i := range groups {
+			if groups[i].Labels == nil {
+				groups[i].Labels = map[string]string{}
+			}
+
+			groups[i].Labels[okrav1alpha1.AWSTargetGroupLabelSet] = config.SetName
+		}
+	}
+
+	return groups, nil
+}
+
+func (g *targetGroupGenerator) generateUnion(gens []Generator) ([]okrav1alpha1.AWSTargetGroup, error) {
+	results, err := g.generateAll(gens)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []okrav1alpha1.AWSTargetGroup
+
+	for _, o := range generator.Union(results...) {
+		groups = append(groups, *o.(*okrav1alpha1.AWSTargetGroup))
+	}
+
+	return groups, nil
+}
+
+func (g *targetGroupGenerator) generateAll(gens []Generator) ([][]metav1.Object, error) {
+	var results [][]metav1.Object
+
+	for _, gen := range gens {
+		objs, err := g.generate(gen)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, objs)
+	}
+
+	return results, nil
+}
+
+func (g *targetGroupGenerator) generate(gen Generator) ([]metav1.Object, error) {
+	if len(gen.Matrix) > 0 {
+		results, err := g.generateAll(gen.Matrix)
+		if err != nil {
+			return nil, err
+		}
+
+		return generator.Matrix(results...), nil
+	}
+
+	if len(gen.Merge) > 0 {
+		results, err := g.generateAll(gen.Merge)
+		if err != nil {
+			return nil, err
+		}
+
+		return generator.Merge(results...), nil
+	}
+
+	if gen.AWSTags != nil {
+		return g.generateFromAWSTags(*gen.AWSTags)
+	}
+
+	if gen.Provision != nil {
+		return g.provision(gen.Provision)
+	}
+
+	var clusters []corev1.Secret
+
+	if gen.ClusterName != "" {
+		var secret corev1.Secret
+
+		if err := g.client.Get(g.ctx, types.NamespacedName{Namespace: g.ns, Name: gen.ClusterName}, &secret); err != nil {
+			return nil, xerrors.Errorf("getting cluster secret: %w", err)
+		}
+
+		clusters = append(clusters, secret)
+	} else if gen.ClusterSelector != "" {
+		secrets, err := g.listClusterSecrets(gen.ClusterSelector)
+		if err != nil {
+			return nil, err
+		}
+
+		clusters = secrets
+	}
+
+	sel, err := labels.Parse(gen.BindingSelector)
+	if err != nil {
+		return nil, xerrors.Errorf("parsing binding selector: %v", err)
+	}
+
+	var objects []metav1.Object
+
+	for _, cluster := range clusters {
+		clusterClient, err := g.newClusterClient(cluster)
+		if err != nil {
+			return nil, fmt.Errorf("creating cr client from cluster secret: %w", err)
+		}
+
+		var bindings v1beta1.TargetGroupBindingList
+
+		if err := clusterClient.List(g.ctx, &bindings, &runtimeclient.ListOptions{
+			LabelSelector: sel,
+		}); err != nil {
+			return nil, okraerror.New(fmt.Errorf("list targetgroupbidings: %w", err))
+		}
+
+		for _, b := range bindings.Items {
+			labels := map[string]string{}
+
+			for k, v := range b.Labels {
+				labels[k] = v
+			}
+
+			g.copyClusterLabels(labels, cluster)
+
+			for k, v := range g.labels {
+				labels[k] = v
+			}
+
+			labels[okrav1alpha1.AWSTargetGroupLabelBindingCluster] = gen.ClusterName
+			labels[okrav1alpha1.AWSTargetGroupLabelBindingNamespace] = b.Namespace
+			labels[okrav1alpha1.AWSTargetGroupLabelBindingName] = b.Name
+
+			objects = append(objects, &okrav1alpha1.AWSTargetGroup{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: okrav1alpha1.GroupVersion.String(),
+					Kind:       "AWSTargetGroup",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      fmt.Sprintf("%s-%s", b.Namespace, b.Name),
+					Namespace: g.ns,
+					Labels:    labels,
+				},
+				Spec: okrav1alpha1.AWSTargetGroupSpec{
+					ARN: b.Spec.TargetGroupARN,
+				},
+			})
+		}
+	}
+
+	return objects, nil
+}
+
+// copyClusterLabels copies the labels of the cluster secret whose keys are in labelsFromCluster.
+func (g *targetGroupGenerator) copyClusterLabels(labels map[string]string, cluster corev1.Secret) {
+	for _, k := range g.labelsFromCluster {
+		if v, ok := cluster.Labels[k]; ok {
+			labels[k] = v
+		}
+	}
+}
+
+// listClusterSecrets returns the cluster secrets in the namespace that match the selector.
+func (g *targetGroupGenerator) listClusterSecrets(selector string) ([]corev1.Secret, error) {
+	sel, err := labels.Parse(selector)
+	if err != nil {
+		return nil, xerrors.Errorf("parsing cluster selector: %v", err)
+	}
+
+	var secrets corev1.SecretList
+
+	if err := g.client.List(g.ctx, &secrets, &runtimeclient.ListOptions{
+		Namespace:     g.ns,
+		LabelSelector: sel,
+	}); err != nil {
+		return nil, xerrors.Errorf("listing cluster secrets: %w", err)
+	}
+
+	return secrets.Items, nil
+}
+
+// SelectsCluster returns true when any of the generators, including the nested ones, generates AWSTargetGroups
+// from the cluster of the cluster secret.
+func SelectsCluster(gens []Generator, secret corev1.Secret) bool {
+	for _, g := range gens {
+		if selectsCluster(g, secret) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func selectsCluster(g Generator, secret corev1.Secret) bool {
+	if len(g.Matrix) > 0 {
+		return SelectsCluster(g.Matrix, secret)
+	}
+
+	if len(g.Merge) > 0 {
+		return SelectsCluster(g.Merge, secret)
+	}
+
+	if g.Provision != nil {
+		return labels.SelectorFromSet(g.Provision.ClusterSelector.MatchLabels).Matches(labels.Set(secret.Labels))
+	}
+
+	if g.ClusterName != "" {
+		return g.ClusterName == secret.Name
+	}
+
+	// Like generate, an empty cluster selector selects no clusters
+	if g.ClusterSelector == "" {
+		return false
+	}
+
+	sel, err := labels.Parse(g.ClusterSelector)
+	if err != nil {
+		return false
+	}
+
+	return sel.Matches(labels.Set(secret.Labels))
+}
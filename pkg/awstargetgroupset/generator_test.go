@@ -0,0 +1,215 @@
+package awstargetgroupset
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mumoshu/okra/api/elbv2/v1beta1"
+	"github.com/mumoshu/okra/pkg/clclient"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	crfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newClusterSecret(name string, labels map[string]string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      name,
+			Labels:    labels,
+		},
+	}
+}
+
+func newBinding(name string, labels map[string]string) *v1beta1.TargetGroupBinding {
+	return &v1beta1.TargetGroupBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "web",
+			Name:      name,
+			Labels:    labels,
+		},
+		Spec: v1beta1.TargetGroupBindingSpec{
+			TargetGroupARN: "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/" + name + "/1",
+		},
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	managementClient := crfake.NewFakeClientWithScheme(clclient.Scheme(),
+		newClusterSecret("cluster1", map[string]string{"account": "a"}),
+		newClusterSecret("cluster2", map[string]string{"account": "b"}),
+	)
+
+	clusterClients := map[string]runtimeclient.Client{
+		"cluster1": crfake.NewFakeClientWithScheme(clclient.Scheme(),
+			newBinding("web1", map[string]string{"role": "web", "tier": "1"}),
+		),
+		"cluster2": crfake.NewFakeClientWithScheme(clclient.Scheme(),
+			newBinding("web2", map[string]string{"role": "web"}),
+			newBinding("api2", map[string]string{"role": "api"}),
+		),
+	}
+
+	g := &targetGroupGenerator{
+		ctx:    context.Background(),
+		ns:     "default",
+		labels: map[string]string{"set": "web"},
+		client: managementClient,
+		newClusterClient: func(s corev1.Secret) (runtimeclient.Client, error) {
+			return clusterClients[s.Name], nil
+		},
+	}
+
+	testcases := []struct {
+		name string
+		gens []Generator
+		want map[string]string
+	}{
+		{
+			name: "union",
+			gens: []Generator{
+				{ClusterSelector: "account=a", BindingSelector: "role=web"},
+				{ClusterSelector: "account=b", BindingSelector: "role=web"},
+				{ClusterSelector: "account in (a,b)", BindingSelector: "role=web"},
+			},
+			want: map[string]string{
+				"web-web1": "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/web1/1",
+				"web-web2": "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/web2/1",
+			},
+		},
+		{
+			name: "matrix",
+			gens: []Generator{
+				{
+					Matrix: []Generator{
+						{ClusterSelector: "account in (a,b)", BindingSelector: "role=web"},
+						{ClusterSelector: "account in (a,b)", BindingSelector: "tier=1"},
+					},
+				},
+			},
+			want: map[string]string{
+				"web-web1": "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/web1/1",
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			groups, err := g.generateUnion(tc.gens)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := map[string]string{}
+			for _, tg := range groups {
+				got[tg.Name] = tg.Spec.ARN
+
+				if tg.Labels["set"] != "web" {
+					t.Errorf("missing template labels on %s: %v", tg.Name, tg.Labels)
+				}
+			}
+
+			if d := cmp.Diff(tc.want, got); d != "" {
+				t.Errorf("unexpected target groups: %s", d)
+			}
+		})
+	}
+}
+
+func TestGenerateLabelsFromCluster(t *testing.T) {
+	managementClient := crfake.NewFakeClientWithScheme(clclient.Scheme(),
+		newClusterSecret("cluster1", map[string]string{"account": "a", "okra.mumo.co/version": "1.2.0", "set": "other"}),
+	)
+
+	g := &targetGroupGenerator{
+		ctx:               context.Background(),
+		ns:                "default",
+		labels:            map[string]string{"set": "web"},
+		labelsFromCluster: []string{"okra.mumo.co/version", "set", "missing"},
+		client:            managementClient,
+		newClusterClient: func(s corev1.Secret) (runtimeclient.Client, error) {
+			return crfake.NewFakeClientWithScheme(clclient.Scheme(),
+				newBinding("web1", map[string]string{"role": "web", "okra.mumo.co/version": "1.0.0"}),
+			), nil
+		},
+	}
+
+	groups, err := g.generateUnion([]Generator{{ClusterSelector: "account=a", BindingSelector: "role=web"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(groups) != 1 {
+		t.Fatalf("unexpected number of target groups: %d", len(groups))
+	}
+
+	want := map[string]string{
+		"role":                 "web",
+		"okra.mumo.co/version": "1.2.0",
+		"set":                  "web",
+	}
+
+	got := map[string]string{}
+	for k := range want {
+		got[k] = groups[0].Labels[k]
+	}
+
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("unexpected labels: -want +got\n%s", d)
+	}
+
+	if _, ok := groups[0].Labels["missing"]; ok {
+		t.Errorf("unexpected label copied from a missing cluster label: %v", groups[0].Labels)
+	}
+}
+
+func TestSelectsCluster(t *testing.T) {
+	secret := *newClusterSecret("cluster1", map[string]string{"account": "a", "role": "web"})
+
+	testcases := []struct {
+		name string
+		gens []Generator
+		want bool
+	}{
+		{
+			name: "cluster name",
+			gens: []Generator{{ClusterName: "cluster1"}},
+			want: true,
+		},
+		{
+			name: "other cluster name",
+			gens: []Generator{{ClusterName: "cluster2"}},
+			want: false,
+		},
+		{
+			name: "matching cluster selector",
+			gens: []Generator{{ClusterSelector: "account=a"}},
+			want: true,
+		},
+		{
+			name: "empty cluster selector",
+			gens: []Generator{{BindingSelector: "role=web"}},
+			want: false,
+		},
+		{
+			name: "nested in matrix",
+			gens: []Generator{{Matrix: []Generator{{ClusterSelector: "account=b"}, {ClusterSelector: "role=web"}}}},
+			want: true,
+		},
+		{
+			name: "awsTags",
+			gens: []Generator{{AWSTags: &AWSTagsGenerator{MatchTags: map[string]string{"role": "web"}}}},
+			want: false,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := SelectsCluster(tc.gens, secret); got != tc.want {
+				t.Errorf("unexpected result: want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
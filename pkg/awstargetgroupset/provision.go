@@ -0,0 +1,421 @@
+package awstargetgroupset
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
+	"github.com/mumoshu/okra/api/elbv2/v1beta1"
+	okrav1alpha1 "github.com/mumoshu/okra/api/v1alpha1"
+	"github.com/mumoshu/okra/pkg/targetgroupbinding"
+	"golang.org/x/xerrors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// maxTargetGroupNameLength is the maximum length of ELBv2 target group names
+	maxTargetGroupNameLength = 32
+
+	// tagKeyCluster is the tag key of provisioned target groups whose value is the name of the cluster
+	tagKeyCluster = "okra.mumo.co/cluster"
+
+	attributeKeyDeregistrationDelay = "deregistration_delay.timeout_seconds"
+)
+
+// provisionedTargetGroupName returns the name of the target group provisioned for the cluster.
+// Names longer than the limit are truncated and suffixed with a hash of the full name, so that they stay unique.
+func provisionedTargetGroupName(prefix, cluster string) string {
+	name := strings.ToLower(prefix + "-" + cluster)
+
+	if len(name) <= maxTargetGroupNameLength {
+		return name
+	}
+
+	hash := fmt.Sprintf("%x", sha256.Sum256([]byte(name)))[:8]
+
+	return strings.TrimRight(name[:maxTargetGroupNameLength-len(hash)-1], "-") + "-" + hash
+}
+
+// provisionerSelector returns the selector for the AWSTargetGroups provisioned by the generator.
+func provisionerSelector(p *okrav1alpha1.AWSTargetGroupProvisionGenerator) string {
+	return labels.SelectorFromSet(labels.Set{okrav1alpha1.AWSTargetGroupLabelProvisioner: p.TargetGroup.NamePrefix}).String()
+}
+
+// provision ensures a target group and a TargetGroupBinding exist for every selected cluster,
+// and returns the AWSTargetGroups for them.
+// In dry-run mode, nothing is created and the ARNs of the target groups yet to be created are empty.
+func (g *targetGroupGenerator) provision(p *okrav1alpha1.AWSTargetGroupProvisionGenerator) ([]metav1.Object, error) {
+	if p.TargetGroup.NamePrefix == "" {
+		return nil, xerrors.Errorf("provision generator requires targetGroup.namePrefix")
+	}
+
+	secrets, err := g.listClusterSecrets(labels.SelectorFromSet(p.ClusterSelector.MatchLabels).String())
+	if err != nil {
+		return nil, err
+	}
+
+	newClient := g.newELBV2
+	if newClient == nil {
+		newClient = newELBV2
+	}
+
+	svc := newClient(p.TargetGroup.Region)
+
+	var objects []metav1.Object
+
+	for _, cluster := range secrets {
+		obj, err := g.provisionFor(svc, p, cluster)
+		if err != nil {
+			return nil, xerrors.Errorf("provisioning target group for cluster %s: %w", cluster.Name, err)
+		}
+
+		objects = append(objects, obj)
+	}
+
+	return objects, nil
+}
+
+func (g *targetGroupGenerator) provisionFor(svc elbv2iface.ELBV2API, p *okrav1alpha1.AWSTargetGroupProvisionGenerator, cluster corev1.Secret) (metav1.Object, error) {
+	name := provisionedTargetGroupName(p.TargetGroup.NamePrefix, cluster.Name)
+
+	tgARN, err := ensureTargetGroup(svc, name, p.TargetGroup, cluster.Name, g.dryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	bindingName := p.Binding.Metadata.Name
+	if bindingName == "" {
+		bindingName = name
+	}
+
+	bindingNS := p.Binding.Metadata.Namespace
+
+	var targetType *v1beta1.TargetType
+	if t := p.TargetGroup.TargetType; t != "" {
+		tt := v1beta1.TargetType(t)
+		targetType = &tt
+	}
+
+	applyBinding := g.applyBinding
+	if applyBinding == nil {
+		applyBinding = targetgroupbinding.Apply
+	}
+
+	if tgARN != "" {
+		if _, err := applyBinding(targetgroupbinding.ApplyInput{
+			ClusterName:      cluster.Name,
+			ClusterNamespace: g.ns,
+			Name:             bindingName,
+			Namespace:        bindingNS,
+			TargetGroupARN:   tgARN,
+			Labels:           p.Binding.Metadata.Labels,
+			ServiceRef: &v1beta1.ServiceReference{
+				Name: p.Binding.ServiceName,
+				Port: p.Binding.ServicePort,
+			},
+			TargetType: targetType,
+			DryRun:     g.dryRun,
+			Context:    g.ctx,
+			Client:     g.client,
+		}); err != nil {
+			return nil, xerrors.Errorf("applying targetgroupbinding %s/%s: %w", bindingNS, bindingName, err)
+		}
+	}
+
+	labels := map[string]string{}
+
+	for k, v := range p.Binding.Metadata.Labels {
+		labels[k] = v
+	}
+
+	g.copyClusterLabels(labels, cluster)
+
+	for k, v := range g.labels {
+		labels[k] = v
+	}
+
+	labels[okrav1alpha1.AWSTargetGroupLabelBindingCluster] = cluster.Name
+	labels[okrav1alpha1.AWSTargetGroupLabelBindingNamespace] = bindingNS
+	labels[okrav1alpha1.AWSTargetGroupLabelBindingName] = bindingName
+	labels[okrav1alpha1.AWSTargetGroupLabelProvisioner] = p.TargetGroup.NamePrefix
+
+	return &okrav1alpha1.AWSTargetGroup{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: okrav1alpha1.GroupVersion.String(),
+			Kind:       "AWSTargetGroup",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: g.ns,
+			Labels:    labels,
+		},
+		Spec: okrav1alpha1.AWSTargetGroupSpec{
+			ARN: tgARN,
+		},
+	}, nil
+}
+
+// ensureTargetGroup returns the ARN of the target group, creating it from the template when it does not exist yet.
+// The health check and the attributes of an existing target group are updated to match the template.
+func ensureTargetGroup(svc elbv2iface.ELBV2API, name string, tmpl okrav1alpha1.AWSTargetGroupProvisionTemplate, cluster string, dryRun bool) (string, error) {
+	out, err := svc.DescribeTargetGroups(&elbv2.DescribeTargetGroupsInput{Names: aws.StringSlice([]string{name})})
+	if err == nil && len(out.TargetGroups) > 0 {
+		tg := out.TargetGroups[0]
+
+		if err := updateTargetGroup(svc, name, tg, tmpl, dryRun); err != nil {
+			return "", err
+		}
+
+		return aws.StringValue(tg.TargetGroupArn), nil
+	}
+
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); !ok || awsErr.Code() != elbv2.ErrCodeTargetGroupNotFoundException {
+			return "", xerrors.Errorf("describing target group %s: %w", name, err)
+		}
+	}
+
+	if dryRun {
+		fmt.Printf("Target group %q created successfully (Dry Run)\n", name)
+
+		return "", nil
+	}
+
+	created, err := svc.CreateTargetGroup(newCreateTargetGroupInput(name, tmpl, cluster))
+	if err != nil {
+		return "", xerrors.Errorf("creating target group %s: %w", name, err)
+	}
+
+	tgARN := aws.StringValue(created.TargetGroups[0].TargetGroupArn)
+
+	if d := tmpl.DeregistrationDelaySeconds; d != nil {
+		if err := modifyDeregistrationDelay(svc, name, tgARN, *d); err != nil {
+			return "", err
+		}
+	}
+
+	fmt.Printf("Target group %q created successfully\n", name)
+
+	return tgARN, nil
+}
+
+// updateTargetGroup updates the health check and the deregistration delay of the existing target group
+// when they differ from the template, so that changes to the template are rolled out to the provisioned target groups.
+func updateTargetGroup(svc elbv2iface.ELBV2API, name string, tg *elbv2.TargetGroup, tmpl okrav1alpha1.AWSTargetGroupProvisionTemplate, dryRun bool) error {
+	tgARN := aws.StringValue(tg.TargetGroupArn)
+
+	var changed bool
+
+	if in := newModifyTargetGroupInput(tg, tmpl.HealthCheck); in != nil {
+		changed = true
+
+		if !dryRun {
+			if _, err := svc.ModifyTargetGroup(in); err != nil {
+				return xerrors.Errorf("modifying health check of target group %s: %w", name, err)
+			}
+		}
+	}
+
+	if d := tmpl.DeregistrationDelaySeconds; d != nil {
+		out, err := svc.DescribeTargetGroupAttributes(&elbv2.DescribeTargetGroupAttributesInput{TargetGroupArn: aws.String(tgARN)})
+		if err != nil {
+			return xerrors.Errorf("describing attributes of target group %s: %w", name, err)
+		}
+
+		var current string
+
+		for _, a := range out.Attributes {
+			if aws.StringValue(a.Key) == attributeKeyDeregistrationDelay {
+				current = aws.StringValue(a.Value)
+			}
+		}
+
+		if current != strconv.FormatInt(*d, 10) {
+			changed = true
+
+			if !dryRun {
+				if err := modifyDeregistrationDelay(svc, name, tgARN, *d); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+
+	if dryRun {
+		fmt.Printf("Target group %q updated successfully (Dry Run)\n", name)
+	} else {
+		fmt.Printf("Target group %q updated successfully\n", name)
+	}
+
+	return nil
+}
+
+// newModifyTargetGroupInput returns the input to update the health check of the target group to the desired one,
+// or nil when the health check is up to date. Fields that are not set in the desired health check are left as is.
+func newModifyTargetGroupInput(tg *elbv2.TargetGroup, hc *okrav1alpha1.AWSTargetGroupHealthCheck) *elbv2.ModifyTargetGroupInput {
+	if hc == nil {
+		return nil
+	}
+
+	in := &elbv2.ModifyTargetGroupInput{TargetGroupArn: tg.TargetGroupArn}
+
+	var changed bool
+
+	if hc.Protocol != "" && hc.Protocol != aws.StringValue(tg.HealthCheckProtocol) {
+		in.HealthCheckProtocol = aws.String(hc.Protocol)
+		changed = true
+	}
+	if hc.Port != "" && hc.Port != aws.StringValue(tg.HealthCheckPort) {
+		in.HealthCheckPort = aws.String(hc.Port)
+		changed = true
+	}
+	if hc.Path != "" && hc.Path != aws.StringValue(tg.HealthCheckPath) {
+		in.HealthCheckPath = aws.String(hc.Path)
+		changed = true
+	}
+	if v := hc.IntervalSeconds; v != nil && *v != aws.Int64Value(tg.HealthCheckIntervalSeconds) {
+		in.HealthCheckIntervalSeconds = v
+		changed = true
+	}
+	if v := hc.TimeoutSeconds; v != nil && *v != aws.Int64Value(tg.HealthCheckTimeoutSeconds) {
+		in.HealthCheckTimeoutSeconds = v
+		changed = true
+	}
+	if v := hc.HealthyThresholdCount; v != nil && *v != aws.Int64Value(tg.HealthyThresholdCount) {
+		in.HealthyThresholdCount = v
+		changed = true
+	}
+	if v := hc.UnhealthyThresholdCount; v != nil && *v != aws.Int64Value(tg.UnhealthyThresholdCount) {
+		in.UnhealthyThresholdCount = v
+		changed = true
+	}
+	if hc.HTTPCode != "" && (tg.Matcher == nil || hc.HTTPCode != aws.StringValue(tg.Matcher.HttpCode)) {
+		in.Matcher = &elbv2.Matcher{HttpCode: aws.String(hc.HTTPCode)}
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+
+	return in
+}
+
+func modifyDeregistrationDelay(svc elbv2iface.ELBV2API, name, tgARN string, seconds int64) error {
+	if _, err := svc.ModifyTargetGroupAttributes(&elbv2.ModifyTargetGroupAttributesInput{
+		TargetGroupArn: aws.String(tgARN),
+		Attributes: []*elbv2.TargetGroupAttribute{
+			{Key: aws.String(attributeKeyDeregistrationDelay), Value: aws.String(strconv.FormatInt(seconds, 10))},
+		},
+	}); err != nil {
+		return xerrors.Errorf("modifying attributes of target group %s: %w", name, err)
+	}
+
+	return nil
+}
+
+func newCreateTargetGroupInput(name string, tmpl okrav1alpha1.AWSTargetGroupProvisionTemplate, cluster string) *elbv2.CreateTargetGroupInput {
+	in := &elbv2.CreateTargetGroupInput{
+		Name:     aws.String(name),
+		Protocol: aws.String(tmpl.Protocol),
+		Port:     aws.Int64(tmpl.Port),
+		VpcId:    aws.String(tmpl.VPCID),
+		Tags: []*elbv2.Tag{
+			{Key: aws.String(okrav1alpha1.AWSTargetGroupLabelProvisioner), Value: aws.String(tmpl.NamePrefix)},
+			{Key: aws.String(tagKeyCluster), Value: aws.String(cluster)},
+		},
+	}
+
+	if tmpl.TargetType != "" {
+		in.TargetType = aws.String(tmpl.TargetType)
+	}
+
+	if hc := tmpl.HealthCheck; hc != nil {
+		if hc.Protocol != "" {
+			in.HealthCheckProtocol = aws.String(hc.Protocol)
+		}
+		if hc.Port != "" {
+			in.HealthCheckPort = aws.String(hc.Port)
+		}
+		if hc.Path != "" {
+			in.HealthCheckPath = aws.String(hc.Path)
+		}
+		in.HealthCheckIntervalSeconds = hc.IntervalSeconds
+		in.HealthCheckTimeoutSeconds = hc.TimeoutSeconds
+		in.HealthyThresholdCount = hc.HealthyThresholdCount
+		in.UnhealthyThresholdCount = hc.UnhealthyThresholdCount
+		if hc.HTTPCode != "" {
+			in.Matcher = &elbv2.Matcher{HttpCode: aws.String(hc.HTTPCode)}
+		}
+	}
+
+	return in
+}
+
+// deprovision deletes the TargetGroupBinding and the target group provisioned for the AWSTargetGroup.
+// The binding is deleted first, so that AWS Load Balancer Controller deregisters the targets before the target group is gone.
+// When the cluster is unreachable and no longer selected by the generators, like when the cluster was deleted before its secret,
+// the binding is considered already deleted, as otherwise the deletion would be retried forever.
+func deprovision(ctx context.Context, c runtimeclient.Client, tg okrav1alpha1.AWSTargetGroup, ns string, clusterSelected bool, newClient func(region string) elbv2iface.ELBV2API, deleteBinding func(targetgroupbinding.DeleteInput) error) error {
+	cluster := tg.Labels[okrav1alpha1.AWSTargetGroupLabelBindingCluster]
+
+	if err := deleteBinding(targetgroupbinding.DeleteInput{
+		ClusterName:      cluster,
+		ClusterNamespace: ns,
+		Name:             tg.Labels[okrav1alpha1.AWSTargetGroupLabelBindingName],
+		Namespace:        tg.Labels[okrav1alpha1.AWSTargetGroupLabelBindingNamespace],
+		Context:          ctx,
+		Client:           c,
+	}); err != nil {
+		if clusterSelected || !clusterUnreachable(err) {
+			return xerrors.Errorf("deleting targetgroupbinding: %w", err)
+		}
+
+		fmt.Printf("Skipped deleting targetgroupbinding of target group %q as cluster %q is unreachable and no longer selected: %v\n", tg.Name, cluster, err)
+	}
+
+	if tg.Spec.ARN == "" {
+		return nil
+	}
+
+	parsed, err := arn.Parse(tg.Spec.ARN)
+	if err != nil {
+		return xerrors.Errorf("parsing target group arn: %w", err)
+	}
+
+	if _, err := newClient(parsed.Region).DeleteTargetGroup(&elbv2.DeleteTargetGroupInput{TargetGroupArn: aws.String(tg.Spec.ARN)}); err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == elbv2.ErrCodeTargetGroupNotFoundException {
+			return nil
+		}
+
+		return xerrors.Errorf("deleting target group: %w", err)
+	}
+
+	fmt.Printf("Target group %q deleted successfully\n", tg.Spec.ARN)
+
+	return nil
+}
+
+// clusterUnreachable returns true when the error did not come from the API server, like when the cluster is gone
+// or the cluster secret no longer has valid credentials.
+func clusterUnreachable(err error) bool {
+	var status kerrors.APIStatus
+
+	return !errors.As(err, &status)
+}
@@ -0,0 +1,314 @@
+package awstargetgroupset
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/mumoshu/okra/api/elbv2/v1beta1"
+	okrav1alpha1 "github.com/mumoshu/okra/api/v1alpha1"
+	"github.com/mumoshu/okra/pkg/clclient"
+	"github.com/mumoshu/okra/pkg/okraerror"
+	"github.com/mumoshu/okra/pkg/targetgroupbinding"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	crfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type fakeProvisioningELBV2 struct {
+	elbv2iface.ELBV2API
+
+	// arns are the ARNs of the existing target groups keyed by their names
+	arns     map[string]string
+	created  []*elbv2.CreateTargetGroupInput
+	modified []*elbv2.ModifyTargetGroupInput
+	// healthChecks are the health checks of the target groups keyed by their ARNs
+	healthChecks map[string]*elbv2.TargetGroup
+	attributes   map[string][]*elbv2.TargetGroupAttribute
+	deleted      []string
+}
+
+func (f *fakeProvisioningELBV2) DescribeTargetGroups(in *elbv2.DescribeTargetGroupsInput) (*elbv2.DescribeTargetGroupsOutput, error) {
+	name := aws.StringValue(in.Names[0])
+
+	arn, ok := f.arns[name]
+	if !ok {
+		return nil, awserr.New(elbv2.ErrCodeTargetGroupNotFoundException, "not found", nil)
+	}
+
+	tg := elbv2.TargetGroup{TargetGroupArn: aws.String(arn)}
+
+	if hc, ok := f.healthChecks[arn]; ok {
+		tg.HealthCheckPath = hc.HealthCheckPath
+		tg.Matcher = hc.Matcher
+	}
+
+	return &elbv2.DescribeTargetGroupsOutput{TargetGroups: []*elbv2.TargetGroup{&tg}}, nil
+}
+
+func (f *fakeProvisioningELBV2) CreateTargetGroup(in *elbv2.CreateTargetGroupInput) (*elbv2.CreateTargetGroupOutput, error) {
+	f.created = append(f.created, in)
+
+	arn := fakeTargetGroupARN(aws.StringValue(in.Name))
+	f.arns[aws.StringValue(in.Name)] = arn
+	f.healthChecks[arn] = &elbv2.TargetGroup{HealthCheckPath: in.HealthCheckPath, Matcher: in.Matcher}
+
+	return &elbv2.CreateTargetGroupOutput{TargetGroups: []*elbv2.TargetGroup{{TargetGroupArn: aws.String(arn)}}}, nil
+}
+
+func (f *fakeProvisioningELBV2) ModifyTargetGroup(in *elbv2.ModifyTargetGroupInput) (*elbv2.ModifyTargetGroupOutput, error) {
+	f.modified = append(f.modified, in)
+	f.healthChecks[aws.StringValue(in.TargetGroupArn)] = &elbv2.TargetGroup{HealthCheckPath: in.HealthCheckPath, Matcher: in.Matcher}
+
+	return &elbv2.ModifyTargetGroupOutput{}, nil
+}
+
+func (f *fakeProvisioningELBV2) DescribeTargetGroupAttributes(in *elbv2.DescribeTargetGroupAttributesInput) (*elbv2.DescribeTargetGroupAttributesOutput, error) {
+	return &elbv2.DescribeTargetGroupAttributesOutput{Attributes: f.attributes[aws.StringValue(in.TargetGroupArn)]}, nil
+}
+
+func (f *fakeProvisioningELBV2) ModifyTargetGroupAttributes(in *elbv2.ModifyTargetGroupAttributesInput) (*elbv2.ModifyTargetGroupAttributesOutput, error) {
+	f.attributes[aws.StringValue(in.TargetGroupArn)] = in.Attributes
+
+	return &elbv2.ModifyTargetGroupAttributesOutput{}, nil
+}
+
+func (f *fakeProvisioningELBV2) DeleteTargetGroup(in *elbv2.DeleteTargetGroupInput) (*elbv2.DeleteTargetGroupOutput, error) {
+	f.deleted = append(f.deleted, aws.StringValue(in.TargetGroupArn))
+
+	return &elbv2.DeleteTargetGroupOutput{}, nil
+}
+
+func TestProvisionedTargetGroupName(t *testing.T) {
+	if got := provisionedTargetGroupName("web", "Cluster1"); got != "web-cluster1" {
+		t.Errorf("unexpected name: %s", got)
+	}
+
+	long := provisionedTargetGroupName("web", "a-very-long-cluster-name-in-us-east-1")
+	if len(long) != maxTargetGroupNameLength {
+		t.Errorf("unexpected length of %s: %d", long, len(long))
+	}
+
+	if other := provisionedTargetGroupName("web", "a-very-long-cluster-name-in-us-east-2"); other == long {
+		t.Errorf("expected different names for different clusters, got %s", other)
+	}
+}
+
+func TestProvision(t *testing.T) {
+	managementClient := crfake.NewFakeClientWithScheme(clclient.Scheme(),
+		newClusterSecret("cluster1", map[string]string{"role": "web"}),
+		newClusterSecret("cluster2", map[string]string{"role": "web"}),
+		newClusterSecret("cluster3", map[string]string{"role": "api"}),
+	)
+
+	svc := &fakeProvisioningELBV2{
+		arns:         map[string]string{"web-cluster1": fakeTargetGroupARN("web-cluster1")},
+		attributes:   map[string][]*elbv2.TargetGroupAttribute{},
+		healthChecks: map[string]*elbv2.TargetGroup{},
+	}
+
+	var applied []targetgroupbinding.ApplyInput
+
+	g := &targetGroupGenerator{
+		ctx:    context.Background(),
+		ns:     "default",
+		labels: map[string]string{"set": "web"},
+		client: managementClient,
+		newELBV2: func(region string) elbv2iface.ELBV2API {
+			return svc
+		},
+		applyBinding: func(in targetgroupbinding.ApplyInput) (*v1beta1.TargetGroupBinding, error) {
+			applied = append(applied, in)
+
+			return &v1beta1.TargetGroupBinding{}, nil
+		},
+	}
+
+	p := &okrav1alpha1.AWSTargetGroupProvisionGenerator{
+		ClusterSelector: okrav1alpha1.TargetGroupClusterSelector{MatchLabels: map[string]string{"role": "web"}},
+		TargetGroup: okrav1alpha1.AWSTargetGroupProvisionTemplate{
+			NamePrefix:                 "web",
+			Protocol:                   "HTTP",
+			Port:                       80,
+			VPCID:                      "vpc-1",
+			TargetType:                 "ip",
+			HealthCheck:                &okrav1alpha1.AWSTargetGroupHealthCheck{Path: "/healthz", HTTPCode: "200"},
+			DeregistrationDelaySeconds: aws.Int64(30),
+		},
+		Binding: okrav1alpha1.TargetGroupBindingTemplate{
+			Metadata: okrav1alpha1.TargetGroupBindingTemplateMetadata{
+				Namespace: "web",
+				Labels:    map[string]string{"role": "web"},
+			},
+			ServiceName: "web",
+			ServicePort: intstr.FromInt(8080),
+		},
+	}
+
+	groups, err := g.generateUnion([]Generator{{Provision: p}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := map[string]string{}
+	for _, tg := range groups {
+		got[tg.Name] = tg.Spec.ARN
+
+		if tg.Labels[okrav1alpha1.AWSTargetGroupLabelProvisioner] != "web" || tg.Labels["set"] != "web" || tg.Labels["role"] != "web" {
+			t.Errorf("unexpected labels of %s: %v", tg.Name, tg.Labels)
+		}
+	}
+
+	want := map[string]string{
+		"web-cluster1": fakeTargetGroupARN("web-cluster1"),
+		"web-cluster2": fakeTargetGroupARN("web-cluster2"),
+	}
+
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("unexpected awstargetgroups: %s", d)
+	}
+
+	// Only the missing target group is created
+	if len(svc.created) != 1 || aws.StringValue(svc.created[0].Name) != "web-cluster2" {
+		t.Fatalf("unexpected target groups created: %v", svc.created)
+	}
+
+	if c := svc.created[0]; aws.StringValue(c.TargetType) != "ip" || aws.StringValue(c.HealthCheckPath) != "/healthz" || aws.StringValue(c.Matcher.HttpCode) != "200" {
+		t.Errorf("unexpected create target group input: %v", c)
+	}
+
+	for _, name := range []string{"web-cluster1", "web-cluster2"} {
+		if attrs := svc.attributes[fakeTargetGroupARN(name)]; len(attrs) != 1 || aws.StringValue(attrs[0].Value) != "30" {
+			t.Errorf("unexpected attributes of target group %s: %v", name, attrs)
+		}
+	}
+
+	// The health check of the existing target group is updated to match the template
+	if len(svc.modified) != 1 || aws.StringValue(svc.modified[0].TargetGroupArn) != fakeTargetGroupARN("web-cluster1") ||
+		aws.StringValue(svc.modified[0].HealthCheckPath) != "/healthz" || aws.StringValue(svc.modified[0].Matcher.HttpCode) != "200" {
+		t.Errorf("unexpected target groups modified: %v", svc.modified)
+	}
+
+	if len(applied) != 2 {
+		t.Fatalf("expected 2 bindings applied, got %d", len(applied))
+	}
+
+	// The cluster secrets are read with the injected client, rather than a new client per binding
+	if applied[0].Client != managementClient {
+		t.Errorf("expected bindings to be applied with the management client")
+	}
+
+	if b := applied[1]; b.ClusterName != "cluster2" || b.Name != "web-cluster2" || b.Namespace != "web" || b.ServiceRef.Name != "web" || string(*b.TargetType) != "ip" {
+		t.Errorf("unexpected binding: %+v", b)
+	}
+
+	// Nothing is modified once the target groups are up to date
+	svc.modified = nil
+
+	if _, err := g.generateUnion([]Generator{{Provision: p}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(svc.modified) != 0 {
+		t.Errorf("unexpected target groups modified: %v", svc.modified)
+	}
+
+	var deletedBinding targetgroupbinding.DeleteInput
+
+	err = deprovision(context.Background(), managementClient, groups[1], "default", false, func(region string) elbv2iface.ELBV2API {
+		if region != "us-east-1" {
+			t.Errorf("unexpected region: %s", region)
+		}
+
+		return svc
+	}, func(in targetgroupbinding.DeleteInput) error {
+		if in.Client != managementClient {
+			t.Errorf("expected the binding to be deleted with the management client")
+		}
+
+		deletedBinding = in
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if d := cmp.Diff(targetgroupbinding.DeleteInput{ClusterName: "cluster2", ClusterNamespace: "default", Name: "web-cluster2", Namespace: "web"}, deletedBinding, cmpopts.IgnoreFields(targetgroupbinding.DeleteInput{}, "Context", "Client")); d != "" {
+		t.Errorf("unexpected binding deleted: %s", d)
+	}
+
+	if d := cmp.Diff([]string{fakeTargetGroupARN("web-cluster2")}, svc.deleted); d != "" {
+		t.Errorf("unexpected target groups deleted: %s", d)
+	}
+
+	if sels := bindingSelectors([]Generator{{Provision: p}}); !cmp.Equal(sels, []string{okrav1alpha1.AWSTargetGroupLabelProvisioner + "=web"}) {
+		t.Errorf("unexpected selectors: %v", sels)
+	}
+}
+
+func TestDeprovisionUnreachableCluster(t *testing.T) {
+	tg := okrav1alpha1.AWSTargetGroup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "web-cluster1",
+			Labels: map[string]string{
+				okrav1alpha1.AWSTargetGroupLabelBindingCluster:   "cluster1",
+				okrav1alpha1.AWSTargetGroupLabelBindingNamespace: "web",
+				okrav1alpha1.AWSTargetGroupLabelBindingName:      "web-cluster1",
+			},
+		},
+		Spec: okrav1alpha1.AWSTargetGroupSpec{ARN: fakeTargetGroupARN("web-cluster1")},
+	}
+
+	unreachable := fmt.Errorf("dial tcp: lookup cluster1.example.com: no such host")
+	forbidden := okraerror.New(fmt.Errorf("deleting binding: %w", kerrors.NewForbidden(schema.GroupResource{Resource: "targetgroupbindings"}, "web-cluster1", nil)))
+
+	testcases := []struct {
+		name            string
+		clusterSelected bool
+		bindingErr      error
+		deleted         bool
+	}{
+		{name: "unreachable and no longer selected", bindingErr: unreachable, deleted: true},
+		{name: "unreachable and still selected", clusterSelected: true, bindingErr: unreachable},
+		{name: "reachable and no longer selected", bindingErr: forbidden},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &fakeProvisioningELBV2{}
+
+			err := deprovision(context.Background(), nil, tg, "default", tc.clusterSelected, func(region string) elbv2iface.ELBV2API {
+				return svc
+			}, func(in targetgroupbinding.DeleteInput) error {
+				return tc.bindingErr
+			})
+
+			if tc.deleted {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				if len(svc.deleted) != 1 {
+					t.Errorf("expected the target group to be deleted, got %v", svc.deleted)
+				}
+			} else {
+				if err == nil {
+					t.Fatal("expected error, got none")
+				}
+
+				if len(svc.deleted) != 0 {
+					t.Errorf("unexpected target groups deleted: %v", svc.deleted)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package awstargetgroupset
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mumoshu/okra/api/v1alpha1"
+	"github.com/mumoshu/okra/pkg/cell"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ActionCreate    = "Create"
+	ActionUpdate    = "Update"
+	ActionUnchanged = "Unchanged"
+	ActionDelete    = "Delete"
+)
+
+// SyncResult is the result of syncing an AWSTargetGroup.
+type SyncResult struct {
+	Cluster string
+	Name    string
+	Action  string
+	// Err is the error occurred while doing Action for the AWSTargetGroup, if any
+	Err error
+}
+
+// resultsError returns an error that summarizes the errors in the results, or nil if there are none.
+func resultsError(results []SyncResult) error {
+	var msgs []string
+
+	for _, r := range results {
+		if r.Err != nil {
+			msgs = append(msgs, fmt.Sprintf("%s %s: %v", strings.ToLower(r.Action), r.Name, r.Err))
+		}
+	}
+
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%d awstargetgroup(s) failed to sync: %s", len(msgs), strings.Join(msgs, "; "))
+}
+
+// deletionBlocked returns true when all the errors in the results are due to deletions blocked by live traffic.
+func deletionBlocked(results []SyncResult) bool {
+	var blocked bool
+
+	for _, r := range results {
+		if r.Err == nil {
+			continue
+		}
+
+		var trafficErr *cell.TrafficError
+		if !errors.As(r.Err, &trafficErr) {
+			return false
+		}
+
+		blocked = true
+	}
+
+	return blocked
+}
+
+// UpdateStatus updates the status of the AWSTargetGroupSet with the results and the error of the sync.
+// The target groups are updated only when the target groups were computed, so that the status keeps
+// the last known target groups when the discovery failed.
+func UpdateStatus(status *v1alpha1.AWSTargetGroupSetStatus, results []SyncResult, err error, now metav1.Time) {
+	status.LastSyncTime = now
+
+	if results != nil || err == nil {
+		var groups v1alpha1.AWSTargetGroupSetStatusTargetGroups
+
+		for _, r := range results {
+			if r.Err != nil {
+				groups.Errors = append(groups.Errors, v1alpha1.MemberError{Name: r.Name, Message: r.Err.Error()})
+			}
+
+			switch r.Action {
+			case ActionCreate:
+				groups.Names = append(groups.Names, r.Name)
+				if r.Err == nil {
+					groups.Created = append(groups.Created, r.Name)
+				}
+			case ActionUpdate:
+				groups.Names = append(groups.Names, r.Name)
+				if r.Err == nil {
+					groups.Updated = append(groups.Updated, r.Name)
+				}
+			case ActionUnchanged:
+				groups.Names = append(groups.Names, r.Name)
+				groups.Unchanged = append(groups.Unchanged, r.Name)
+			case ActionDelete:
+				if r.Err == nil {
+					groups.Deleted = append(groups.Deleted, r.Name)
+				}
+			}
+		}
+
+		sort.Strings(groups.Names)
+
+		status.TargetGroups = groups
+	}
+
+	cond := metav1.Condition{
+		Type:               v1alpha1.ConditionTypeReady,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Synced",
+		Message:            fmt.Sprintf("%d target group(s) synced", len(status.TargetGroups.Names)),
+		LastTransitionTime: now,
+	}
+
+	if err != nil {
+		status.Phase = "Failed"
+		status.Reason = "SyncFailed"
+		status.Message = err.Error()
+
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = "SyncFailed"
+		cond.Message = err.Error()
+
+		if deletionBlocked(results) {
+			status.Reason = "DeletionBlocked"
+			cond.Reason = "DeletionBlocked"
+		}
+	} else {
+		status.Phase = "Synced"
+		status.Reason = ""
+		status.Message = ""
+	}
+
+	meta.SetStatusCondition(&status.Conditions, cond)
+}
@@ -0,0 +1,63 @@
+package awstargetgroupset
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mumoshu/okra/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestUpdateStatus(t *testing.T) {
+	var status v1alpha1.AWSTargetGroupSetStatus
+
+	results := []SyncResult{
+		{Cluster: "web1", Name: "web1-tg", Action: ActionCreate},
+		{Cluster: "web2", Name: "web2-tg", Action: ActionUpdate},
+		{Cluster: "web3", Name: "web3-tg", Action: ActionUnchanged},
+		{Cluster: "web4", Name: "web4-tg", Action: ActionCreate, Err: errors.New("forbidden")},
+		{Cluster: "web0", Name: "web0-tg", Action: ActionDelete},
+	}
+
+	UpdateStatus(&status, results, resultsError(results), metav1.Now())
+
+	want := v1alpha1.AWSTargetGroupSetStatusTargetGroups{
+		Names:     []string{"web1-tg", "web2-tg", "web3-tg", "web4-tg"},
+		Created:   []string{"web1-tg"},
+		Updated:   []string{"web2-tg"},
+		Unchanged: []string{"web3-tg"},
+		Deleted:   []string{"web0-tg"},
+		Errors:    []v1alpha1.MemberError{{Name: "web4-tg", Message: "forbidden"}},
+	}
+
+	if d := cmp.Diff(want, status.TargetGroups); d != "" {
+		t.Errorf("unexpected target groups: %s", d)
+	}
+
+	if status.Phase != "Failed" {
+		t.Errorf("unexpected phase: %s", status.Phase)
+	}
+
+	if !meta.IsStatusConditionFalse(status.Conditions, v1alpha1.ConditionTypeReady) {
+		t.Errorf("expected Ready to be False: %+v", status.Conditions)
+	}
+
+	// A failed discovery keeps the last known target groups
+	UpdateStatus(&status, nil, errors.New("listing cluster secrets"), metav1.Now())
+
+	if d := cmp.Diff(want, status.TargetGroups); d != "" {
+		t.Errorf("unexpected target groups after failed discovery: %s", d)
+	}
+
+	UpdateStatus(&status, results[:3], nil, metav1.Now())
+
+	if status.Phase != "Synced" || len(status.TargetGroups.Errors) != 0 {
+		t.Errorf("unexpected status: %+v", status)
+	}
+
+	if !meta.IsStatusConditionTrue(status.Conditions, v1alpha1.ConditionTypeReady) {
+		t.Errorf("expected Ready to be True: %+v", status.Conditions)
+	}
+}
@@ -0,0 +1,133 @@
+package cell
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	okrav1alpha1 "github.com/mumoshu/okra/api/v1alpha1"
+	"github.com/mumoshu/okra/pkg/sync"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const LabelKeyALBConfigHash = "alb-config-hash"
+
+// albRouter routes traffic to AWS target groups via an AWSApplicationLoadBalancerConfig
+// that is named after the cell.
+type albRouter struct {
+	cell          okrav1alpha1.Cell
+	runtimeClient client.Client
+	scheme        *runtime.Scheme
+
+	albConfig okrav1alpha1.AWSApplicationLoadBalancerConfig
+}
+
+func (r *albRouter) versionLabelKeys() []string {
+	return defaultVersionLabelKeys(r.cell.Spec.Ingress.AWSApplicationLoadBalancer.TargetGroupSelector.VersionLabels)
+}
+
+func (r *albRouter) listBackends(ctx context.Context) ([]backend, error) {
+	var tgs okrav1alpha1.AWSTargetGroupList
+
+	if err := r.runtimeClient.List(ctx, &tgs, client.InNamespace(r.cell.Namespace), client.MatchingLabels(r.cell.Spec.Ingress.AWSApplicationLoadBalancer.TargetGroupSelector.MatchLabels)); err != nil {
+		return nil, fmt.Errorf("listing awstargetgroups: %w", err)
+	}
+
+	var backends []backend
+
+	for _, tg := range tgs.Items {
+		backends = append(backends, backend{
+			Name:     tg.Name,
+			ARN:      tg.Spec.ARN,
+			Labels:   tg.Labels,
+			Deleting: !tg.DeletionTimestamp.IsZero(),
+		})
+	}
+
+	return backends, nil
+}
+
+func (r *albRouter) desiredSpec() okrav1alpha1.AWSApplicationLoadBalancerConfigSpec {
+	var spec okrav1alpha1.AWSApplicationLoadBalancerConfigSpec
+
+	spec.Listener = r.cell.Spec.Ingress.AWSApplicationLoadBalancer.Listener
+	spec.ListenerARN = r.cell.Spec.Ingress.AWSApplicationLoadBalancer.ListenerARN
+
+	return spec
+}
+
+func (r *albRouter) get(ctx context.Context) ([]okrav1alpha1.ForwardTargetGroup, bool, error) {
+	key := types.NamespacedName{Namespace: r.cell.Namespace, Name: r.cell.Name}
+
+	if err := r.runtimeClient.Get(ctx, key, &r.albConfig); err != nil {
+		log.Printf("%v\n", err)
+		if !kerrors.IsNotFound(err) {
+			return nil, false, err
+		}
+
+		return nil, false, nil
+	}
+
+	return r.albConfig.Spec.Listener.Rule.Forward.TargetGroups, true, nil
+}
+
+func (r *albRouter) create(ctx context.Context, tgs []okrav1alpha1.ForwardTargetGroup) error {
+	albConfig := okrav1alpha1.AWSApplicationLoadBalancerConfig{}
+	albConfig.Namespace = r.cell.Namespace
+	albConfig.Name = r.cell.Name
+	albConfig.Spec = r.desiredSpec()
+	ctrl.SetControllerReference(&r.cell, &albConfig, r.scheme)
+
+	albConfig.Spec.Listener.Rule.Forward.TargetGroups = append(albConfig.Spec.Listener.Rule.Forward.TargetGroups, tgs...)
+
+	metav1.SetMetaDataAnnotation(&albConfig.ObjectMeta, LabelKeyALBConfigHash, sync.ComputeHash(r.desiredSpec()))
+
+	if err := r.runtimeClient.Create(ctx, &albConfig); err != nil {
+		return fmt.Errorf("creating albconfig: %w", err)
+	}
+
+	return nil
+}
+
+func (r *albRouter) updateConfig(ctx context.Context) (bool, error) {
+	desiredSpec := r.desiredSpec()
+	desiredHash := sync.ComputeHash(desiredSpec)
+
+	if r.albConfig.Annotations[LabelKeyALBConfigHash] == desiredHash {
+		return false, nil
+	}
+
+	metav1.SetMetaDataAnnotation(&r.albConfig.ObjectMeta, LabelKeyALBConfigHash, desiredHash)
+
+	r.albConfig.Spec = desiredSpec
+
+	if err := r.runtimeClient.Update(ctx, &r.albConfig); err != nil {
+		return false, fmt.Errorf("updating albconfig: %w", err)
+	}
+
+	return true, nil
+}
+
+func (r *albRouter) update(ctx context.Context, tgs []okrav1alpha1.ForwardTargetGroup) (bool, error) {
+	r.albConfig.Spec.Listener.Rule.Forward.TargetGroups = tgs
+
+	currentHash := r.albConfig.Annotations[LabelKeyTemplateHash]
+	desiredHash := sync.ComputeHash(r.albConfig.Spec)
+
+	if currentHash == desiredHash {
+		return false, nil
+	}
+
+	metav1.SetMetaDataAnnotation(&r.albConfig.ObjectMeta, LabelKeyTemplateHash, desiredHash)
+
+	if err := r.runtimeClient.Update(ctx, &r.albConfig); err != nil {
+		return false, fmt.Errorf("updating albconfig: %w", err)
+	}
+
+	return true, nil
+}
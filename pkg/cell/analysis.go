@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"strconv"
 	"time"
 
 	rolloutsv1alpha1 "github.com/mumoshu/okra/api/rollouts/v1alpha1"
@@ -421,3 +422,133 @@ func (s cellComponentReconciler) reconcilePause(ctx context.Context, componentID
 
 	return ComponentPassed, nil
 }
+
+// deleteOutdatedComponents deletes the analysis runs, experiments, and pauses created for the previous states of the cell.
+func (s cellComponentReconciler) deleteOutdatedComponents(ctx context.Context) error {
+	objects := []runtime.Object{
+		&rolloutsv1alpha1.AnalysisRun{},
+		&rolloutsv1alpha1.Experiment{},
+		&okrav1alpha1.Pause{},
+	}
+
+	outdatedComponents, err := s.outdatedComponentSelectorLabels()
+	if err != nil {
+		return err
+	}
+
+	for _, o := range objects {
+		// Seems like we need to explicitly specify the namespace with client.InNamespace.
+		// Otherwise it results in `Error: the server could not find the requested resource (delete analysisruns.argoproj.io)`
+		if err := s.runtimeClient.DeleteAllOf(ctx, o, client.InNamespace(s.cell.Namespace), &client.DeleteAllOfOptions{
+			ListOptions: client.ListOptions{
+				LabelSelector: outdatedComponents,
+			},
+		}); err != nil {
+			log.Printf("Failed deleting %Ts: %v", o, err)
+			return err
+		}
+
+		log.Printf("Deleted all %Ts with %s, if any", o, outdatedComponents)
+	}
+
+	return nil
+}
+
+// canaryStepsResult is the progress of canary steps.
+type canaryStepsResult struct {
+	// weight is the sum of the setWeight of the steps reached so far
+	weight    int
+	passedAll bool
+	failed    bool
+}
+
+// runCanarySteps runs the steps in order along with the background analysis, until a step is in progress or failed.
+// The IDs of the components created for the steps are prefixed with componentPrefix,
+// so that components of different step lists never conflict.
+// A setWeight step that would raise the weight above maxWeight is held in progress, so that the following steps never run
+// while a gate like the target health requirement holds the rollout.
+func (s cellComponentReconciler) runCanarySteps(ctx context.Context, router trafficRouter, componentPrefix string, steps []rolloutsv1alpha1.CanaryStep, analysis *rolloutsv1alpha1.RolloutAnalysisBackground, maxWeight int) (*canaryStepsResult, error) {
+	var result canaryStepsResult
+
+	for stepIndex, step := range steps {
+		stepIndexStr := componentPrefix + strconv.Itoa(stepIndex)
+
+		if a := analysis; a != nil {
+			// A background analysis works very much like
+			// Argo Rollouts Background Analysis as documented at
+			// https://argoproj.github.io/argo-rollouts/features/analysis/#background-analysis
+			// except that okra's works against clusters(backing e.g. AWSTargetGroups) instead of replicasets.
+
+			start := int32(0)
+			if a.StartingStep != nil {
+				start = *a.StartingStep
+			}
+
+			if int32(stepIndex) >= start {
+				r, err := s.reconcileAnalysisRun(ctx, componentPrefix+"bg", &a.RolloutAnalysis, nil)
+				if err != nil {
+					return nil, err
+				} else if r == ComponentFailed {
+					result.failed = true
+					return &result, nil
+				}
+
+				// We accept both StepInProgress and StepPassed
+				// as a background analysis makes the cell degraded
+				// only if it failed.
+			}
+		}
+
+		var (
+			r   componentReconcilationResult
+			err error
+		)
+
+		if step.Analysis != nil {
+			r, err = s.reconcileAnalysisRun(ctx, stepIndexStr, step.Analysis, func(at rolloutsv1alpha1.AnalysisTemplate) error {
+				for _, m := range at.Spec.Metrics {
+					if d, _ := m.Interval.Duration(); d > 0 && m.Count == nil {
+						return fmt.Errorf("analysistemplate %s: metric %s: step analysis should have non-zero count", at.Name, m.Name)
+					}
+				}
+				return nil
+			})
+		} else if step.Experiment != nil {
+			r, err = s.reconcileExperiment(ctx, stepIndexStr, step.Experiment)
+		} else if step.SetWeight != nil {
+			if result.weight+int(*step.SetWeight) > maxWeight {
+				return &result, nil
+			}
+
+			result.weight += int(*step.SetWeight)
+
+			r = ComponentPassed
+
+			if msr, ok := router.(minStepDurationRouter); ok && msr.minStepDuration() > 0 {
+				// Wait until the new weight is observed by clients before proceeding to the next step
+				r, err = s.reconcilePause(ctx, stepIndexStr+"-min", &rolloutsv1alpha1.RolloutPause{
+					Duration: rolloutsv1alpha1.DurationFromInt(int(msr.minStepDuration().Seconds())),
+				})
+			}
+		} else if step.Pause != nil {
+			r, err = s.reconcilePause(ctx, stepIndexStr, step.Pause)
+		} else {
+			return nil, fmt.Errorf("steps[%d]: only setWeight, analysis, and pause step are supported. got %v", stepIndex, step)
+		}
+
+		if err != nil {
+			return nil, err
+		} else if r == ComponentInProgress {
+			return &result, nil
+		} else if r == ComponentFailed {
+			result.failed = true
+			return &result, nil
+		}
+
+		if stepIndex+1 == len(steps) {
+			result.passedAll = true
+		}
+	}
+
+	return &result, nil
+}
@@ -5,12 +5,11 @@ import (
 	"fmt"
 	"log"
 	"sort"
-	"strconv"
+	"strings"
 
 	"github.com/blang/semver"
 	rolloutsv1alpha1 "github.com/mumoshu/okra/api/rollouts/v1alpha1"
 	okrav1alpha1 "github.com/mumoshu/okra/api/v1alpha1"
-	"github.com/mumoshu/okra/pkg/awstargetgroupset"
 	"github.com/mumoshu/okra/pkg/clclient"
 	"github.com/mumoshu/okra/pkg/sync"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
@@ -18,7 +17,6 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
-	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -48,10 +46,15 @@ type SyncInput struct {
 
 	Scheme *runtime.Scheme
 	Client client.Client
+	// Context defaults to context.Background()
+	Context context.Context
 }
 
 func Sync(config SyncInput) error {
-	ctx := context.TODO()
+	ctx := config.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
 
 	runtimeClient, scheme, err := clclient.Init(config.Client, config.Scheme)
 	if err != nil {
@@ -62,6 +65,11 @@ func Sync(config SyncInput) error {
 
 	if config.Cell != nil {
 		cell = *config.Cell
+
+		// Return the status updated by the sync, so that the caller can persist it
+		defer func() {
+			config.Cell.Status = cell.Status
+		}()
 	} else {
 		if err := runtimeClient.Get(ctx, types.NamespacedName{Namespace: config.NS, Name: config.Name}, &cell); err != nil {
 			return err
@@ -70,51 +78,29 @@ func Sync(config SyncInput) error {
 
 	key := types.NamespacedName{Namespace: cell.Namespace, Name: cell.Name}
 
-	albListenerARN := cell.Spec.Ingress.AWSApplicationLoadBalancer.ListenerARN
-	tgSelectorMatchLabels := cell.Spec.Ingress.AWSApplicationLoadBalancer.TargetGroupSelector
-	tgSelector := labels.SelectorFromSet(tgSelectorMatchLabels.MatchLabels)
-
-	var albConfig okrav1alpha1.AWSApplicationLoadBalancerConfig
-	var albConfigExists bool
-	var desiredALBConfigSpec okrav1alpha1.AWSApplicationLoadBalancerConfigSpec
-
-	if err := runtimeClient.Get(ctx, key, &albConfig); err != nil {
-		log.Printf("%v\n", err)
-		if !kerrors.IsNotFound(err) {
-			return err
-		}
+	router, err := newTrafficRouter(cell, runtimeClient, scheme)
+	if err != nil {
+		return err
+	}
 
-		albConfig.Namespace = cell.Namespace
-		albConfig.Name = cell.Name
-		albConfig.Spec.ListenerARN = albListenerARN
-		albConfig.Spec.Listener = cell.Spec.Ingress.AWSApplicationLoadBalancer.Listener
-		ctrl.SetControllerReference(&cell, &albConfig, scheme)
-	} else {
-		albConfigExists = true
+	// The target health is recorded only on AWSTargetGroups
+	if canary := cell.Spec.UpdateStrategy.Canary; canary != nil && canary.TargetHealth != nil && TargetGroupSelector(cell) == nil {
+		return fmt.Errorf("cell %s/%s: updateStrategy.canary.targetHealth is supported only by ingresses routing traffic to AWSTargetGroups", cell.Namespace, cell.Name)
 	}
 
-	const LabelKeyALBConfigHash = "alb-config-hash"
+	labelKeys := router.versionLabelKeys()
 
-	desiredALBConfigSpec.Listener = cell.Spec.Ingress.AWSApplicationLoadBalancer.Listener
-	desiredALBConfigSpec.ListenerARN = albListenerARN
-	desiredALBConfigSpecHash := sync.ComputeHash(desiredALBConfigSpec)
-	currentALBConfigSpecHash := albConfig.Annotations[LabelKeyALBConfigHash]
+	v := cell.Spec.Version
 
-	labelKeys := cell.Spec.Ingress.AWSApplicationLoadBalancer.TargetGroupSelector.VersionLabels
-	if len(labelKeys) == 0 {
-		labelKeys = []string{okrav1alpha1.DefaultVersionLabelKey}
+	allKnownTGs, err := router.listBackends(ctx)
+	if err != nil {
+		return err
 	}
 
-	v := cell.Spec.Version
-
-	desiredVer, desiredTGs, err := awstargetgroupset.ListLatestAWSTargetGroups(awstargetgroupset.ListLatestAWSTargetGroupsInput{
-		ListAWSTargetGroupsInput: awstargetgroupset.ListAWSTargetGroupsInput{
-			NS:       cell.Namespace,
-			Selector: tgSelector.String(),
-		},
-		SemverLabelKeys: labelKeys,
-		Version:         v,
-	})
+	// Backends being deleted are excluded from the desired ones, while their versions are still known.
+	// This way a cluster that is being replaced by a new one of the same version, and held by the TrafficGuard
+	// until it is drained, does not count towards the replicas, so that the replacement can start while it still serves traffic.
+	desiredVer, desiredTGs, err := latestBackends(selectableBackends(allKnownTGs), labelKeys, v)
 	if err != nil {
 		return err
 	}
@@ -123,26 +109,9 @@ func Sync(config SyncInput) error {
 		log.Printf("Using cell.Spec.Version(%s) instead of latest version", v)
 	}
 
-	allKnownTGs, err := awstargetgroupset.ListAWSTargetGroups(awstargetgroupset.ListAWSTargetGroupsInput{
-		NS:       cell.Namespace,
-		Selector: tgSelector.String(),
-	})
-	if err != nil {
-		return err
-	}
-
 	allKnownTGsNameToVer := make(map[string]string)
 	for _, tg := range allKnownTGs {
-		var ver string
-		for _, l := range labelKeys {
-			v, ok := tg.Labels[l]
-			if ok {
-				ver = v
-				break
-			}
-		}
-
-		if ver != "" {
+		if ver := backendVersion(tg, labelKeys); ver != "" {
 			allKnownTGsNameToVer[tg.Name] = ver
 		}
 	}
@@ -155,9 +124,14 @@ func Sync(config SyncInput) error {
 		threshold = int(*cell.Spec.Replicas)
 	}
 
-	log.Printf("key=%s, albConfigExists=%v, tgSelector=%s, len(latestTGs)=%d\n", key, albConfigExists, tgSelector.String(), len(desiredTGs))
+	currentTGs, routerExists, err := router.get(ctx)
+	if err != nil {
+		return err
+	}
 
-	if numLatestTGs != threshold {
+	log.Printf("key=%s, ingressType=%s, routerExists=%v, len(latestTGs)=%d\n", key, cell.Spec.Ingress.Type, routerExists, len(desiredTGs))
+
+	if desiredVer == nil || numLatestTGs != threshold {
 		return nil
 	}
 
@@ -171,16 +145,19 @@ func Sync(config SyncInput) error {
 	// Do distribute weights evently so that the total becomes 100
 	desiredTGsByName := distributeWeights(100, desiredTGs)
 
-	if !albConfigExists {
-		// ALB isn't initialized yet so we are creating the ALBConfig resource for the first time
+	if !routerExists {
+		// The loadbalancer isn't initialized yet so we are creating its config for the first time
+		var tgs []okrav1alpha1.ForwardTargetGroup
 		for _, tg := range desiredTGsByName {
-			albConfig.Spec.Listener.Rule.Forward.TargetGroups = append(albConfig.Spec.Listener.Rule.Forward.TargetGroups, tg)
+			tgs = append(tgs, tg)
 		}
 
-		metav1.SetMetaDataAnnotation(&albConfig.ObjectMeta, LabelKeyALBConfigHash, desiredALBConfigSpecHash)
+		sort.Slice(tgs, func(i, j int) bool {
+			return tgs[i].Name < tgs[j].Name
+		})
 
-		if err := runtimeClient.Create(ctx, &albConfig); err != nil {
-			return fmt.Errorf("creating albconfig: %w", err)
+		if err := router.create(ctx, tgs); err != nil {
+			return err
 		}
 
 		updated := make(map[string]int)
@@ -193,15 +170,9 @@ func Sync(config SyncInput) error {
 		return nil
 	}
 
-	if currentALBConfigSpecHash != desiredALBConfigSpecHash {
-		metav1.SetMetaDataAnnotation(&albConfig.ObjectMeta, LabelKeyALBConfigHash, desiredALBConfigSpecHash)
-
-		albConfig.Spec = desiredALBConfigSpec
-
-		if err := runtimeClient.Update(ctx, &albConfig); err != nil {
-			return fmt.Errorf("updating albconfig: %w", err)
-		}
-
+	if updated, err := router.updateConfig(ctx); err != nil {
+		return err
+	} else if updated {
 		return nil
 	}
 
@@ -221,8 +192,8 @@ func Sync(config SyncInput) error {
 		currentStableTGsByVer  = map[string][]okrav1alpha1.ForwardTargetGroup{}
 	)
 
-	for _, tg := range albConfig.Spec.Listener.Rule.Forward.TargetGroups {
-		// Divide target groups already registered to our ALB config
+	for _, tg := range currentTGs {
+		// Divide target groups already registered to our loadbalancer config
 		// between canary and stable versions, which are necessary for a gradual update.
 
 		tg := tg
@@ -263,6 +234,10 @@ func Sync(config SyncInput) error {
 		currentStableTGs = currentStableTGsByVer[currentStableTGsMaxVer.String()]
 	}
 
+	if currentCanaryTGsWeight == 100 {
+		cell.Status.StableVersion = desiredVer.String()
+	}
+
 	// Do update immediately without analysis or step update when
 	// it seems to have been triggered by an additional cluster that might have been
 	// added to deal with more load.
@@ -272,17 +247,21 @@ func Sync(config SyncInput) error {
 		// Immediately update LB config as quickly as possible when
 		// either a rollback or a scale in/out is requested.
 
-		albConfig.Spec.Listener.Rule.Forward.TargetGroups = nil
+		var tgs []okrav1alpha1.ForwardTargetGroup
 		for _, tg := range desiredTGsByName {
-			albConfig.Spec.Listener.Rule.Forward.TargetGroups = append(albConfig.Spec.Listener.Rule.Forward.TargetGroups, tg)
+			tgs = append(tgs, tg)
 		}
 		for _, tg := range currentStableTGs {
 			tg.Weight = 0
-			albConfig.Spec.Listener.Rule.Forward.TargetGroups = append(albConfig.Spec.Listener.Rule.Forward.TargetGroups, tg)
+			tgs = append(tgs, tg)
 		}
 
-		if err := runtimeClient.Update(ctx, &albConfig); err != nil {
-			return fmt.Errorf("updating albconfig: %w", err)
+		sort.Slice(tgs, func(i, j int) bool {
+			return tgs[i].Name < tgs[j].Name
+		})
+
+		if _, err := router.update(ctx, tgs); err != nil {
+			return err
 		}
 
 		updated := make(map[string]int)
@@ -345,123 +324,105 @@ func Sync(config SyncInput) error {
 
 	desiredStableTGsWeight := 100
 
-	if len(canarySteps) > 0 && !passedAllCanarySteps {
-		var analysisRunList rolloutsv1alpha1.AnalysisRunList
+	ccr := cellComponentReconciler{
+		cell:          cell,
+		runtimeClient: runtimeClient,
+		scheme:        scheme,
+		cellStateHash: cellStateHash,
+	}
 
-		if err := runtimeClient.List(ctx, &analysisRunList, &client.ListOptions{
-			LabelSelector: everythingOwnedByThisCell,
-		}); err != nil {
+	if canary != nil && canary.Replacement != nil {
+		if r := detectReplacement(currentTGs, desiredTGsByName, allKnownTGsNameToVer, cell.Status.StableVersion, *desiredVer); r != nil {
+			// A new cluster of the current stable version is replacing an existing one.
+			// Move the share of the replaced cluster gradually by the replacement steps, instead of the canary steps.
+			return r.sync(ctx, ccr, router, *canary.Replacement)
+		}
+	}
+
+	failureCause := "AnalysisRun failed"
+
+	// holdCanaryWeight prevents the canary weight from increasing in this sync.
+	// Gates are evaluated before the steps so that a held setWeight step stays in progress,
+	// instead of letting the following steps like analyses and pauses run against the held weight.
+	var holdCanaryWeight bool
+
+	if canary != nil && canary.TargetHealth != nil && currentCanaryTGsWeight < 100 {
+		violations, err := targetHealthViolations(ctx, runtimeClient, cell.Namespace, desiredTGs, *canary.TargetHealth)
+		if err != nil {
 			return err
 		}
 
-		ccr := cellComponentReconciler{
-			cell:          cell,
-			runtimeClient: runtimeClient,
-			scheme:        scheme,
-			cellStateHash: cellStateHash,
+		var collapsed []string
+
+		for _, v := range violations {
+			log.Printf("Canary target group %s does not meet the target health requirement: %s", v.Name, v.Message)
+
+			if v.Observed {
+				collapsed = append(collapsed, v.Name+": "+v.Message)
+			}
 		}
 
-		objects := []runtime.Object{
-			&rolloutsv1alpha1.AnalysisRun{},
-			&rolloutsv1alpha1.Experiment{},
-			&okrav1alpha1.Pause{},
+		if currentCanaryTGsWeight > 0 && len(collapsed) > 0 {
+			// The canary target groups already receive traffic, so we abort the rollout
+			// instead of keeping the traffic on the unhealthy targets
+			anyStepFailed = true
+			failureCause = "Target health collapsed: " + strings.Join(collapsed, "; ")
+		} else if len(violations) > 0 {
+			log.Printf("Holding the stable weight at %d until all the canary target groups become healthy", currentStableTGsWeight)
+
+			holdCanaryWeight = true
 		}
+	}
 
-		outdatedComponents, err := ccr.outdatedComponentSelectorLabels()
+	if canary != nil && canary.RequireReachableClusters && !anyStepFailed && currentCanaryTGsWeight < 100 {
+		unreachable, err := unreachableClusters(ctx, runtimeClient, cell.Namespace, desiredTGs)
 		if err != nil {
 			return err
 		}
 
-		for _, o := range objects {
-			// Seems like we need to explicitly specify the namespace with client.InNamespace.
-			// Otherwise it results in `Error: the server could not find the requested resource (delete analysisruns.argoproj.io)`
-			if err := runtimeClient.DeleteAllOf(ctx, o, client.InNamespace(cell.Namespace), &client.DeleteAllOfOptions{
-				ListOptions: client.ListOptions{
-					LabelSelector: outdatedComponents,
-				},
-			}); err != nil {
-				log.Printf("Failed deleting %Ts: %v", o, err)
-				return err
-			}
+		if len(unreachable) > 0 {
+			log.Printf("Holding the stable weight at %d as clusters %v backing the canary target groups are unreachable", currentStableTGsWeight, unreachable)
 
-			log.Printf("Deleted all %Ts with %s, if any", o, outdatedComponents)
+			holdCanaryWeight = true
 		}
+	}
 
-	STEPS:
-		for stepIndex, step := range canarySteps {
-			stepIndexStr := strconv.Itoa(stepIndex)
-
-			if a := canary.Analysis; a != nil {
-				// A background analysis works very much like
-				// Argo Rollouts Background Analysis as documented at
-				// https://argoproj.github.io/argo-rollouts/features/analysis/#background-analysis
-				// except that okra's works against clusters(backing e.g. AWSTargetGroups) instead of replicasets.
-
-				start := int32(0)
-				if a.StartingStep != nil {
-					start = *a.StartingStep
-				}
-
-				if int32(stepIndex) >= start {
-					r, err := ccr.reconcileAnalysisRun(ctx, "bg", &a.RolloutAnalysis, nil)
-					if err != nil {
-						return err
-					} else if r == ComponentFailed {
-						anyStepFailed = true
-						break STEPS
-					}
-
-					// We accept both StepInProgress and StepPassed
-					// as a background analysis makes the cell degraded
-					// only if it failed.
-				}
-			}
+	maxCanaryWeight := 100
+	if holdCanaryWeight {
+		maxCanaryWeight = 100 - currentStableTGsWeight
+	}
 
-			var (
-				r   componentReconcilationResult
-				err error
-			)
-
-			if step.Analysis != nil {
-				r, err = ccr.reconcileAnalysisRun(ctx, stepIndexStr, step.Analysis, func(at rolloutsv1alpha1.AnalysisTemplate) error {
-					for _, m := range at.Spec.Metrics {
-						if d, _ := m.Interval.Duration(); d > 0 && m.Count == nil {
-							return fmt.Errorf("analysistemplate %s: metric %s: step analysis should have non-zero count", at.Name, m.Name)
-						}
-					}
-					return nil
-				})
-			} else if step.Experiment != nil {
-				r, err = ccr.reconcileExperiment(ctx, stepIndexStr, step.Experiment)
-			} else if step.SetWeight != nil {
-				desiredStableTGsWeight -= int(*step.SetWeight)
-
-				r = ComponentPassed
-			} else if step.Pause != nil {
-				r, err = ccr.reconcilePause(ctx, stepIndexStr, step.Pause)
-			} else {
-				return fmt.Errorf("steps[%d]: only setWeight, analysis, and pause step are supported. got %v", stepIndex, step)
-			}
+	if len(canarySteps) > 0 && !passedAllCanarySteps && !anyStepFailed {
+		var analysisRunList rolloutsv1alpha1.AnalysisRunList
 
-			if err != nil {
-				return err
-			} else if r == ComponentInProgress {
-				break STEPS
-			} else if r == ComponentFailed {
-				anyStepFailed = true
-				break STEPS
-			}
+		if err := runtimeClient.List(ctx, &analysisRunList, &client.ListOptions{
+			LabelSelector: everythingOwnedByThisCell,
+		}); err != nil {
+			return err
+		}
 
-			if stepIndex+1 == len(canarySteps) {
-				passedAllCanarySteps = true
-			}
+		if err := ccr.deleteOutdatedComponents(ctx); err != nil {
+			return err
 		}
+
+		r, err := ccr.runCanarySteps(ctx, router, "", canarySteps, canary.Analysis, maxCanaryWeight)
+		if err != nil {
+			return err
+		}
+
+		desiredStableTGsWeight -= r.weight
+		passedAllCanarySteps = r.passedAll
+		anyStepFailed = r.failed
 	}
 
 	if passedAllCanarySteps || len(canarySteps) == 0 {
 		desiredStableTGsWeight = 0
 	}
 
+	if holdCanaryWeight && desiredStableTGsWeight < currentStableTGsWeight {
+		desiredStableTGsWeight = currentStableTGsWeight
+	}
+
 	if anyStepFailed {
 		desiredStableTGsWeight = 100
 	}
@@ -491,23 +452,17 @@ func Sync(config SyncInput) error {
 		return updatedTGs[i].Name < updatedTGs[j].Name
 	})
 
-	albConfig.Spec.Listener.Rule.Forward.TargetGroups = updatedTGs
-
-	currentHash := albConfig.Annotations[LabelKeyTemplateHash]
-	desiredHash := sync.ComputeHash(albConfig.Spec)
+	updated, err := router.update(ctx, updatedTGs)
+	if err != nil {
+		return err
+	}
 
-	if currentHash != desiredHash {
+	if updated {
 		if currentStableTGsWeight != desiredStableTGsWeight {
-			log.Printf("Changing stable weight(%v): %d -> %d\n", currentStableTGsMaxVer, currentStableTGsWeight, desiredStableTGsWeight)
+			log.Printf("Changed stable weight(%v): %d -> %d\n", currentStableTGsMaxVer, currentStableTGsWeight, desiredStableTGsWeight)
 		}
 		if currentCanaryTGsWeight != desiredCanaryTGsWeight {
-			log.Printf("Changing canary(%s) weight: %d -> %d\n", desiredVer, currentCanaryTGsWeight, desiredCanaryTGsWeight)
-		}
-
-		metav1.SetMetaDataAnnotation(&albConfig.ObjectMeta, LabelKeyTemplateHash, desiredHash)
-
-		if err := runtimeClient.Update(ctx, &albConfig); err != nil {
-			return err
+			log.Printf("Changed canary(%s) weight: %d -> %d\n", desiredVer, currentCanaryTGsWeight, desiredCanaryTGsWeight)
 		}
 
 		updated := make(map[string]int)
@@ -517,7 +472,7 @@ func Sync(config SyncInput) error {
 
 		log.Printf("Updated target groups and weights to: %v\n", updated)
 	} else {
-		log.Printf("No change detected on AWSApplicationLoadBalancerConfig and target group weights. Skipped updating.")
+		log.Printf("No change detected on loadbalancer config and target group weights. Skipped updating.")
 	}
 
 	if anyStepFailed {
@@ -525,7 +480,7 @@ func Sync(config SyncInput) error {
 
 		item := okrav1alpha1.VersionBlocklistItem{
 			Version: desiredVer.String(),
-			Cause:   "AnalysisRun failed",
+			Cause:   failureCause,
 		}
 
 		if err := runtimeClient.Get(ctx, types.NamespacedName{Namespace: cell.Namespace, Name: cell.Name}, &bl); err != nil {
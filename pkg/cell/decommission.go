@@ -0,0 +1,484 @@
+package cell
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/eks/eksiface"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
+	"github.com/blang/semver"
+	okrav1alpha1 "github.com/mumoshu/okra/api/v1alpha1"
+	"github.com/mumoshu/okra/pkg/awsclicompat"
+	"github.com/mumoshu/okra/pkg/clclient"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DefaultDecommissionBakeTime is the default duration a target group stays at zero weight before it is considered drained
+	DefaultDecommissionBakeTime = 10 * time.Minute
+
+	// defaultDeregistrationDelay is the deregistration delay of ELBv2 target groups when not configured
+	defaultDeregistrationDelay = 300 * time.Second
+
+	// webhookTimeout is the timeout of a request to the decommission webhook
+	webhookTimeout = 10 * time.Second
+
+	decommissionReasonZeroWeight = "ZeroWeight"
+	decommissionReasonDrained    = "Drained"
+)
+
+// eksServerPattern matches the endpoint of an EKS cluster, capturing the region
+var eksServerPattern = regexp.MustCompile(`\.([a-z0-9-]+)\.eks\.amazonaws\.com(:\d+)?/?$`)
+
+type DecommissionInput struct {
+	Cell    *okrav1alpha1.Cell
+	Client  client.Client
+	Context context.Context
+	Now     metav1.Time
+
+	// Recorder, when set, receives an event on the cell per decommissionable cluster
+	Recorder record.EventRecorder
+
+	// newELBV2 defaults to the client for the region with the default credentials. Overridden in tests.
+	newELBV2 func(region string) elbv2iface.ELBV2API
+	// notify defaults to notifyDecommissionable. Overridden in tests.
+	notify func(context.Context, DecommissionNotification, *okrav1alpha1.CellDecommission, corev1.Secret) error
+}
+
+type DecommissionResult struct {
+	// RequeueAfter is the duration until the next target group is drained, or zero when no target group is being drained
+	RequeueAfter time.Duration
+	// Clusters are the clusters that became decommissionable
+	Clusters []string
+}
+
+// DecommissionNotification is the body of the request sent to the webhook once a cluster becomes decommissionable.
+type DecommissionNotification struct {
+	Namespace             string      `json:"namespace"`
+	Cell                  string      `json:"cell"`
+	Cluster               string      `json:"cluster"`
+	TargetGroups          []string    `json:"targetGroups"`
+	DecommissionableSince metav1.Time `json:"decommissionableSince"`
+}
+
+// Decommission tracks the target groups of versions older than the desired version of the cell,
+// that are at zero weight in the loadbalancer config of the cell after the canary release finished.
+// A target group becomes decommissionable after staying at zero weight for the bake time plus its deregistration delay,
+// and is then removed from the loadbalancer config.
+// Once all the target groups of a cluster are decommissionable, the cluster secret is labeled so, and
+// the provisioning pipeline is notified via an event, EKS cluster tags, and the webhook, so that it can tear down the cluster.
+func Decommission(input DecommissionInput) (*DecommissionResult, error) {
+	cell := input.Cell
+	d := cell.Spec.Decommission
+	result := &DecommissionResult{}
+
+	ingress := cell.Spec.Ingress
+
+	if d == nil || (ingress.Type != "" && ingress.Type != okrav1alpha1.CellIngressTypeAWSApplicationLoadBalancer) || ingress.AWSApplicationLoadBalancer == nil {
+		return result, nil
+	}
+
+	ctx := input.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	c := input.Client
+
+	var albConfig okrav1alpha1.AWSApplicationLoadBalancerConfig
+
+	if err := c.Get(ctx, types.NamespacedName{Namespace: cell.Namespace, Name: cell.Name}, &albConfig); err != nil {
+		if kerrors.IsNotFound(err) {
+			return result, nil
+		}
+
+		return nil, err
+	}
+
+	// Only the target groups older than the desired version of the cell are decommissionable.
+	// This is the same version as Sync computes for cell.Status.DesiredVersion.
+	router := &albRouter{cell: *cell, runtimeClient: c}
+
+	labelKeys := router.versionLabelKeys()
+
+	backends, err := router.listBackends(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	desiredVer, desiredTGs, err := latestBackends(backends, labelKeys, cell.Spec.Version)
+	if err != nil {
+		return nil, err
+	} else if desiredVer == nil {
+		return result, nil
+	}
+
+	desired := map[string]struct{}{}
+	for _, b := range desiredTGs {
+		desired[b.Name] = struct{}{}
+	}
+
+	// Wait until the canary release finishes, so that the previous version may still receive traffic on a failure
+	var desiredWeight int
+	for _, ftg := range albConfig.Spec.Listener.Rule.Forward.TargetGroups {
+		if _, ok := desired[ftg.Name]; ok {
+			desiredWeight += ftg.Weight
+		}
+	}
+
+	canaryCompleted := desiredWeight == 100
+
+	bakeTime := DefaultDecommissionBakeTime
+	if d.BakeTime != nil {
+		bakeTime = d.BakeTime.Duration
+	}
+
+	newELBV2 := input.newELBV2
+	if newELBV2 == nil {
+		newELBV2 = func(region string) elbv2iface.ELBV2API {
+			return elbv2.New(awsclicompat.NewSession(region, ""))
+		}
+	}
+
+	drained := map[string]okrav1alpha1.AWSTargetGroup{}
+
+	for _, ftg := range albConfig.Spec.Listener.Rule.Forward.TargetGroups {
+		var tg okrav1alpha1.AWSTargetGroup
+
+		if err := c.Get(ctx, types.NamespacedName{Namespace: cell.Namespace, Name: ftg.Name}, &tg); err != nil {
+			if kerrors.IsNotFound(err) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		cond := meta.FindStatusCondition(tg.Status.Conditions, okrav1alpha1.ConditionTypeDecommissionable)
+
+		_, isDesired := desired[tg.Name]
+
+		if ftg.Weight > 0 || isDesired || !olderThan(tg, labelKeys, *desiredVer) {
+			// The target group received traffic again, or became desired again, like after a rollback.
+			// Canary target groups at zero weight, like when a step holds them, are never decommissionable.
+			if cond != nil {
+				meta.RemoveStatusCondition(&tg.Status.Conditions, okrav1alpha1.ConditionTypeDecommissionable)
+
+				if err := c.Status().Update(ctx, &tg); err != nil {
+					return nil, fmt.Errorf("updating awstargetgroup %s status: %w", tg.Name, err)
+				}
+			}
+
+			continue
+		}
+
+		if !canaryCompleted {
+			continue
+		}
+
+		if cond != nil && cond.Status == metav1.ConditionTrue {
+			drained[tg.Name] = tg
+
+			continue
+		}
+
+		if cond == nil {
+			meta.SetStatusCondition(&tg.Status.Conditions, metav1.Condition{
+				Type:               okrav1alpha1.ConditionTypeDecommissionable,
+				Status:             metav1.ConditionFalse,
+				Reason:             decommissionReasonZeroWeight,
+				Message:            fmt.Sprintf("Zero weight in AWSApplicationLoadBalancerConfig %s/%s", albConfig.Namespace, albConfig.Name),
+				LastTransitionTime: input.Now,
+			})
+
+			if err := c.Status().Update(ctx, &tg); err != nil {
+				return nil, fmt.Errorf("updating awstargetgroup %s status: %w", tg.Name, err)
+			}
+
+			cond = meta.FindStatusCondition(tg.Status.Conditions, okrav1alpha1.ConditionTypeDecommissionable)
+		}
+
+		delay, err := deregistrationDelay(newELBV2, tg.Spec.ARN)
+		if err != nil {
+			return nil, err
+		}
+
+		if remaining := cond.LastTransitionTime.Add(bakeTime + delay).Sub(input.Now.Time); remaining > 0 {
+			if result.RequeueAfter == 0 || remaining < result.RequeueAfter {
+				result.RequeueAfter = remaining
+			}
+
+			continue
+		}
+
+		meta.SetStatusCondition(&tg.Status.Conditions, metav1.Condition{
+			Type:               okrav1alpha1.ConditionTypeDecommissionable,
+			Status:             metav1.ConditionTrue,
+			Reason:             decommissionReasonDrained,
+			Message:            fmt.Sprintf("Zero weight for %s including the deregistration delay of %s", bakeTime+delay, delay),
+			LastTransitionTime: input.Now,
+		})
+
+		if err := c.Status().Update(ctx, &tg); err != nil {
+			return nil, fmt.Errorf("updating awstargetgroup %s status: %w", tg.Name, err)
+		}
+
+		log.Printf("Target group %s is drained and decommissionable", tg.Name)
+
+		drained[tg.Name] = tg
+	}
+
+	if len(drained) == 0 {
+		return result, nil
+	}
+
+	clusters := map[string]struct{}{}
+
+	for _, tg := range drained {
+		if cluster := tg.Labels[okrav1alpha1.AWSTargetGroupLabelBindingCluster]; cluster != "" {
+			clusters[cluster] = struct{}{}
+		}
+	}
+
+	notify := input.notify
+	if notify == nil {
+		notify = notifyDecommissionable
+	}
+
+	for cluster := range clusters {
+		n, ok, err := decommissionableCluster(ctx, c, cell.Namespace, cluster)
+		if err != nil {
+			return nil, err
+		} else if !ok {
+			continue
+		}
+
+		var secret corev1.Secret
+
+		if err := c.Get(ctx, types.NamespacedName{Namespace: cell.Namespace, Name: cluster}, &secret); err != nil {
+			if kerrors.IsNotFound(err) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		if secret.Labels[okrav1alpha1.LabelDecommissionable] == "true" {
+			continue
+		}
+
+		n.Cell = cell.Name
+		n.DecommissionableSince = input.Now
+
+		// Notify before labeling the secret, so that the notification is retried on failure
+		if err := notify(ctx, *n, d, secret); err != nil {
+			return nil, fmt.Errorf("notifying decommissionable cluster %s: %w", cluster, err)
+		}
+
+		if secret.Labels == nil {
+			secret.Labels = map[string]string{}
+		}
+
+		secret.Labels[okrav1alpha1.LabelDecommissionable] = "true"
+
+		metav1.SetMetaDataAnnotation(&secret.ObjectMeta, okrav1alpha1.AnnotationDecommissionableSince, input.Now.UTC().Format(time.RFC3339))
+
+		if err := c.Update(ctx, &secret); err != nil {
+			return nil, fmt.Errorf("labeling cluster secret %s: %w", cluster, err)
+		}
+
+		if input.Recorder != nil {
+			input.Recorder.Eventf(cell, corev1.EventTypeNormal, "ClusterDecommissionable", "Cluster '%s' is decommissionable as all of its target groups are drained", cluster)
+		}
+
+		log.Printf("Cluster %s is decommissionable", cluster)
+
+		result.Clusters = append(result.Clusters, cluster)
+	}
+
+	// Remove the drained target groups only after all the notifications succeeded.
+	// Otherwise the next run would no longer find the drained target groups in the loadbalancer config,
+	// and would never retry the failed notifications.
+	var remaining []okrav1alpha1.ForwardTargetGroup
+
+	for _, ftg := range albConfig.Spec.Listener.Rule.Forward.TargetGroups {
+		if _, ok := drained[ftg.Name]; !ok {
+			remaining = append(remaining, ftg)
+		}
+	}
+
+	albConfig.Spec.Listener.Rule.Forward.TargetGroups = remaining
+
+	if err := c.Update(ctx, &albConfig); err != nil {
+		return nil, fmt.Errorf("removing drained target groups from awsapplicationloadbalancerconfig: %w", err)
+	}
+
+	sort.Strings(result.Clusters)
+
+	return result, nil
+}
+
+// olderThan returns true when the version of the target group is older than the version.
+// A target group without a valid version is never older.
+func olderThan(tg okrav1alpha1.AWSTargetGroup, labelKeys []string, ver semver.Version) bool {
+	v, err := semver.Parse(backendVersion(backend{Name: tg.Name, Labels: tg.Labels}, labelKeys))
+	if err != nil {
+		return false
+	}
+
+	return v.LT(ver)
+}
+
+// decommissionableCluster returns true when all the AWSTargetGroups of the cluster are decommissionable,
+// along with the notification for the cluster.
+func decommissionableCluster(ctx context.Context, c client.Client, ns, cluster string) (*DecommissionNotification, bool, error) {
+	var tgs okrav1alpha1.AWSTargetGroupList
+
+	if err := c.List(ctx, &tgs, client.InNamespace(ns), client.MatchingLabels{okrav1alpha1.AWSTargetGroupLabelBindingCluster: cluster}); err != nil {
+		return nil, false, err
+	}
+
+	n := &DecommissionNotification{Namespace: ns, Cluster: cluster}
+
+	for _, tg := range tgs.Items {
+		if !meta.IsStatusConditionTrue(tg.Status.Conditions, okrav1alpha1.ConditionTypeDecommissionable) {
+			return nil, false, nil
+		}
+
+		n.TargetGroups = append(n.TargetGroups, tg.Name)
+	}
+
+	sort.Strings(n.TargetGroups)
+
+	return n, true, nil
+}
+
+// deregistrationDelay returns the deregistration delay of the target group.
+func deregistrationDelay(newELBV2 func(region string) elbv2iface.ELBV2API, tgARN string) (time.Duration, error) {
+	if tgARN == "" {
+		return defaultDeregistrationDelay, nil
+	}
+
+	parsed, err := arn.Parse(tgARN)
+	if err != nil {
+		return 0, fmt.Errorf("parsing target group arn: %w", err)
+	}
+
+	out, err := newELBV2(parsed.Region).DescribeTargetGroupAttributes(&elbv2.DescribeTargetGroupAttributesInput{
+		TargetGroupArn: aws.String(tgARN),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("describing attributes of target group %s: %w", tgARN, err)
+	}
+
+	for _, a := range out.Attributes {
+		if aws.StringValue(a.Key) != "deregistration_delay.timeout_seconds" {
+			continue
+		}
+
+		secs, err := strconv.Atoi(aws.StringValue(a.Value))
+		if err != nil {
+			return 0, fmt.Errorf("parsing deregistration delay of target group %s: %w", tgARN, err)
+		}
+
+		return time.Duration(secs) * time.Second, nil
+	}
+
+	return defaultDeregistrationDelay, nil
+}
+
+// notifyDecommissionable tags the EKS cluster and calls the webhook, if configured.
+func notifyDecommissionable(ctx context.Context, n DecommissionNotification, d *okrav1alpha1.CellDecommission, secret corev1.Secret) error {
+	if len(d.EKSClusterTags) > 0 {
+		if err := tagEKSCluster(secret, d.EKSClusterTags, func(region string) eksiface.EKSAPI {
+			return eks.New(awsclicompat.NewSession(region, ""))
+		}); err != nil {
+			return err
+		}
+	}
+
+	if d.WebhookURL != "" {
+		if err := callWebhook(ctx, d.WebhookURL, n); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// tagEKSCluster tags the EKS cluster of the cluster secret. Clusters other than EKS ones are skipped.
+func tagEKSCluster(secret corev1.Secret, tags map[string]string, newEKS func(region string) eksiface.EKSAPI) error {
+	cluster, err := clclient.SecretToCluster(&secret)
+	if err != nil {
+		return fmt.Errorf("secret to cluster: %w", err)
+	}
+
+	m := eksServerPattern.FindStringSubmatch(cluster.Server)
+	if cluster.Config.AWSAuthConfig == nil || m == nil {
+		log.Printf("Skipped tagging cluster %s that is not an EKS cluster", secret.Name)
+
+		return nil
+	}
+
+	svc := newEKS(m[1])
+
+	out, err := svc.DescribeCluster(&eks.DescribeClusterInput{Name: aws.String(cluster.Config.AWSAuthConfig.ClusterName)})
+	if err != nil {
+		return fmt.Errorf("describing eks cluster: %w", err)
+	}
+
+	if _, err := svc.TagResource(&eks.TagResourceInput{
+		ResourceArn: out.Cluster.Arn,
+		Tags:        aws.StringMap(tags),
+	}); err != nil {
+		return fmt.Errorf("tagging eks cluster: %w", err)
+	}
+
+	return nil
+}
+
+func callWebhook(ctx context.Context, url string, n DecommissionNotification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("calling webhook: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("calling webhook: unexpected status %s", res.Status)
+	}
+
+	return nil
+}
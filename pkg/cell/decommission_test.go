@@ -0,0 +1,335 @@
+package cell
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
+	"github.com/google/go-cmp/cmp"
+	okrav1alpha1 "github.com/mumoshu/okra/api/v1alpha1"
+	"github.com/mumoshu/okra/pkg/clclient"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	crfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type fakeDeregistrationDelayELBV2 struct {
+	elbv2iface.ELBV2API
+}
+
+func (f *fakeDeregistrationDelayELBV2) DescribeTargetGroupAttributes(in *elbv2.DescribeTargetGroupAttributesInput) (*elbv2.DescribeTargetGroupAttributesOutput, error) {
+	return &elbv2.DescribeTargetGroupAttributesOutput{
+		Attributes: []*elbv2.TargetGroupAttribute{
+			{Key: aws.String("deregistration_delay.timeout_seconds"), Value: aws.String("30")},
+		},
+	}, nil
+}
+
+func newDecommissionTargetGroup(name, cluster, version string) *okrav1alpha1.AWSTargetGroup {
+	return &okrav1alpha1.AWSTargetGroup{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      name,
+			Labels: map[string]string{
+				okrav1alpha1.AWSTargetGroupLabelBindingCluster: cluster,
+				okrav1alpha1.DefaultVersionLabelKey:            version,
+				"role":                                         "web",
+			},
+		},
+		Spec: okrav1alpha1.AWSTargetGroupSpec{
+			ARN: "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/" + name + "/1",
+		},
+	}
+}
+
+func newDecommissionCell() *okrav1alpha1.Cell {
+	return &okrav1alpha1.Cell{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+		Spec: okrav1alpha1.CellSpec{
+			Ingress: okrav1alpha1.CellIngress{
+				AWSApplicationLoadBalancer: &okrav1alpha1.CellIngressAWSApplicationLoadBalancer{
+					TargetGroupSelector: okrav1alpha1.TargetGroupSelector{MatchLabels: map[string]string{"role": "web"}},
+				},
+			},
+			Decommission: &okrav1alpha1.CellDecommission{},
+		},
+	}
+}
+
+func newDecommissionALBConfig(tgs ...okrav1alpha1.ForwardTargetGroup) *okrav1alpha1.AWSApplicationLoadBalancerConfig {
+	var config okrav1alpha1.AWSApplicationLoadBalancerConfig
+
+	config.Namespace = "default"
+	config.Name = "web"
+	config.Spec.Listener.Rule.Forward.TargetGroups = tgs
+
+	return &config
+}
+
+func decommissionForTest(t *testing.T, c client.Client, cell *okrav1alpha1.Cell, now time.Time, notified *[]DecommissionNotification) *DecommissionResult {
+	t.Helper()
+
+	r, err := Decommission(DecommissionInput{
+		Cell:    cell,
+		Client:  c,
+		Context: context.Background(),
+		Now:     metav1.NewTime(now),
+		newELBV2: func(region string) elbv2iface.ELBV2API {
+			return &fakeDeregistrationDelayELBV2{}
+		},
+		notify: func(_ context.Context, n DecommissionNotification, _ *okrav1alpha1.CellDecommission, _ corev1.Secret) error {
+			*notified = append(*notified, n)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return r
+}
+
+func decommissionableCondition(t *testing.T, c client.Client, name string) *metav1.Condition {
+	t.Helper()
+
+	var tg okrav1alpha1.AWSTargetGroup
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: name}, &tg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return meta.FindStatusCondition(tg.Status.Conditions, okrav1alpha1.ConditionTypeDecommissionable)
+}
+
+func TestDecommission(t *testing.T) {
+	const ns = "default"
+
+	cell := newDecommissionCell()
+
+	albConfig := newDecommissionALBConfig(
+		okrav1alpha1.ForwardTargetGroup{Name: "old", Weight: 0},
+		okrav1alpha1.ForwardTargetGroup{Name: "new", Weight: 100},
+	)
+
+	c := crfake.NewFakeClientWithScheme(clclient.Scheme(),
+		cell,
+		albConfig,
+		newDecommissionTargetGroup("old", "cluster1", "1.0.0"),
+		newDecommissionTargetGroup("new", "cluster2", "2.0.0"),
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: "cluster1"}},
+	)
+
+	var notified []DecommissionNotification
+
+	t0 := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	decommission := func(now time.Time) *DecommissionResult {
+		t.Helper()
+
+		return decommissionForTest(t, c, cell, now, &notified)
+	}
+
+	condition := func(name string) *metav1.Condition {
+		t.Helper()
+
+		return decommissionableCondition(t, c, name)
+	}
+
+	// Zero weight starts the bake time of 10m plus the deregistration delay of 30s
+	r := decommission(t0)
+	if cond := condition("old"); cond == nil || cond.Status != metav1.ConditionFalse {
+		t.Fatalf("unexpected condition: %v", cond)
+	}
+	if cond := condition("new"); cond != nil {
+		t.Errorf("unexpected condition on the weighted target group: %v", cond)
+	}
+	if r.RequeueAfter != 10*time.Minute+30*time.Second {
+		t.Errorf("unexpected requeue after: %s", r.RequeueAfter)
+	}
+
+	r = decommission(t0.Add(5 * time.Minute))
+	if cond := condition("old"); cond.Status != metav1.ConditionFalse {
+		t.Errorf("unexpected condition while baking: %v", cond)
+	}
+	if r.RequeueAfter != 5*time.Minute+30*time.Second {
+		t.Errorf("unexpected requeue after: %s", r.RequeueAfter)
+	}
+
+	r = decommission(t0.Add(11 * time.Minute))
+	if cond := condition("old"); cond.Status != metav1.ConditionTrue {
+		t.Errorf("unexpected condition after draining: %v", cond)
+	}
+	if d := cmp.Diff([]string{"cluster1"}, r.Clusters); d != "" {
+		t.Errorf("unexpected clusters: -want +got\n%s", d)
+	}
+
+	var gotConfig okrav1alpha1.AWSApplicationLoadBalancerConfig
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: ns, Name: "web"}, &gotConfig); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d := cmp.Diff([]okrav1alpha1.ForwardTargetGroup{{Name: "new", Weight: 100}}, gotConfig.Spec.Listener.Rule.Forward.TargetGroups); d != "" {
+		t.Errorf("unexpected target groups: -want +got\n%s", d)
+	}
+
+	var secret corev1.Secret
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: ns, Name: "cluster1"}, &secret); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if secret.Labels[okrav1alpha1.LabelDecommissionable] != "true" {
+		t.Errorf("expected the cluster secret to be labeled: %v", secret.Labels)
+	}
+
+	// A cluster is notified only once
+	decommission(t0.Add(12 * time.Minute))
+
+	want := []DecommissionNotification{
+		{
+			Namespace:             ns,
+			Cell:                  "web",
+			Cluster:               "cluster1",
+			TargetGroups:          []string{"old"},
+			DecommissionableSince: metav1.NewTime(t0.Add(11 * time.Minute)),
+		},
+	}
+	if d := cmp.Diff(want, notified); d != "" {
+		t.Errorf("unexpected notifications: -want +got\n%s", d)
+	}
+}
+
+func TestDecommissionRetriesFailedNotification(t *testing.T) {
+	const ns = "default"
+
+	cell := newDecommissionCell()
+
+	c := crfake.NewFakeClientWithScheme(clclient.Scheme(),
+		cell,
+		newDecommissionALBConfig(
+			okrav1alpha1.ForwardTargetGroup{Name: "old", Weight: 0},
+			okrav1alpha1.ForwardTargetGroup{Name: "new", Weight: 100},
+		),
+		newDecommissionTargetGroup("old", "cluster1", "1.0.0"),
+		newDecommissionTargetGroup("new", "cluster2", "2.0.0"),
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: "cluster1"}},
+	)
+
+	var (
+		calls    int
+		notified []string
+	)
+
+	decommission := func(now time.Time) error {
+		_, err := Decommission(DecommissionInput{
+			Cell:    cell,
+			Client:  c,
+			Context: context.Background(),
+			Now:     metav1.NewTime(now),
+			newELBV2: func(region string) elbv2iface.ELBV2API {
+				return &fakeDeregistrationDelayELBV2{}
+			},
+			notify: func(_ context.Context, n DecommissionNotification, _ *okrav1alpha1.CellDecommission, _ corev1.Secret) error {
+				calls++
+				if calls == 1 {
+					return errors.New("webhook unavailable")
+				}
+
+				notified = append(notified, n.Cluster)
+
+				return nil
+			},
+		})
+
+		return err
+	}
+
+	forwardedTargetGroups := func() []okrav1alpha1.ForwardTargetGroup {
+		t.Helper()
+
+		var config okrav1alpha1.AWSApplicationLoadBalancerConfig
+		if err := c.Get(context.Background(), types.NamespacedName{Namespace: ns, Name: "web"}, &config); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		return config.Spec.Listener.Rule.Forward.TargetGroups
+	}
+
+	t0 := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if err := decommission(t0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := decommission(t0.Add(11 * time.Minute)); err == nil {
+		t.Fatal("expected the notification error, got none")
+	}
+
+	// The drained target group is kept in the loadbalancer config so that the next run finds it again
+	if got := len(forwardedTargetGroups()); got != 2 {
+		t.Fatalf("expected the drained target group to be kept on the notification failure, got %d target groups", got)
+	}
+
+	if err := decommission(t0.Add(12 * time.Minute)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if d := cmp.Diff([]string{"cluster1"}, notified); d != "" {
+		t.Errorf("unexpected notifications: -want +got\n%s", d)
+	}
+
+	if d := cmp.Diff([]okrav1alpha1.ForwardTargetGroup{{Name: "new", Weight: 100}}, forwardedTargetGroups()); d != "" {
+		t.Errorf("unexpected target groups: -want +got\n%s", d)
+	}
+}
+
+func TestDecommissionIgnoresCanaryTargetGroups(t *testing.T) {
+	cell := newDecommissionCell()
+
+	t0 := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	testcases := map[string]*okrav1alpha1.AWSApplicationLoadBalancerConfig{
+		// The canary target group is held at zero weight, like by a leading pause step or the target health gate
+		"canary at zero weight": newDecommissionALBConfig(
+			okrav1alpha1.ForwardTargetGroup{Name: "old", Weight: 100},
+			okrav1alpha1.ForwardTargetGroup{Name: "new", Weight: 0},
+		),
+		// The old target group is at zero weight but the canary release is yet to finish
+		"canary in progress": newDecommissionALBConfig(
+			okrav1alpha1.ForwardTargetGroup{Name: "old", Weight: 0},
+			okrav1alpha1.ForwardTargetGroup{Name: "new", Weight: 50},
+			okrav1alpha1.ForwardTargetGroup{Name: "other", Weight: 50},
+		),
+	}
+
+	for name, albConfig := range testcases {
+		t.Run(name, func(t *testing.T) {
+			c := crfake.NewFakeClientWithScheme(clclient.Scheme(),
+				cell,
+				albConfig,
+				newDecommissionTargetGroup("old", "cluster1", "1.0.0"),
+				newDecommissionTargetGroup("new", "cluster2", "2.0.0"),
+				newDecommissionTargetGroup("other", "cluster3", "1.0.0"),
+			)
+
+			var notified []DecommissionNotification
+
+			for _, now := range []time.Time{t0, t0.Add(time.Hour)} {
+				decommissionForTest(t, c, cell, now, &notified)
+			}
+
+			for _, tg := range []string{"old", "new"} {
+				if cond := decommissionableCondition(t, c, tg); cond != nil {
+					t.Errorf("unexpected condition on %s: %v", tg, cond)
+				}
+			}
+
+			if len(notified) > 0 {
+				t.Errorf("unexpected notifications: %v", notified)
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package cell
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+
+	okrav1alpha1 "github.com/mumoshu/okra/api/v1alpha1"
+	"github.com/mumoshu/okra/pkg/sync"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const LabelKeyEnvoyConfigHash = "envoy-config-hash"
+
+// envoyRouter routes traffic to AWS target groups or ClusterEndpoints via an EnvoyRouteConfig
+// that is named after the cell and served to Envoy front proxies by okrad's xDS server.
+type envoyRouter struct {
+	cell          okrav1alpha1.Cell
+	runtimeClient client.Client
+	scheme        *runtime.Scheme
+
+	routeConfig okrav1alpha1.EnvoyRouteConfig
+
+	// clusters maps each backend name to the Envoy cluster without weight.
+	// It contains both selected backends and the clusters that are already in the EnvoyRouteConfig,
+	// so that we can keep routing to a cluster whose backend has gone.
+	clusters map[string]okrav1alpha1.EnvoyCluster
+}
+
+func (r *envoyRouter) config() okrav1alpha1.CellIngressEnvoy {
+	return *r.cell.Spec.Ingress.Envoy
+}
+
+func (r *envoyRouter) versionLabelKeys() []string {
+	config := r.config()
+
+	if config.TargetGroupSelector != nil {
+		return defaultVersionLabelKeys(config.TargetGroupSelector.VersionLabels)
+	}
+
+	return defaultVersionLabelKeys(config.EndpointSelector.VersionLabels)
+}
+
+func (r *envoyRouter) listBackends(ctx context.Context) ([]backend, error) {
+	config := r.config()
+
+	r.clusters = map[string]okrav1alpha1.EnvoyCluster{}
+
+	var backends []backend
+
+	if config.TargetGroupSelector != nil {
+		var tgs okrav1alpha1.AWSTargetGroupList
+
+		if err := r.runtimeClient.List(ctx, &tgs, client.InNamespace(r.cell.Namespace), client.MatchingLabels(config.TargetGroupSelector.MatchLabels)); err != nil {
+			return nil, fmt.Errorf("listing awstargetgroups: %w", err)
+		}
+
+		for _, tg := range tgs.Items {
+			r.clusters[tg.Name] = okrav1alpha1.EnvoyCluster{
+				Name:           tg.Name,
+				TargetGroupARN: tg.Spec.ARN,
+			}
+
+			backends = append(backends, backend{
+				Name:     tg.Name,
+				ARN:      tg.Spec.ARN,
+				Labels:   tg.Labels,
+				Deleting: !tg.DeletionTimestamp.IsZero(),
+			})
+		}
+
+		return backends, nil
+	}
+
+	var endpoints okrav1alpha1.ClusterEndpointList
+
+	if err := r.runtimeClient.List(ctx, &endpoints, client.InNamespace(r.cell.Namespace), client.MatchingLabels(config.EndpointSelector.MatchLabels)); err != nil {
+		return nil, fmt.Errorf("listing clusterendpoints: %w", err)
+	}
+
+	for _, ep := range endpoints.Items {
+		r.clusters[ep.Name] = okrav1alpha1.EnvoyCluster{
+			Name:      ep.Name,
+			Addresses: endpointAddresses(ep),
+		}
+
+		backends = append(backends, backend{
+			Name:     ep.Name,
+			Labels:   ep.Labels,
+			Deleting: !ep.DeletionTimestamp.IsZero(),
+		})
+	}
+
+	return backends, nil
+}
+
+func endpointAddresses(ep okrav1alpha1.ClusterEndpoint) []string {
+	if len(ep.Spec.Addresses) > 0 {
+		return ep.Spec.Addresses
+	}
+
+	port := ep.Spec.Port
+	if port == 0 {
+		port = 80
+	}
+
+	return []string{net.JoinHostPort(ep.Spec.Host, strconv.Itoa(int(port)))}
+}
+
+func (r *envoyRouter) get(ctx context.Context) ([]okrav1alpha1.ForwardTargetGroup, bool, error) {
+	key := types.NamespacedName{Namespace: r.cell.Namespace, Name: r.cell.Name}
+
+	if err := r.runtimeClient.Get(ctx, key, &r.routeConfig); err != nil {
+		if !kerrors.IsNotFound(err) {
+			return nil, false, err
+		}
+
+		return nil, false, nil
+	}
+
+	if r.clusters == nil {
+		r.clusters = map[string]okrav1alpha1.EnvoyCluster{}
+	}
+
+	var tgs []okrav1alpha1.ForwardTargetGroup
+
+	for _, c := range r.routeConfig.Spec.Clusters {
+		if _, ok := r.clusters[c.Name]; !ok {
+			known := c
+			known.Weight = 0
+			r.clusters[c.Name] = known
+		}
+
+		tgs = append(tgs, okrav1alpha1.ForwardTargetGroup{
+			Name:   c.Name,
+			ARN:    c.TargetGroupARN,
+			Weight: c.Weight,
+		})
+	}
+
+	return tgs, true, nil
+}
+
+// desiredSpec returns the spec of the EnvoyRouteConfig with the given clusters.
+func (r *envoyRouter) desiredSpec(tgs []okrav1alpha1.ForwardTargetGroup) (*okrav1alpha1.EnvoyRouteConfigSpec, error) {
+	config := r.config()
+
+	var clusters []okrav1alpha1.EnvoyCluster
+
+	for _, tg := range tgs {
+		c, ok := r.clusters[tg.Name]
+		if !ok {
+			return nil, fmt.Errorf("no backend found for envoy cluster %s", tg.Name)
+		}
+
+		c.Weight = tg.Weight
+
+		clusters = append(clusters, c)
+	}
+
+	return &okrav1alpha1.EnvoyRouteConfigSpec{
+		NodeCluster: config.NodeCluster,
+		Listener:    config.Listener,
+		Clusters:    clusters,
+	}, nil
+}
+
+func (r *envoyRouter) currentWeights() []okrav1alpha1.ForwardTargetGroup {
+	var tgs []okrav1alpha1.ForwardTargetGroup
+
+	for _, c := range r.routeConfig.Spec.Clusters {
+		tgs = append(tgs, okrav1alpha1.ForwardTargetGroup{Name: c.Name, Weight: c.Weight})
+	}
+
+	return tgs
+}
+
+func (r *envoyRouter) create(ctx context.Context, tgs []okrav1alpha1.ForwardTargetGroup) error {
+	spec, err := r.desiredSpec(tgs)
+	if err != nil {
+		return err
+	}
+
+	routeConfig := okrav1alpha1.EnvoyRouteConfig{}
+	routeConfig.Namespace = r.cell.Namespace
+	routeConfig.Name = r.cell.Name
+	routeConfig.Spec = *spec
+	ctrl.SetControllerReference(&r.cell, &routeConfig, r.scheme)
+
+	metav1.SetMetaDataAnnotation(&routeConfig.ObjectMeta, LabelKeyEnvoyConfigHash, sync.ComputeHash(r.config()))
+
+	if err := r.runtimeClient.Create(ctx, &routeConfig); err != nil {
+		return fmt.Errorf("creating envoyrouteconfig: %w", err)
+	}
+
+	return nil
+}
+
+func (r *envoyRouter) updateConfig(ctx context.Context) (bool, error) {
+	desiredHash := sync.ComputeHash(r.config())
+
+	if r.routeConfig.Annotations[LabelKeyEnvoyConfigHash] == desiredHash {
+		return false, nil
+	}
+
+	spec, err := r.desiredSpec(r.currentWeights())
+	if err != nil {
+		return false, err
+	}
+
+	metav1.SetMetaDataAnnotation(&r.routeConfig.ObjectMeta, LabelKeyEnvoyConfigHash, desiredHash)
+
+	r.routeConfig.Spec = *spec
+
+	if err := r.runtimeClient.Update(ctx, &r.routeConfig); err != nil {
+		return false, fmt.Errorf("updating envoyrouteconfig: %w", err)
+	}
+
+	return true, nil
+}
+
+func (r *envoyRouter) update(ctx context.Context, tgs []okrav1alpha1.ForwardTargetGroup) (bool, error) {
+	spec, err := r.desiredSpec(tgs)
+	if err != nil {
+		return false, err
+	}
+
+	r.routeConfig.Spec = *spec
+
+	currentHash := r.routeConfig.Annotations[LabelKeyTemplateHash]
+	desiredHash := sync.ComputeHash(r.routeConfig.Spec)
+
+	if currentHash == desiredHash {
+		return false, nil
+	}
+
+	metav1.SetMetaDataAnnotation(&r.routeConfig.ObjectMeta, LabelKeyTemplateHash, desiredHash)
+
+	if err := r.runtimeClient.Update(ctx, &r.routeConfig); err != nil {
+		return false, fmt.Errorf("updating envoyrouteconfig: %w", err)
+	}
+
+	return true, nil
+}
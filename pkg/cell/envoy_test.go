@@ -0,0 +1,78 @@
+package cell
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	rolloutsv1alpha1 "github.com/mumoshu/okra/api/rollouts/v1alpha1"
+	okrav1alpha1 "github.com/mumoshu/okra/api/v1alpha1"
+	"github.com/mumoshu/okra/pkg/clclient"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSyncEnvoy(t *testing.T) {
+	scheme := clclient.Scheme()
+
+	cell := &okrav1alpha1.Cell{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "web",
+		},
+		Spec: okrav1alpha1.CellSpec{
+			Ingress: okrav1alpha1.CellIngress{
+				Type: okrav1alpha1.CellIngressTypeEnvoy,
+				Envoy: &okrav1alpha1.CellIngressEnvoy{
+					NodeCluster: "front",
+					EndpointSelector: &okrav1alpha1.ClusterEndpointSelector{
+						MatchLabels: map[string]string{"role": "web"},
+					},
+				},
+			},
+			UpdateStrategy: okrav1alpha1.CellUpdateStrategy{
+				Type: okrav1alpha1.CellUpdateStrategyTypeCanary,
+				Canary: &okrav1alpha1.CellUpdateStrategyCanary{
+					Steps: []rolloutsv1alpha1.CanaryStep{
+						{SetWeight: pointer.Int32Ptr(20)},
+						{Pause: &rolloutsv1alpha1.RolloutPause{}},
+					},
+				},
+			},
+		},
+	}
+
+	ep1 := newClusterEndpoint("web-1", "", "1.0.0")
+	ep1.Spec.Addresses = []string{"10.0.0.1:8080"}
+
+	c := fake.NewFakeClientWithScheme(scheme, cell, ep1)
+
+	syncCell(t, c, scheme, cell)
+
+	if err := c.Create(context.Background(), newClusterEndpoint("web-2", "", "2.0.0")); err != nil {
+		t.Fatal(err)
+	}
+
+	syncCell(t, c, scheme, cell)
+
+	var routeConfig okrav1alpha1.EnvoyRouteConfig
+
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "web"}, &routeConfig); err != nil {
+		t.Fatalf("getting envoyrouteconfig: %v", err)
+	}
+
+	want := []okrav1alpha1.EnvoyCluster{
+		{Name: "web-1", Weight: 80, Addresses: []string{"10.0.0.1:8080"}},
+		{Name: "web-2", Weight: 20, Addresses: []string{"web.default.svc.cluster.local:80"}},
+	}
+
+	if d := cmp.Diff(want, routeConfig.Spec.Clusters); d != "" {
+		t.Errorf("unexpected clusters: %s", d)
+	}
+
+	if routeConfig.Spec.NodeCluster != "front" {
+		t.Errorf("unexpected node cluster: %s", routeConfig.Spec.NodeCluster)
+	}
+}
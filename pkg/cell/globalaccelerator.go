@@ -0,0 +1,201 @@
+package cell
+
+import (
+	"context"
+	"fmt"
+
+	okrav1alpha1 "github.com/mumoshu/okra/api/v1alpha1"
+	"github.com/mumoshu/okra/pkg/sync"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const LabelKeyGlobalAcceleratorConfigHash = "globalaccelerator-config-hash"
+
+// globalAcceleratorRouter routes traffic to ClusterEndpoints via the endpoint groups of an AWS Global Accelerator listener,
+// managed via an AWSGlobalAcceleratorConfig that is named after the cell.
+type globalAcceleratorRouter struct {
+	cell          okrav1alpha1.Cell
+	runtimeClient client.Client
+	scheme        *runtime.Scheme
+
+	acceleratorConfig okrav1alpha1.AWSGlobalAcceleratorConfig
+
+	// endpoints maps each endpoint ID to the endpoint without weight.
+	// It contains both selected ClusterEndpoints and the endpoints that are already in the AWSGlobalAcceleratorConfig,
+	// so that we can keep routing to an endpoint whose ClusterEndpoint has gone.
+	endpoints map[string]okrav1alpha1.AWSGlobalAcceleratorEndpoint
+}
+
+func (r *globalAcceleratorRouter) config() okrav1alpha1.CellIngressAWSGlobalAccelerator {
+	return *r.cell.Spec.Ingress.AWSGlobalAccelerator
+}
+
+func (r *globalAcceleratorRouter) versionLabelKeys() []string {
+	return defaultVersionLabelKeys(r.config().EndpointSelector.VersionLabels)
+}
+
+func (r *globalAcceleratorRouter) listBackends(ctx context.Context) ([]backend, error) {
+	var endpoints okrav1alpha1.ClusterEndpointList
+
+	if err := r.runtimeClient.List(ctx, &endpoints, client.InNamespace(r.cell.Namespace), client.MatchingLabels(r.config().EndpointSelector.MatchLabels)); err != nil {
+		return nil, fmt.Errorf("listing clusterendpoints: %w", err)
+	}
+
+	r.endpoints = map[string]okrav1alpha1.AWSGlobalAcceleratorEndpoint{}
+
+	var backends []backend
+
+	for _, ep := range endpoints.Items {
+		id := ep.Spec.AWSLoadBalancerARN
+		if id == "" {
+			continue
+		}
+
+		r.endpoints[id] = okrav1alpha1.AWSGlobalAcceleratorEndpoint{
+			EndpointID:                  id,
+			ClientIPPreservationEnabled: r.config().ClientIPPreservationEnabled,
+		}
+
+		// Global Accelerator endpoints are identified by their IDs, so we use them as backend names
+		backends = append(backends, backend{
+			Name:     id,
+			Labels:   ep.Labels,
+			Deleting: !ep.DeletionTimestamp.IsZero(),
+		})
+	}
+
+	return backends, nil
+}
+
+func (r *globalAcceleratorRouter) get(ctx context.Context) ([]okrav1alpha1.ForwardTargetGroup, bool, error) {
+	key := types.NamespacedName{Namespace: r.cell.Namespace, Name: r.cell.Name}
+
+	if err := r.runtimeClient.Get(ctx, key, &r.acceleratorConfig); err != nil {
+		if !kerrors.IsNotFound(err) {
+			return nil, false, err
+		}
+
+		return nil, false, nil
+	}
+
+	if r.endpoints == nil {
+		r.endpoints = map[string]okrav1alpha1.AWSGlobalAcceleratorEndpoint{}
+	}
+
+	for _, e := range r.acceleratorConfig.Spec.Endpoints {
+		if _, ok := r.endpoints[e.EndpointID]; !ok {
+			known := e
+			known.Weight = 0
+			r.endpoints[e.EndpointID] = known
+		}
+	}
+
+	return r.currentWeights(), true, nil
+}
+
+func (r *globalAcceleratorRouter) currentWeights() []okrav1alpha1.ForwardTargetGroup {
+	var tgs []okrav1alpha1.ForwardTargetGroup
+
+	for _, e := range r.acceleratorConfig.Spec.Endpoints {
+		tgs = append(tgs, okrav1alpha1.ForwardTargetGroup{
+			Name:   e.EndpointID,
+			Weight: e.Weight,
+		})
+	}
+
+	return tgs
+}
+
+// desiredSpec returns the spec of the AWSGlobalAcceleratorConfig with the given endpoint weights.
+func (r *globalAcceleratorRouter) desiredSpec(tgs []okrav1alpha1.ForwardTargetGroup) (*okrav1alpha1.AWSGlobalAcceleratorConfigSpec, error) {
+	var endpoints []okrav1alpha1.AWSGlobalAcceleratorEndpoint
+
+	for _, tg := range tgs {
+		e, ok := r.endpoints[tg.Name]
+		if !ok {
+			return nil, fmt.Errorf("no clusterendpoint found for endpoint %s", tg.Name)
+		}
+
+		e.Weight = tg.Weight
+
+		endpoints = append(endpoints, e)
+	}
+
+	return &okrav1alpha1.AWSGlobalAcceleratorConfigSpec{
+		ListenerARN: r.config().ListenerARN,
+		Endpoints:   endpoints,
+	}, nil
+}
+
+func (r *globalAcceleratorRouter) create(ctx context.Context, tgs []okrav1alpha1.ForwardTargetGroup) error {
+	spec, err := r.desiredSpec(tgs)
+	if err != nil {
+		return err
+	}
+
+	acceleratorConfig := okrav1alpha1.AWSGlobalAcceleratorConfig{}
+	acceleratorConfig.Namespace = r.cell.Namespace
+	acceleratorConfig.Name = r.cell.Name
+	acceleratorConfig.Spec = *spec
+	ctrl.SetControllerReference(&r.cell, &acceleratorConfig, r.scheme)
+
+	metav1.SetMetaDataAnnotation(&acceleratorConfig.ObjectMeta, LabelKeyGlobalAcceleratorConfigHash, sync.ComputeHash(r.config()))
+
+	if err := r.runtimeClient.Create(ctx, &acceleratorConfig); err != nil {
+		return fmt.Errorf("creating awsglobalacceleratorconfig: %w", err)
+	}
+
+	return nil
+}
+
+func (r *globalAcceleratorRouter) updateConfig(ctx context.Context) (bool, error) {
+	desiredHash := sync.ComputeHash(r.config())
+
+	if r.acceleratorConfig.Annotations[LabelKeyGlobalAcceleratorConfigHash] == desiredHash {
+		return false, nil
+	}
+
+	spec, err := r.desiredSpec(r.currentWeights())
+	if err != nil {
+		return false, err
+	}
+
+	metav1.SetMetaDataAnnotation(&r.acceleratorConfig.ObjectMeta, LabelKeyGlobalAcceleratorConfigHash, desiredHash)
+
+	r.acceleratorConfig.Spec = *spec
+
+	if err := r.runtimeClient.Update(ctx, &r.acceleratorConfig); err != nil {
+		return false, fmt.Errorf("updating awsglobalacceleratorconfig: %w", err)
+	}
+
+	return true, nil
+}
+
+func (r *globalAcceleratorRouter) update(ctx context.Context, tgs []okrav1alpha1.ForwardTargetGroup) (bool, error) {
+	spec, err := r.desiredSpec(tgs)
+	if err != nil {
+		return false, err
+	}
+
+	r.acceleratorConfig.Spec = *spec
+
+	currentHash := r.acceleratorConfig.Annotations[LabelKeyTemplateHash]
+	desiredHash := sync.ComputeHash(r.acceleratorConfig.Spec)
+
+	if currentHash == desiredHash {
+		return false, nil
+	}
+
+	metav1.SetMetaDataAnnotation(&r.acceleratorConfig.ObjectMeta, LabelKeyTemplateHash, desiredHash)
+
+	if err := r.runtimeClient.Update(ctx, &r.acceleratorConfig); err != nil {
+		return false, fmt.Errorf("updating awsglobalacceleratorconfig: %w", err)
+	}
+
+	return true, nil
+}
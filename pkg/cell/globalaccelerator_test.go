@@ -0,0 +1,101 @@
+package cell
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	rolloutsv1alpha1 "github.com/mumoshu/okra/api/rollouts/v1alpha1"
+	okrav1alpha1 "github.com/mumoshu/okra/api/v1alpha1"
+	"github.com/mumoshu/okra/pkg/clclient"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newGlobalAcceleratorCell(steps ...rolloutsv1alpha1.CanaryStep) *okrav1alpha1.Cell {
+	return &okrav1alpha1.Cell{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "web",
+		},
+		Spec: okrav1alpha1.CellSpec{
+			Ingress: okrav1alpha1.CellIngress{
+				Type: okrav1alpha1.CellIngressTypeAWSGlobalAccelerator,
+				AWSGlobalAccelerator: &okrav1alpha1.CellIngressAWSGlobalAccelerator{
+					ListenerARN: "arn:aws:globalaccelerator::123456789012:accelerator/1/listener/1",
+					EndpointSelector: okrav1alpha1.ClusterEndpointSelector{
+						MatchLabels: map[string]string{"role": "web"},
+					},
+				},
+			},
+			UpdateStrategy: okrav1alpha1.CellUpdateStrategy{
+				Type: okrav1alpha1.CellUpdateStrategyTypeCanary,
+				Canary: &okrav1alpha1.CellUpdateStrategyCanary{
+					Steps: steps,
+				},
+			},
+		},
+	}
+}
+
+func newLoadBalancerClusterEndpoint(name, version string) *okrav1alpha1.ClusterEndpoint {
+	ep := newClusterEndpoint(name, "", version)
+	ep.Spec.AWSLoadBalancerARN = "arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/app/" + name + "/1"
+
+	return ep
+}
+
+func endpointWeights(t *testing.T, c client.Client) map[string]int {
+	t.Helper()
+
+	var config okrav1alpha1.AWSGlobalAcceleratorConfig
+
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "web"}, &config); err != nil {
+		t.Fatalf("getting awsglobalacceleratorconfig: %v", err)
+	}
+
+	weights := map[string]int{}
+	for _, e := range config.Spec.Endpoints {
+		weights[e.EndpointID] = e.Weight
+	}
+
+	return weights
+}
+
+func TestSyncAWSGlobalAccelerator(t *testing.T) {
+	scheme := clclient.Scheme()
+
+	cell := newGlobalAcceleratorCell(
+		rolloutsv1alpha1.CanaryStep{SetWeight: pointer.Int32Ptr(20)},
+		rolloutsv1alpha1.CanaryStep{Pause: &rolloutsv1alpha1.RolloutPause{}},
+	)
+
+	web1 := newLoadBalancerClusterEndpoint("web-1", "1.0.0")
+	web2 := newLoadBalancerClusterEndpoint("web-2", "2.0.0")
+
+	c := fake.NewFakeClientWithScheme(scheme, cell, web1)
+
+	syncCell(t, c, scheme, cell)
+
+	if d := cmp.Diff(map[string]int{web1.Spec.AWSLoadBalancerARN: 100}, endpointWeights(t, c)); d != "" {
+		t.Fatalf("unexpected weights after creation: %s", d)
+	}
+
+	if err := c.Create(context.Background(), web2); err != nil {
+		t.Fatal(err)
+	}
+
+	syncCell(t, c, scheme, cell)
+
+	want := map[string]int{
+		web1.Spec.AWSLoadBalancerARN: 80,
+		web2.Spec.AWSLoadBalancerARN: 20,
+	}
+
+	if d := cmp.Diff(want, endpointWeights(t, c)); d != "" {
+		t.Fatalf("unexpected weights after the first canary step: %s", d)
+	}
+}
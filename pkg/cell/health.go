@@ -0,0 +1,112 @@
+package cell
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	okrav1alpha1 "github.com/mumoshu/okra/api/v1alpha1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// unreachableClusters returns the names of the clusters backing the target groups
+// whose last connectivity check recorded in any ClusterSet in the namespace failed.
+// Target groups without the binding cluster label are not checked.
+func unreachableClusters(ctx context.Context, runtimeClient client.Client, ns string, tgs []backend) ([]string, error) {
+	var list okrav1alpha1.ClusterSetList
+
+	if err := runtimeClient.List(ctx, &list, client.InNamespace(ns)); err != nil {
+		return nil, fmt.Errorf("listing clustersets: %w", err)
+	}
+
+	failed := map[string]bool{}
+
+	for _, cs := range list.Items {
+		for _, h := range cs.Status.ClusterHealth {
+			if h.Status == okrav1alpha1.ClusterConnectionStatusFailed {
+				failed[h.Name] = true
+			}
+		}
+	}
+
+	seen := map[string]bool{}
+
+	var unreachable []string
+
+	for _, tg := range tgs {
+		cluster := tg.Labels[okrav1alpha1.AWSTargetGroupLabelBindingCluster]
+
+		if cluster == "" || !failed[cluster] || seen[cluster] {
+			continue
+		}
+
+		seen[cluster] = true
+
+		unreachable = append(unreachable, cluster)
+	}
+
+	sort.Strings(unreachable)
+
+	return unreachable, nil
+}
+
+// targetHealthViolation tells why a target group does not meet the target health requirement.
+type targetHealthViolation struct {
+	Name    string
+	Message string
+	// Observed is false when the health of the target group is not observed yet
+	Observed bool
+}
+
+// targetHealthViolations returns the violations of the target health requirement by the target groups,
+// based on the target health recorded in the status of the AWSTargetGroups of the same names.
+func targetHealthViolations(ctx context.Context, runtimeClient client.Client, ns string, tgs []backend, req okrav1alpha1.CanaryTargetHealth) ([]targetHealthViolation, error) {
+	var violations []targetHealthViolation
+
+	for _, tg := range tgs {
+		var awsTG okrav1alpha1.AWSTargetGroup
+
+		if err := runtimeClient.Get(ctx, types.NamespacedName{Namespace: ns, Name: tg.Name}, &awsTG); err != nil {
+			if kerrors.IsNotFound(err) {
+				violations = append(violations, targetHealthViolation{Name: tg.Name, Message: "awstargetgroup not found"})
+				continue
+			}
+
+			return nil, fmt.Errorf("getting awstargetgroup %s: %w", tg.Name, err)
+		}
+
+		h := awsTG.Status.TargetHealth
+		if h == nil {
+			violations = append(violations, targetHealthViolation{Name: tg.Name, Message: "target health not observed yet"})
+			continue
+		}
+
+		if msg := checkTargetHealth(*h, req); msg != "" {
+			violations = append(violations, targetHealthViolation{Name: tg.Name, Message: msg, Observed: true})
+		}
+	}
+
+	return violations, nil
+}
+
+// checkTargetHealth returns the reason the target health does not meet the requirement, or an empty string if it does.
+func checkTargetHealth(h okrav1alpha1.AWSTargetGroupTargetHealth, req okrav1alpha1.CanaryTargetHealth) string {
+	minTargets := int32(1)
+	if req.MinHealthyTargets != nil {
+		minTargets = *req.MinHealthyTargets
+	} else if req.MinHealthyPercentage != nil {
+		minTargets = 0
+	}
+
+	if h.Healthy < minTargets {
+		return fmt.Sprintf("%d healthy target(s) is less than %d", h.Healthy, minTargets)
+	}
+
+	if p := req.MinHealthyPercentage; p != nil && (h.Total == 0 || h.Healthy*100 < *p*h.Total) {
+		return fmt.Sprintf("%d out of %d target(s) healthy is less than %d%%", h.Healthy, h.Total, *p)
+	}
+
+	return ""
+}
@@ -0,0 +1,273 @@
+package cell
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	rolloutsv1alpha1 "github.com/mumoshu/okra/api/rollouts/v1alpha1"
+	okrav1alpha1 "github.com/mumoshu/okra/api/v1alpha1"
+	"github.com/mumoshu/okra/pkg/clclient"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestUnreachableClusters(t *testing.T) {
+	cs := &okrav1alpha1.ClusterSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+		Status: okrav1alpha1.ClusterSetStatus{
+			ClusterHealth: []okrav1alpha1.ClusterHealth{
+				{Name: "web1", Status: okrav1alpha1.ClusterConnectionStatusSuccessful},
+				{Name: "web2", Status: okrav1alpha1.ClusterConnectionStatusFailed},
+			},
+		},
+	}
+
+	c := fake.NewFakeClientWithScheme(clclient.Scheme(), cs)
+
+	tg := func(name, cluster string) backend {
+		return backend{Name: name, Labels: map[string]string{okrav1alpha1.AWSTargetGroupLabelBindingCluster: cluster}}
+	}
+
+	got, err := unreachableClusters(context.Background(), c, "default", []backend{
+		tg("web1-v2", "web1"),
+		tg("web2-v2", "web2"),
+		tg("web2-v2-b", "web2"),
+		{Name: "unlabeled"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if d := cmp.Diff([]string{"web2"}, got); d != "" {
+		t.Errorf("unexpected unreachable clusters: %s", d)
+	}
+
+	got, err = unreachableClusters(context.Background(), c, "other", []backend{tg("web2-v2", "web2")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(got) != 0 {
+		t.Errorf("unexpected unreachable clusters in another namespace: %v", got)
+	}
+}
+
+func TestTargetHealthViolations(t *testing.T) {
+	tg := func(name string, healthy, total int32) *okrav1alpha1.AWSTargetGroup {
+		return &okrav1alpha1.AWSTargetGroup{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Status: okrav1alpha1.AWSTargetGroupStatus{
+				TargetHealth: &okrav1alpha1.AWSTargetGroupTargetHealth{Healthy: healthy, Total: total},
+			},
+		}
+	}
+
+	unobserved := &okrav1alpha1.AWSTargetGroup{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web-v2-c"}}
+
+	c := fake.NewFakeClientWithScheme(clclient.Scheme(), tg("web-v2-a", 3, 4), tg("web-v2-b", 1, 4), unobserved)
+
+	tgs := []backend{{Name: "web-v2-a"}, {Name: "web-v2-b"}, {Name: "web-v2-c"}}
+
+	testcases := []struct {
+		name string
+		req  okrav1alpha1.CanaryTargetHealth
+		want []targetHealthViolation
+	}{
+		{
+			name: "default",
+			want: []targetHealthViolation{
+				{Name: "web-v2-c", Message: "target health not observed yet"},
+			},
+		},
+		{
+			name: "min healthy targets",
+			req:  okrav1alpha1.CanaryTargetHealth{MinHealthyTargets: pointer.Int32Ptr(2)},
+			want: []targetHealthViolation{
+				{Name: "web-v2-b", Message: "1 healthy target(s) is less than 2", Observed: true},
+				{Name: "web-v2-c", Message: "target health not observed yet"},
+			},
+		},
+		{
+			name: "min healthy percentage",
+			req:  okrav1alpha1.CanaryTargetHealth{MinHealthyPercentage: pointer.Int32Ptr(75)},
+			want: []targetHealthViolation{
+				{Name: "web-v2-b", Message: "1 out of 4 target(s) healthy is less than 75%", Observed: true},
+				{Name: "web-v2-c", Message: "target health not observed yet"},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := targetHealthViolations(context.Background(), c, "default", tgs, tc.req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if d := cmp.Diff(tc.want, got); d != "" {
+				t.Errorf("unexpected violations: %s", d)
+			}
+		})
+	}
+}
+
+func TestSyncHoldsCanaryStepsUntilTargetsAreHealthy(t *testing.T) {
+	scheme := clclient.Scheme()
+
+	cell := &okrav1alpha1.Cell{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+		Spec: okrav1alpha1.CellSpec{
+			Ingress: okrav1alpha1.CellIngress{
+				AWSApplicationLoadBalancer: &okrav1alpha1.CellIngressAWSApplicationLoadBalancer{
+					TargetGroupSelector: okrav1alpha1.TargetGroupSelector{MatchLabels: map[string]string{"role": "web"}},
+				},
+			},
+			UpdateStrategy: okrav1alpha1.CellUpdateStrategy{
+				Type: okrav1alpha1.CellUpdateStrategyTypeCanary,
+				Canary: &okrav1alpha1.CellUpdateStrategyCanary{
+					Steps: []rolloutsv1alpha1.CanaryStep{
+						{SetWeight: pointer.Int32Ptr(20)},
+						{Pause: &rolloutsv1alpha1.RolloutPause{}},
+					},
+					TargetHealth: &okrav1alpha1.CanaryTargetHealth{},
+				},
+			},
+		},
+	}
+
+	tg := func(name, version string) *okrav1alpha1.AWSTargetGroup {
+		return &okrav1alpha1.AWSTargetGroup{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      name,
+				Labels:    map[string]string{"role": "web", okrav1alpha1.DefaultVersionLabelKey: version},
+			},
+			Spec: okrav1alpha1.AWSTargetGroupSpec{ARN: "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/" + name + "/1"},
+		}
+	}
+
+	c := fake.NewFakeClientWithScheme(scheme, cell, tg("web-1", "1.0.0"))
+
+	syncCell(t, c, scheme, cell)
+
+	web2 := tg("web-2", "2.0.0")
+
+	if err := c.Create(context.Background(), web2); err != nil {
+		t.Fatal(err)
+	}
+
+	weights := func() map[string]int {
+		t.Helper()
+
+		var config okrav1alpha1.AWSApplicationLoadBalancerConfig
+		if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "web"}, &config); err != nil {
+			t.Fatal(err)
+		}
+
+		weights := map[string]int{}
+		for _, tg := range config.Spec.Listener.Rule.Forward.TargetGroups {
+			weights[tg.Name] = tg.Weight
+		}
+
+		return weights
+	}
+
+	pauses := func() int {
+		t.Helper()
+
+		var pauses okrav1alpha1.PauseList
+		if err := c.List(context.Background(), &pauses); err != nil {
+			t.Fatal(err)
+		}
+
+		return len(pauses.Items)
+	}
+
+	// The setWeight step is held in progress, so the pause step never starts
+	syncCell(t, c, scheme, cell)
+
+	if d := cmp.Diff(map[string]int{"web-1": 100, "web-2": 0}, weights()); d != "" {
+		t.Fatalf("unexpected weights while the target health is not observed: %s", d)
+	}
+
+	if n := pauses(); n != 0 {
+		t.Fatalf("expected no pause while the setWeight step is held, got %d", n)
+	}
+
+	web2.Status.TargetHealth = &okrav1alpha1.AWSTargetGroupTargetHealth{Healthy: 1, Total: 1}
+
+	if err := c.Status().Update(context.Background(), web2); err != nil {
+		t.Fatal(err)
+	}
+
+	syncCell(t, c, scheme, cell)
+
+	if d := cmp.Diff(map[string]int{"web-1": 80, "web-2": 20}, weights()); d != "" {
+		t.Fatalf("unexpected weights after the targets became healthy: %s", d)
+	}
+
+	if n := pauses(); n != 1 {
+		t.Fatalf("expected the pause step to create a pause, got %d pauses", n)
+	}
+}
+
+func TestSyncRejectsTargetHealthWithoutAWSTargetGroups(t *testing.T) {
+	scheme := clclient.Scheme()
+
+	cell := newHTTPRouteCell()
+	cell.Spec.UpdateStrategy.Canary.TargetHealth = &okrav1alpha1.CanaryTargetHealth{}
+
+	c := fake.NewFakeClientWithScheme(scheme, cell, newClusterService("web-1", "1.0.0"))
+
+	if err := Sync(SyncInput{Cell: cell, Client: c, Scheme: scheme}); err == nil {
+		t.Fatal("expected error, got none")
+	}
+}
+
+func TestSyncHoldsCanaryStepsWhileClustersAreUnreachable(t *testing.T) {
+	scheme := clclient.Scheme()
+
+	cell := newHTTPRouteCell(
+		rolloutsv1alpha1.CanaryStep{SetWeight: pointer.Int32Ptr(20)},
+		rolloutsv1alpha1.CanaryStep{Pause: &rolloutsv1alpha1.RolloutPause{}},
+	)
+	cell.Spec.UpdateStrategy.Canary.RequireReachableClusters = true
+
+	cs := &okrav1alpha1.ClusterSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+		Status: okrav1alpha1.ClusterSetStatus{
+			ClusterHealth: []okrav1alpha1.ClusterHealth{
+				{Name: "cluster2", Status: okrav1alpha1.ClusterConnectionStatusFailed},
+			},
+		},
+	}
+
+	c := fake.NewFakeClientWithScheme(scheme, cell, cs, newClusterService("web-1", "1.0.0"))
+
+	syncCell(t, c, scheme, cell)
+
+	web2 := newClusterService("web-2", "2.0.0")
+	web2.Labels[okrav1alpha1.AWSTargetGroupLabelBindingCluster] = "cluster2"
+
+	if err := c.Create(context.Background(), web2); err != nil {
+		t.Fatal(err)
+	}
+
+	syncCell(t, c, scheme, cell)
+
+	if d := cmp.Diff(map[string]int32{"web-1": 100, "web-2": 0}, backendWeights(t, c)); d != "" {
+		t.Fatalf("unexpected weights while the cluster is unreachable: %s", d)
+	}
+
+	var pauses okrav1alpha1.PauseList
+	if err := c.List(context.Background(), &pauses); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pauses.Items) != 0 {
+		t.Fatalf("expected no pause while the setWeight step is held, got %d pauses", len(pauses.Items))
+	}
+}
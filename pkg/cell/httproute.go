@@ -0,0 +1,190 @@
+package cell
+
+import (
+	"context"
+	"fmt"
+
+	gatewayv1beta1 "github.com/mumoshu/okra/api/gateway/v1beta1"
+	okrav1alpha1 "github.com/mumoshu/okra/api/v1alpha1"
+	"github.com/mumoshu/okra/pkg/sync"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const LabelKeyHTTPRouteConfigHash = "httproute-config-hash"
+
+// httpRouteRouter routes traffic to Kubernetes services via a Gateway API HTTPRoute
+// that is named after the cell.
+type httpRouteRouter struct {
+	cell          okrav1alpha1.Cell
+	runtimeClient client.Client
+	scheme        *runtime.Scheme
+
+	route gatewayv1beta1.HTTPRoute
+}
+
+func (r *httpRouteRouter) config() okrav1alpha1.CellIngressGatewayAPIHTTPRoute {
+	return *r.cell.Spec.Ingress.GatewayAPIHTTPRoute
+}
+
+func (r *httpRouteRouter) versionLabelKeys() []string {
+	return defaultVersionLabelKeys(r.config().BackendSelector.VersionLabels)
+}
+
+func (r *httpRouteRouter) listBackends(ctx context.Context) ([]backend, error) {
+	var services corev1.ServiceList
+
+	if err := r.runtimeClient.List(ctx, &services, client.InNamespace(r.cell.Namespace), client.MatchingLabels(r.config().BackendSelector.MatchLabels)); err != nil {
+		return nil, fmt.Errorf("listing services: %w", err)
+	}
+
+	var backends []backend
+
+	for _, svc := range services.Items {
+		backends = append(backends, backend{
+			Name:     svc.Name,
+			Labels:   svc.Labels,
+			Deleting: !svc.DeletionTimestamp.IsZero(),
+		})
+	}
+
+	return backends, nil
+}
+
+func (r *httpRouteRouter) get(ctx context.Context) ([]okrav1alpha1.ForwardTargetGroup, bool, error) {
+	key := types.NamespacedName{Namespace: r.cell.Namespace, Name: r.cell.Name}
+
+	if err := r.runtimeClient.Get(ctx, key, &r.route); err != nil {
+		if !kerrors.IsNotFound(err) {
+			return nil, false, err
+		}
+
+		return nil, false, nil
+	}
+
+	return r.currentWeights(), true, nil
+}
+
+func (r *httpRouteRouter) currentWeights() []okrav1alpha1.ForwardTargetGroup {
+	var tgs []okrav1alpha1.ForwardTargetGroup
+
+	for _, rule := range r.route.Spec.Rules {
+		for _, ref := range rule.BackendRefs {
+			// Gateway API defaults the weight to 1 when omitted
+			weight := 1
+			if ref.Weight != nil {
+				weight = int(*ref.Weight)
+			}
+
+			tgs = append(tgs, okrav1alpha1.ForwardTargetGroup{
+				Name:   string(ref.Name),
+				Weight: weight,
+			})
+		}
+	}
+
+	return tgs
+}
+
+func (r *httpRouteRouter) backendRefs(tgs []okrav1alpha1.ForwardTargetGroup) []gatewayv1beta1.HTTPBackendRef {
+	var port *gatewayv1beta1.PortNumber
+
+	if p := r.config().Port; p != 0 {
+		n := gatewayv1beta1.PortNumber(p)
+		port = &n
+	}
+
+	var refs []gatewayv1beta1.HTTPBackendRef
+
+	for _, tg := range tgs {
+		weight := int32(tg.Weight)
+
+		refs = append(refs, gatewayv1beta1.HTTPBackendRef{
+			BackendRef: gatewayv1beta1.BackendRef{
+				BackendObjectReference: gatewayv1beta1.BackendObjectReference{
+					Name: gatewayv1beta1.ObjectName(tg.Name),
+					Port: port,
+				},
+				Weight: &weight,
+			},
+		})
+	}
+
+	return refs
+}
+
+// desiredSpec returns the spec of the HTTPRoute with the given backends.
+func (r *httpRouteRouter) desiredSpec(tgs []okrav1alpha1.ForwardTargetGroup) gatewayv1beta1.HTTPRouteSpec {
+	config := r.config()
+
+	return gatewayv1beta1.HTTPRouteSpec{
+		CommonRouteSpec: gatewayv1beta1.CommonRouteSpec{
+			ParentRefs: config.ParentRefs,
+		},
+		Hostnames: config.Hostnames,
+		Rules: []gatewayv1beta1.HTTPRouteRule{
+			{
+				Matches:     config.Matches,
+				BackendRefs: r.backendRefs(tgs),
+			},
+		},
+	}
+}
+
+func (r *httpRouteRouter) create(ctx context.Context, tgs []okrav1alpha1.ForwardTargetGroup) error {
+	route := gatewayv1beta1.HTTPRoute{}
+	route.Namespace = r.cell.Namespace
+	route.Name = r.cell.Name
+	route.Spec = r.desiredSpec(tgs)
+	ctrl.SetControllerReference(&r.cell, &route, r.scheme)
+
+	metav1.SetMetaDataAnnotation(&route.ObjectMeta, LabelKeyHTTPRouteConfigHash, sync.ComputeHash(r.config()))
+
+	if err := r.runtimeClient.Create(ctx, &route); err != nil {
+		return fmt.Errorf("creating httproute: %w", err)
+	}
+
+	return nil
+}
+
+func (r *httpRouteRouter) updateConfig(ctx context.Context) (bool, error) {
+	desiredHash := sync.ComputeHash(r.config())
+
+	if r.route.Annotations[LabelKeyHTTPRouteConfigHash] == desiredHash {
+		return false, nil
+	}
+
+	metav1.SetMetaDataAnnotation(&r.route.ObjectMeta, LabelKeyHTTPRouteConfigHash, desiredHash)
+
+	r.route.Spec = r.desiredSpec(r.currentWeights())
+
+	if err := r.runtimeClient.Update(ctx, &r.route); err != nil {
+		return false, fmt.Errorf("updating httproute: %w", err)
+	}
+
+	return true, nil
+}
+
+func (r *httpRouteRouter) update(ctx context.Context, tgs []okrav1alpha1.ForwardTargetGroup) (bool, error) {
+	r.route.Spec = r.desiredSpec(tgs)
+
+	currentHash := r.route.Annotations[LabelKeyTemplateHash]
+	desiredHash := sync.ComputeHash(r.route.Spec)
+
+	if currentHash == desiredHash {
+		return false, nil
+	}
+
+	metav1.SetMetaDataAnnotation(&r.route.ObjectMeta, LabelKeyTemplateHash, desiredHash)
+
+	if err := r.runtimeClient.Update(ctx, &r.route); err != nil {
+		return false, fmt.Errorf("updating httproute: %w", err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,124 @@
+package cell
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	gatewayv1beta1 "github.com/mumoshu/okra/api/gateway/v1beta1"
+	rolloutsv1alpha1 "github.com/mumoshu/okra/api/rollouts/v1alpha1"
+	okrav1alpha1 "github.com/mumoshu/okra/api/v1alpha1"
+	"github.com/mumoshu/okra/pkg/clclient"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newHTTPRouteCell(steps ...rolloutsv1alpha1.CanaryStep) *okrav1alpha1.Cell {
+	return &okrav1alpha1.Cell{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "web",
+		},
+		Spec: okrav1alpha1.CellSpec{
+			Ingress: okrav1alpha1.CellIngress{
+				Type: okrav1alpha1.CellIngressTypeGatewayAPIHTTPRoute,
+				GatewayAPIHTTPRoute: &okrav1alpha1.CellIngressGatewayAPIHTTPRoute{
+					ParentRefs: []gatewayv1beta1.ParentReference{
+						{Name: "gw"},
+					},
+					Port: 80,
+					BackendSelector: okrav1alpha1.ServiceSelector{
+						MatchLabels: map[string]string{"role": "web"},
+					},
+				},
+			},
+			UpdateStrategy: okrav1alpha1.CellUpdateStrategy{
+				Type: okrav1alpha1.CellUpdateStrategyTypeCanary,
+				Canary: &okrav1alpha1.CellUpdateStrategyCanary{
+					Steps: steps,
+				},
+			},
+		},
+	}
+}
+
+func newClusterService(name, version string) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      name,
+			Labels: map[string]string{
+				"role":                              "web",
+				okrav1alpha1.DefaultVersionLabelKey: version,
+			},
+		},
+	}
+}
+
+func backendWeights(t *testing.T, c client.Client) map[string]int32 {
+	t.Helper()
+
+	var route gatewayv1beta1.HTTPRoute
+
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "web"}, &route); err != nil {
+		t.Fatalf("getting httproute: %v", err)
+	}
+
+	weights := map[string]int32{}
+	for _, rule := range route.Spec.Rules {
+		for _, ref := range rule.BackendRefs {
+			weights[string(ref.Name)] = *ref.Weight
+		}
+	}
+
+	return weights
+}
+
+func syncCell(t *testing.T, c client.Client, scheme *runtime.Scheme, cell *okrav1alpha1.Cell) {
+	t.Helper()
+
+	if err := Sync(SyncInput{Cell: cell, Client: c, Scheme: scheme}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestSyncGatewayAPIHTTPRoute(t *testing.T) {
+	scheme := clclient.Scheme()
+
+	cell := newHTTPRouteCell(
+		rolloutsv1alpha1.CanaryStep{SetWeight: pointer.Int32Ptr(20)},
+		rolloutsv1alpha1.CanaryStep{Pause: &rolloutsv1alpha1.RolloutPause{}},
+	)
+
+	c := fake.NewFakeClientWithScheme(scheme, cell, newClusterService("web-1", "1.0.0"))
+
+	syncCell(t, c, scheme, cell)
+
+	if d := cmp.Diff(map[string]int32{"web-1": 100}, backendWeights(t, c)); d != "" {
+		t.Fatalf("unexpected weights after creation: %s", d)
+	}
+
+	if err := c.Create(context.Background(), newClusterService("web-2", "2.0.0")); err != nil {
+		t.Fatal(err)
+	}
+
+	syncCell(t, c, scheme, cell)
+
+	if d := cmp.Diff(map[string]int32{"web-1": 80, "web-2": 20}, backendWeights(t, c)); d != "" {
+		t.Fatalf("unexpected weights after the first canary step: %s", d)
+	}
+
+	var pauses okrav1alpha1.PauseList
+	if err := c.List(context.Background(), &pauses); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pauses.Items) != 1 {
+		t.Fatalf("expected the pause step to create a pause, got %d pauses", len(pauses.Items))
+	}
+}
@@ -0,0 +1,411 @@
+package cell
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	okrav1alpha1 "github.com/mumoshu/okra/api/v1alpha1"
+	"github.com/mumoshu/okra/pkg/sync"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	LabelKeyIngressNginxConfigHash = "ingress-nginx-config-hash"
+
+	// AnnotationKeyManagedAnnotations lists the keys of the annotations set from the cell's ingressNginx.annotations,
+	// so that the ones removed from the cell are removed from the Ingresses too.
+	AnnotationKeyManagedAnnotations = "okra.mumo.co/managed-annotations"
+
+	AnnotationKeyNginxCanary                = "nginx.ingress.kubernetes.io/canary"
+	AnnotationKeyNginxCanaryWeight          = "nginx.ingress.kubernetes.io/canary-weight"
+	AnnotationKeyNginxCanaryByHeader        = "nginx.ingress.kubernetes.io/canary-by-header"
+	AnnotationKeyNginxCanaryByHeaderValue   = "nginx.ingress.kubernetes.io/canary-by-header-value"
+	AnnotationKeyNginxCanaryByHeaderPattern = "nginx.ingress.kubernetes.io/canary-by-header-pattern"
+	AnnotationKeyNginxCanaryByCookie        = "nginx.ingress.kubernetes.io/canary-by-cookie"
+)
+
+// ingressNginxRouter routes traffic to Kubernetes services via a pair of Ingresses
+// managed by ingress-nginx. The stable Ingress is named after the cell and the canary Ingress is
+// named `<cell>-canary`.
+type ingressNginxRouter struct {
+	cell          okrav1alpha1.Cell
+	runtimeClient client.Client
+	scheme        *runtime.Scheme
+
+	stable       networkingv1.Ingress
+	canary       networkingv1.Ingress
+	canaryExists bool
+}
+
+// ingressNginxState is the set of the stable and the canary backends and the canary weight
+// that is represented by the pair of Ingresses.
+type ingressNginxState struct {
+	Stable string
+	Canary string
+	Weight int
+}
+
+func (r *ingressNginxRouter) config() okrav1alpha1.CellIngressIngressNginx {
+	return *r.cell.Spec.Ingress.IngressNginx
+}
+
+func (r *ingressNginxRouter) canaryName() string {
+	return r.cell.Name + "-canary"
+}
+
+func (r *ingressNginxRouter) versionLabelKeys() []string {
+	return defaultVersionLabelKeys(r.config().BackendSelector.VersionLabels)
+}
+
+func (r *ingressNginxRouter) listBackends(ctx context.Context) ([]backend, error) {
+	var services corev1.ServiceList
+
+	if err := r.runtimeClient.List(ctx, &services, client.InNamespace(r.cell.Namespace), client.MatchingLabels(r.config().BackendSelector.MatchLabels)); err != nil {
+		return nil, fmt.Errorf("listing services: %w", err)
+	}
+
+	var backends []backend
+
+	for _, svc := range services.Items {
+		backends = append(backends, backend{
+			Name:     svc.Name,
+			Labels:   svc.Labels,
+			Deleting: !svc.DeletionTimestamp.IsZero(),
+		})
+	}
+
+	return backends, nil
+}
+
+func (r *ingressNginxRouter) get(ctx context.Context) ([]okrav1alpha1.ForwardTargetGroup, bool, error) {
+	if err := r.runtimeClient.Get(ctx, types.NamespacedName{Namespace: r.cell.Namespace, Name: r.cell.Name}, &r.stable); err != nil {
+		if !kerrors.IsNotFound(err) {
+			return nil, false, err
+		}
+
+		return nil, false, nil
+	}
+
+	if err := r.runtimeClient.Get(ctx, types.NamespacedName{Namespace: r.cell.Namespace, Name: r.canaryName()}, &r.canary); err != nil {
+		if !kerrors.IsNotFound(err) {
+			return nil, false, err
+		}
+	} else {
+		r.canaryExists = true
+	}
+
+	state, err := r.currentState()
+	if err != nil {
+		return nil, false, err
+	}
+
+	tgs := []okrav1alpha1.ForwardTargetGroup{
+		{Name: state.Stable, Weight: 100 - state.Weight},
+	}
+
+	if state.Canary != "" {
+		tgs = append(tgs, okrav1alpha1.ForwardTargetGroup{Name: state.Canary, Weight: state.Weight})
+	}
+
+	return tgs, true, nil
+}
+
+func ingressServiceName(ing networkingv1.Ingress) string {
+	for _, rule := range ing.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+
+		for _, p := range rule.HTTP.Paths {
+			if p.Backend.Service != nil {
+				return p.Backend.Service.Name
+			}
+		}
+	}
+
+	return ""
+}
+
+func (r *ingressNginxRouter) currentState() (*ingressNginxState, error) {
+	state := &ingressNginxState{
+		Stable: ingressServiceName(r.stable),
+	}
+
+	if !r.canaryExists {
+		return state, nil
+	}
+
+	canary := ingressServiceName(r.canary)
+	if canary == "" || canary == state.Stable {
+		return state, nil
+	}
+
+	var weight int
+
+	if v := r.canary.Annotations[AnnotationKeyNginxCanaryWeight]; v != "" {
+		w, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("parsing annotation %s of ingress %s: %w", AnnotationKeyNginxCanaryWeight, r.canary.Name, err)
+		}
+
+		weight = w
+	}
+
+	state.Canary = canary
+	state.Weight = weight
+
+	return state, nil
+}
+
+// desiredState maps the weighted backends to the stable and the canary backends.
+// The current stable backend is kept stable as long as it has non-zero weight.
+func (r *ingressNginxRouter) desiredState(tgs []okrav1alpha1.ForwardTargetGroup) (*ingressNginxState, error) {
+	var weighted []okrav1alpha1.ForwardTargetGroup
+
+	for _, tg := range tgs {
+		if tg.Weight > 0 {
+			weighted = append(weighted, tg)
+		}
+	}
+
+	switch len(weighted) {
+	case 0:
+		return nil, fmt.Errorf("no service with non-zero weight")
+	case 1:
+		return &ingressNginxState{Stable: weighted[0].Name}, nil
+	case 2:
+		stable, canary := weighted[0], weighted[1]
+
+		current := ingressServiceName(r.stable)
+		if canary.Name == current || (stable.Name != current && canary.Weight > stable.Weight) {
+			stable, canary = canary, stable
+		}
+
+		return &ingressNginxState{Stable: stable.Name, Canary: canary.Name, Weight: canary.Weight}, nil
+	default:
+		return nil, fmt.Errorf("ingress-nginx supports routing to at most 2 services at once, but got %d services with non-zero weight", len(weighted))
+	}
+}
+
+func (r *ingressNginxRouter) ingressSpec(svc string) networkingv1.IngressSpec {
+	config := r.config()
+
+	path := config.Path
+	if path == "" {
+		path = "/"
+	}
+
+	pathType := networkingv1.PathTypePrefix
+	if config.PathType != nil {
+		pathType = *config.PathType
+	}
+
+	return networkingv1.IngressSpec{
+		IngressClassName: config.IngressClassName,
+		TLS:              config.TLS,
+		Rules: []networkingv1.IngressRule{
+			{
+				Host: config.Host,
+				IngressRuleValue: networkingv1.IngressRuleValue{
+					HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{
+							{
+								Path:     path,
+								PathType: &pathType,
+								Backend: networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{
+										Name: svc,
+										Port: networkingv1.ServiceBackendPort{Number: config.Port},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (r *ingressNginxRouter) canaryAnnotations(weight int) map[string]string {
+	config := r.config()
+
+	annotations := map[string]string{
+		AnnotationKeyNginxCanary:       "true",
+		AnnotationKeyNginxCanaryWeight: strconv.Itoa(weight),
+	}
+
+	for k, v := range map[string]string{
+		AnnotationKeyNginxCanaryByHeader:        config.CanaryByHeader,
+		AnnotationKeyNginxCanaryByHeaderValue:   config.CanaryByHeaderValue,
+		AnnotationKeyNginxCanaryByHeaderPattern: config.CanaryByHeaderPattern,
+		AnnotationKeyNginxCanaryByCookie:        config.CanaryByCookie,
+	} {
+		if v != "" {
+			annotations[k] = v
+		}
+	}
+
+	return annotations
+}
+
+// render updates the pair of Ingresses in-place to represent the state.
+func (r *ingressNginxRouter) render(state ingressNginxState) {
+	r.stable.Namespace = r.cell.Namespace
+	r.stable.Name = r.cell.Name
+	r.stable.Spec = r.ingressSpec(state.Stable)
+
+	// The canary Ingress points to the stable service while there's no canary,
+	// so that the canary Ingress can be kept around.
+	canary := state.Canary
+	if canary == "" {
+		canary = state.Stable
+	}
+
+	r.canary.Namespace = r.cell.Namespace
+	r.canary.Name = r.canaryName()
+	r.canary.Spec = r.ingressSpec(canary)
+
+	setManagedAnnotations(&r.stable.ObjectMeta, r.config().Annotations)
+	setManagedAnnotations(&r.canary.ObjectMeta, r.config().Annotations)
+
+	for _, k := range []string{
+		AnnotationKeyNginxCanaryByHeader,
+		AnnotationKeyNginxCanaryByHeaderValue,
+		AnnotationKeyNginxCanaryByHeaderPattern,
+		AnnotationKeyNginxCanaryByCookie,
+	} {
+		delete(r.canary.Annotations, k)
+	}
+
+	for k, v := range r.canaryAnnotations(state.Weight) {
+		metav1.SetMetaDataAnnotation(&r.canary.ObjectMeta, k, v)
+	}
+
+	ctrl.SetControllerReference(&r.cell, &r.stable, r.scheme)
+	ctrl.SetControllerReference(&r.cell, &r.canary, r.scheme)
+}
+
+// setManagedAnnotations sets the annotations and removes the ones set by the previous call but no longer in the annotations.
+func setManagedAnnotations(meta *metav1.ObjectMeta, annotations map[string]string) {
+	if prev := meta.Annotations[AnnotationKeyManagedAnnotations]; prev != "" {
+		for _, k := range strings.Split(prev, ",") {
+			delete(meta.Annotations, k)
+		}
+	}
+
+	delete(meta.Annotations, AnnotationKeyManagedAnnotations)
+
+	var keys []string
+
+	for k, v := range annotations {
+		metav1.SetMetaDataAnnotation(meta, k, v)
+
+		keys = append(keys, k)
+	}
+
+	if len(keys) > 0 {
+		sort.Strings(keys)
+
+		metav1.SetMetaDataAnnotation(meta, AnnotationKeyManagedAnnotations, strings.Join(keys, ","))
+	}
+}
+
+func (r *ingressNginxRouter) apply(ctx context.Context) error {
+	if r.canaryExists {
+		if err := r.runtimeClient.Update(ctx, &r.canary); err != nil {
+			return fmt.Errorf("updating canary ingress: %w", err)
+		}
+	} else {
+		if err := r.runtimeClient.Create(ctx, &r.canary); err != nil {
+			return fmt.Errorf("creating canary ingress: %w", err)
+		}
+
+		r.canaryExists = true
+	}
+
+	if err := r.runtimeClient.Update(ctx, &r.stable); err != nil {
+		return fmt.Errorf("updating ingress: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ingressNginxRouter) create(ctx context.Context, tgs []okrav1alpha1.ForwardTargetGroup) error {
+	state, err := r.desiredState(tgs)
+	if err != nil {
+		return err
+	}
+
+	r.render(*state)
+
+	metav1.SetMetaDataAnnotation(&r.stable.ObjectMeta, LabelKeyIngressNginxConfigHash, sync.ComputeHash(r.config()))
+
+	if err := r.runtimeClient.Create(ctx, &r.stable); err != nil {
+		return fmt.Errorf("creating ingress: %w", err)
+	}
+
+	if err := r.runtimeClient.Create(ctx, &r.canary); err != nil {
+		return fmt.Errorf("creating canary ingress: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ingressNginxRouter) updateConfig(ctx context.Context) (bool, error) {
+	desiredHash := sync.ComputeHash(r.config())
+
+	if r.stable.Annotations[LabelKeyIngressNginxConfigHash] == desiredHash {
+		return false, nil
+	}
+
+	// Keep the current backends and weight while updating everything else
+	state, err := r.currentState()
+	if err != nil {
+		return false, err
+	}
+
+	r.render(*state)
+
+	metav1.SetMetaDataAnnotation(&r.stable.ObjectMeta, LabelKeyIngressNginxConfigHash, desiredHash)
+
+	if err := r.apply(ctx); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *ingressNginxRouter) update(ctx context.Context, tgs []okrav1alpha1.ForwardTargetGroup) (bool, error) {
+	state, err := r.desiredState(tgs)
+	if err != nil {
+		return false, err
+	}
+
+	currentHash := r.stable.Annotations[LabelKeyTemplateHash]
+	desiredHash := sync.ComputeHash(state)
+
+	if r.canaryExists && currentHash == desiredHash {
+		return false, nil
+	}
+
+	r.render(*state)
+
+	metav1.SetMetaDataAnnotation(&r.stable.ObjectMeta, LabelKeyTemplateHash, desiredHash)
+
+	if err := r.apply(ctx); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,205 @@
+package cell
+
+import (
+	"context"
+	"testing"
+
+	rolloutsv1alpha1 "github.com/mumoshu/okra/api/rollouts/v1alpha1"
+	okrav1alpha1 "github.com/mumoshu/okra/api/v1alpha1"
+	"github.com/mumoshu/okra/pkg/clclient"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newIngressNginxCell(steps ...rolloutsv1alpha1.CanaryStep) *okrav1alpha1.Cell {
+	return &okrav1alpha1.Cell{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "web",
+		},
+		Spec: okrav1alpha1.CellSpec{
+			Ingress: okrav1alpha1.CellIngress{
+				Type: okrav1alpha1.CellIngressTypeIngressNginx,
+				IngressNginx: &okrav1alpha1.CellIngressIngressNginx{
+					Host:           "web.example.com",
+					Port:           80,
+					CanaryByHeader: "X-Canary",
+					BackendSelector: okrav1alpha1.ServiceSelector{
+						MatchLabels: map[string]string{"role": "web"},
+					},
+				},
+			},
+			UpdateStrategy: okrav1alpha1.CellUpdateStrategy{
+				Type: okrav1alpha1.CellUpdateStrategyTypeCanary,
+				Canary: &okrav1alpha1.CellUpdateStrategyCanary{
+					Steps: steps,
+				},
+			},
+		},
+	}
+}
+
+func getIngress(t *testing.T, c client.Client, name string) networkingv1.Ingress {
+	t.Helper()
+
+	var ing networkingv1.Ingress
+
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: name}, &ing); err != nil {
+		t.Fatalf("getting ingress %s: %v", name, err)
+	}
+
+	return ing
+}
+
+func assertIngresses(t *testing.T, c client.Client, stable, canary, weight string) {
+	t.Helper()
+
+	stableIng := getIngress(t, c, "web")
+	if got := ingressServiceName(stableIng); got != stable {
+		t.Errorf("unexpected stable service: want %s, got %s", stable, got)
+	}
+
+	canaryIng := getIngress(t, c, "web-canary")
+	if got := ingressServiceName(canaryIng); got != canary {
+		t.Errorf("unexpected canary service: want %s, got %s", canary, got)
+	}
+
+	if got := canaryIng.Annotations[AnnotationKeyNginxCanaryWeight]; got != weight {
+		t.Errorf("unexpected canary weight: want %s, got %s", weight, got)
+	}
+
+	if got := canaryIng.Annotations[AnnotationKeyNginxCanaryByHeader]; got != "X-Canary" {
+		t.Errorf("unexpected canary-by-header: got %s", got)
+	}
+}
+
+func TestSyncIngressNginx(t *testing.T) {
+	scheme := clclient.Scheme()
+
+	cell := newIngressNginxCell(
+		rolloutsv1alpha1.CanaryStep{SetWeight: pointer.Int32Ptr(20)},
+		rolloutsv1alpha1.CanaryStep{Pause: &rolloutsv1alpha1.RolloutPause{}},
+	)
+
+	c := fake.NewFakeClientWithScheme(scheme, cell, newClusterService("web-1", "1.0.0"))
+
+	syncCell(t, c, scheme, cell)
+
+	assertIngresses(t, c, "web-1", "web-1", "0")
+
+	if err := c.Create(context.Background(), newClusterService("web-2", "2.0.0")); err != nil {
+		t.Fatal(err)
+	}
+
+	syncCell(t, c, scheme, cell)
+
+	assertIngresses(t, c, "web-1", "web-2", "20")
+}
+
+func TestSyncIngressNginxRemovesAnnotations(t *testing.T) {
+	scheme := clclient.Scheme()
+
+	cell := newIngressNginxCell()
+	cell.Spec.Ingress.IngressNginx.Annotations = map[string]string{
+		"nginx.ingress.kubernetes.io/proxy-body-size": "8m",
+		"nginx.ingress.kubernetes.io/ssl-redirect":    "false",
+	}
+
+	c := fake.NewFakeClientWithScheme(scheme, cell, newClusterService("web-1", "1.0.0"))
+
+	syncCell(t, c, scheme, cell)
+
+	// An annotation added by others is kept
+	stable := getIngress(t, c, "web")
+	metav1.SetMetaDataAnnotation(&stable.ObjectMeta, "example.com/owner", "team-a")
+
+	if err := c.Update(context.Background(), &stable); err != nil {
+		t.Fatal(err)
+	}
+
+	delete(cell.Spec.Ingress.IngressNginx.Annotations, "nginx.ingress.kubernetes.io/ssl-redirect")
+
+	syncCell(t, c, scheme, cell)
+
+	for _, name := range []string{"web", "web-canary"} {
+		ing := getIngress(t, c, name)
+
+		if _, ok := ing.Annotations["nginx.ingress.kubernetes.io/ssl-redirect"]; ok {
+			t.Errorf("expected the removed annotation to be removed from ingress %s", name)
+		}
+
+		if got := ing.Annotations["nginx.ingress.kubernetes.io/proxy-body-size"]; got != "8m" {
+			t.Errorf("unexpected proxy-body-size on ingress %s: %q", name, got)
+		}
+
+		if got := ing.Annotations[AnnotationKeyManagedAnnotations]; got != "nginx.ingress.kubernetes.io/proxy-body-size" {
+			t.Errorf("unexpected managed annotations on ingress %s: %q", name, got)
+		}
+	}
+
+	if got := getIngress(t, c, "web").Annotations["example.com/owner"]; got != "team-a" {
+		t.Errorf("expected the annotation added by others to be kept, got %q", got)
+	}
+}
+
+func TestIngressNginxDesiredState(t *testing.T) {
+	r := &ingressNginxRouter{}
+	r.stable.Spec.Rules = []networkingv1.IngressRule{
+		{
+			IngressRuleValue: networkingv1.IngressRuleValue{
+				HTTP: &networkingv1.HTTPIngressRuleValue{
+					Paths: []networkingv1.HTTPIngressPath{
+						{Backend: networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{Name: "web-1"}}},
+					},
+				},
+			},
+		},
+	}
+
+	testcases := []struct {
+		name string
+		tgs  []okrav1alpha1.ForwardTargetGroup
+		want ingressNginxState
+		err  bool
+	}{
+		{
+			name: "current stable keeps being stable",
+			tgs:  []okrav1alpha1.ForwardTargetGroup{{Name: "web-2", Weight: 60}, {Name: "web-1", Weight: 40}},
+			want: ingressNginxState{Stable: "web-1", Canary: "web-2", Weight: 60},
+		},
+		{
+			name: "promotion",
+			tgs:  []okrav1alpha1.ForwardTargetGroup{{Name: "web-1", Weight: 0}, {Name: "web-2", Weight: 100}},
+			want: ingressNginxState{Stable: "web-2"},
+		},
+		{
+			name: "too many services",
+			tgs:  []okrav1alpha1.ForwardTargetGroup{{Name: "web-1", Weight: 50}, {Name: "web-2", Weight: 25}, {Name: "web-3", Weight: 25}},
+			err:  true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := r.desiredState(tc.tgs)
+			if tc.err {
+				if err == nil {
+					t.Fatal("expected error, got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if *got != tc.want {
+				t.Errorf("want %+v, got %+v", tc.want, *got)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package cell
+
+import (
+	"context"
+	"log"
+	"sort"
+
+	"github.com/blang/semver"
+	okrav1alpha1 "github.com/mumoshu/okra/api/v1alpha1"
+)
+
+// replacement is a replacement of target groups of the current stable version, like when hot-swapping a cluster.
+type replacement struct {
+	// desired are the target groups of the current stable version, weighted evenly
+	desired map[string]okrav1alpha1.ForwardTargetGroup
+	// added are the names of the desired target groups that are yet to receive their share
+	added map[string]struct{}
+	// replaced are the target groups that still receive traffic but are no longer selected by the cell or are being deleted
+	replaced []okrav1alpha1.ForwardTargetGroup
+}
+
+// detectReplacement returns the replacement when one or more target groups that receive traffic are no longer selected by the cell,
+// usually because they are being deleted and held by the TrafficGuard until drained,
+// while the others are of the current stable version and at least one of them already receives its share.
+// When none of them receives its share yet, like when hot-swapping the only cluster, it is a replacement only when
+// all the replaced target groups are known to be of the current stable version.
+// It returns nil otherwise, including when the current target groups are of another version, which is a canary release or a rollback.
+func detectReplacement(currentTGs []okrav1alpha1.ForwardTargetGroup, desiredTGsByName map[string]okrav1alpha1.ForwardTargetGroup, knownVersions map[string]string, stableVersion string, desiredVer semver.Version) *replacement {
+	r := &replacement{
+		desired: desiredTGsByName,
+		added:   map[string]struct{}{},
+	}
+
+	currentWeights := map[string]int{}
+
+	allStable := true
+
+	for _, tg := range currentTGs {
+		if _, ok := desiredTGsByName[tg.Name]; ok {
+			currentWeights[tg.Name] = tg.Weight
+			continue
+		}
+
+		if tg.Weight == 0 {
+			continue
+		}
+
+		// The replaced target group may already be gone along with its cluster, so its version can be unknown.
+		// It is of the stable version recorded in the previous syncs, as every target group added since then was of the version.
+		ver, ok := knownVersions[tg.Name]
+		if !ok {
+			ver = stableVersion
+		}
+
+		if ver == "" {
+			allStable = false
+		} else if v, err := semver.Parse(ver); err != nil || !v.EQ(desiredVer) {
+			return nil
+		}
+
+		r.replaced = append(r.replaced, tg)
+	}
+
+	if len(r.replaced) == 0 {
+		return nil
+	}
+
+	var numKept int
+
+	for name, tg := range desiredTGsByName {
+		// Allow the difference of 1 as the remainder of the even distribution moves when the set of target groups changes
+		if currentWeights[name] >= tg.Weight-1 {
+			numKept++
+		} else {
+			r.added[name] = struct{}{}
+		}
+	}
+
+	if numKept == 0 && !allStable {
+		return nil
+	}
+
+	sort.Slice(r.replaced, func(i, j int) bool {
+		return r.replaced[i].Name < r.replaced[j].Name
+	})
+
+	return r
+}
+
+// weights returns the target groups and their weights after moving the percentage of the share of the replaced target groups
+// to the added ones.
+func (r *replacement) weights(percentage int) []okrav1alpha1.ForwardTargetGroup {
+	if percentage > 100 {
+		percentage = 100
+	} else if percentage < 0 {
+		percentage = 0
+	}
+
+	var (
+		tgs   []okrav1alpha1.ForwardTargetGroup
+		total int
+	)
+
+	for name, tg := range r.desired {
+		if _, ok := r.added[name]; ok {
+			tg.Weight = tg.Weight * percentage / 100
+		}
+
+		total += tg.Weight
+
+		tgs = append(tgs, tg)
+	}
+
+	for _, tg := range redistributeWeights(100-total, r.replaced) {
+		tgs = append(tgs, tg)
+	}
+
+	sort.Slice(tgs, func(i, j int) bool {
+		return tgs[i].Name < tgs[j].Name
+	})
+
+	return tgs
+}
+
+// sync runs the replacement steps and updates the weights accordingly.
+// The replaced target groups are drained once all the steps passed.
+// When any step failed, the current weights are held until the set of target groups changes.
+func (r *replacement) sync(ctx context.Context, ccr cellComponentReconciler, router trafficRouter, strategy okrav1alpha1.CanaryReplacement) error {
+	if err := ccr.deleteOutdatedComponents(ctx); err != nil {
+		return err
+	}
+
+	percentage := 100
+
+	if len(strategy.Steps) > 0 {
+		result, err := ccr.runCanarySteps(ctx, router, "replacement-", strategy.Steps, strategy.Analysis, 100)
+		if err != nil {
+			return err
+		}
+
+		if result.failed {
+			log.Printf("Replacement failed. Holding the current weights")
+
+			return nil
+		}
+
+		if !result.passedAll {
+			percentage = result.weight
+		}
+	}
+
+	tgs := r.weights(percentage)
+
+	updated, err := router.update(ctx, tgs)
+	if err != nil {
+		return err
+	}
+
+	if updated {
+		weights := make(map[string]int)
+		for _, tg := range tgs {
+			weights[tg.Name] = tg.Weight
+		}
+
+		log.Printf("Updated target groups and weights to: %v", weights)
+	}
+
+	if percentage == 100 {
+		log.Printf("Finished replacement. Drained the replaced target groups")
+	}
+
+	return nil
+}
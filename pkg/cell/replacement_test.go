@@ -0,0 +1,191 @@
+package cell
+
+import (
+	"context"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/google/go-cmp/cmp"
+	rolloutsv1alpha1 "github.com/mumoshu/okra/api/rollouts/v1alpha1"
+	okrav1alpha1 "github.com/mumoshu/okra/api/v1alpha1"
+	"github.com/mumoshu/okra/pkg/clclient"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSyncReplacement(t *testing.T) {
+	scheme := clclient.Scheme()
+
+	cell := newHTTPRouteCell()
+	cell.Spec.Replicas = pointer.Int32Ptr(2)
+	cell.Spec.UpdateStrategy.Canary.Replacement = &okrav1alpha1.CanaryReplacement{
+		Steps: []rolloutsv1alpha1.CanaryStep{
+			{SetWeight: pointer.Int32Ptr(20)},
+			{Pause: &rolloutsv1alpha1.RolloutPause{}},
+		},
+	}
+
+	web2 := newClusterService("web-2", "1.0.0")
+
+	c := fake.NewFakeClientWithScheme(scheme, cell, newClusterService("web-1", "1.0.0"), web2)
+
+	syncCell(t, c, scheme, cell)
+
+	if d := cmp.Diff(map[string]int32{"web-1": 50, "web-2": 50}, backendWeights(t, c)); d != "" {
+		t.Fatalf("unexpected weights after creation: %s", d)
+	}
+
+	// Hot-swap web-2 with web-3 of the same version.
+	// web-3 is added while web-2 still exists, and then web-2 is deleted but held by a finalizer until it is drained.
+	if err := c.Create(context.Background(), newClusterService("web-3", "1.0.0")); err != nil {
+		t.Fatal(err)
+	}
+
+	syncCell(t, c, scheme, cell)
+
+	if d := cmp.Diff(map[string]int32{"web-1": 50, "web-2": 50}, backendWeights(t, c)); d != "" {
+		t.Fatalf("unexpected weights before web-2 is deleted: %s", d)
+	}
+
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "web-2"}, web2); err != nil {
+		t.Fatal(err)
+	}
+
+	now := metav1.Now()
+	web2.DeletionTimestamp = &now
+	web2.Finalizers = []string{okrav1alpha1.FinalizerTrafficGuard}
+
+	if err := c.Update(context.Background(), web2); err != nil {
+		t.Fatal(err)
+	}
+
+	syncCell(t, c, scheme, cell)
+
+	if d := cmp.Diff(map[string]int32{"web-1": 50, "web-2": 40, "web-3": 10}, backendWeights(t, c)); d != "" {
+		t.Fatalf("unexpected weights after the first replacement step: %s", d)
+	}
+
+	var pauses okrav1alpha1.PauseList
+	if err := c.List(context.Background(), &pauses); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pauses.Items) != 1 || pauses.Items[0].Labels[LabelKeyStepIndex] != "replacement-1" {
+		t.Fatalf("expected the pause step to create a replacement pause, got %v", pauses.Items)
+	}
+
+	// Passing the pause step drains the replaced backend
+	pause := pauses.Items[0]
+	pause.Status.Phase = okrav1alpha1.PausePhaseExpired
+
+	if err := c.Update(context.Background(), &pause); err != nil {
+		t.Fatal(err)
+	}
+
+	syncCell(t, c, scheme, cell)
+
+	if d := cmp.Diff(map[string]int32{"web-1": 50, "web-2": 0, "web-3": 50}, backendWeights(t, c)); d != "" {
+		t.Fatalf("unexpected weights after the replacement: %s", d)
+	}
+}
+
+func TestSyncReplacementOfOnlyCluster(t *testing.T) {
+	scheme := clclient.Scheme()
+
+	cell := newHTTPRouteCell()
+	cell.Spec.UpdateStrategy.Canary.Replacement = &okrav1alpha1.CanaryReplacement{
+		Steps: []rolloutsv1alpha1.CanaryStep{
+			{SetWeight: pointer.Int32Ptr(20)},
+			{Analysis: &rolloutsv1alpha1.RolloutAnalysis{
+				Templates: []rolloutsv1alpha1.RolloutAnalysisTemplate{{TemplateName: "success-rate"}},
+			}},
+			{SetWeight: pointer.Int32Ptr(80)},
+		},
+	}
+
+	web1 := newClusterService("web-1", "1.0.0")
+
+	template := &rolloutsv1alpha1.AnalysisTemplate{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "success-rate"},
+	}
+
+	c := fake.NewFakeClientWithScheme(scheme, cell, web1, template)
+
+	syncCell(t, c, scheme, cell)
+	syncCell(t, c, scheme, cell)
+
+	if cell.Status.StableVersion != "1.0.0" {
+		t.Fatalf("unexpected stable version: %q", cell.Status.StableVersion)
+	}
+
+	// Hot-swap the only cluster web-1 with web-2 of the same version
+	if err := c.Delete(context.Background(), web1); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Create(context.Background(), newClusterService("web-2", "1.0.0")); err != nil {
+		t.Fatal(err)
+	}
+
+	syncCell(t, c, scheme, cell)
+
+	if d := cmp.Diff(map[string]int32{"web-1": 80, "web-2": 20}, backendWeights(t, c)); d != "" {
+		t.Fatalf("unexpected weights after the first replacement step: %s", d)
+	}
+
+	syncCell(t, c, scheme, cell)
+
+	var runs rolloutsv1alpha1.AnalysisRunList
+	if err := c.List(context.Background(), &runs); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(runs.Items) != 1 {
+		t.Fatalf("expected the analysis step to create an analysisrun, got %v", runs.Items)
+	}
+
+	// A failed analysis holds the current weights
+	run := runs.Items[0]
+	run.Status.Phase = rolloutsv1alpha1.AnalysisPhaseFailed
+
+	if err := c.Update(context.Background(), &run); err != nil {
+		t.Fatal(err)
+	}
+
+	syncCell(t, c, scheme, cell)
+
+	if d := cmp.Diff(map[string]int32{"web-1": 80, "web-2": 20}, backendWeights(t, c)); d != "" {
+		t.Fatalf("unexpected weights after the failed analysis: %s", d)
+	}
+}
+
+func TestDetectReplacementWithoutKeptTargetGroups(t *testing.T) {
+	desiredVer := semver.MustParse("1.0.0")
+
+	current := []okrav1alpha1.ForwardTargetGroup{{Name: "web-1", Weight: 100}}
+	desired := map[string]okrav1alpha1.ForwardTargetGroup{"web-2": {Name: "web-2", Weight: 100}}
+
+	testcases := []struct {
+		name          string
+		knownVersions map[string]string
+		stableVersion string
+		want          bool
+	}{
+		{name: "known stable version", knownVersions: map[string]string{"web-1": "1.0.0"}, want: true},
+		{name: "recorded stable version", stableVersion: "1.0.0", want: true},
+		{name: "unknown version"},
+		{name: "canary release", stableVersion: "0.9.0"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := detectReplacement(current, desired, tc.knownVersions, tc.stableVersion, desiredVer)
+
+			if got := r != nil; got != tc.want {
+				t.Errorf("expected replacement detected to be %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
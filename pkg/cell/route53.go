@@ -0,0 +1,221 @@
+package cell
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	okrav1alpha1 "github.com/mumoshu/okra/api/v1alpha1"
+	"github.com/mumoshu/okra/pkg/awsroute53"
+	"github.com/mumoshu/okra/pkg/sync"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const LabelKeyRoute53ConfigHash = "route53-config-hash"
+
+// route53Router routes traffic to ClusterEndpoints via Route53 weighted record sets,
+// managed via an AWSRoute53WeightedRecordConfig that is named after the cell.
+type route53Router struct {
+	cell          okrav1alpha1.Cell
+	runtimeClient client.Client
+	scheme        *runtime.Scheme
+
+	recordConfig okrav1alpha1.AWSRoute53WeightedRecordConfig
+
+	// records maps each backend name to the record without weight.
+	// It contains both selected ClusterEndpoints and the records that are already in the AWSRoute53WeightedRecordConfig,
+	// so that we can keep routing to a record whose ClusterEndpoint has gone.
+	records map[string]okrav1alpha1.AWSRoute53WeightedRecord
+}
+
+func (r *route53Router) config() okrav1alpha1.CellIngressRoute53WeightedRecord {
+	return *r.cell.Spec.Ingress.Route53WeightedRecord
+}
+
+func (r *route53Router) versionLabelKeys() []string {
+	return defaultVersionLabelKeys(r.config().EndpointSelector.VersionLabels)
+}
+
+// minStepDuration returns the duration to wait after each setWeight step.
+// DNS resolvers may cache the previous records until the TTL expires,
+// so proceeding to the next step before that makes the analysis meaningless.
+func (r *route53Router) minStepDuration() time.Duration {
+	config := r.config()
+
+	if config.MinStepDuration != nil {
+		return config.MinStepDuration.Duration
+	}
+
+	ttl := config.TTL
+	if ttl == 0 {
+		ttl = awsroute53.DefaultTTL
+	}
+
+	return time.Duration(ttl) * time.Second
+}
+
+func (r *route53Router) listBackends(ctx context.Context) ([]backend, error) {
+	var endpoints okrav1alpha1.ClusterEndpointList
+
+	if err := r.runtimeClient.List(ctx, &endpoints, client.InNamespace(r.cell.Namespace), client.MatchingLabels(r.config().EndpointSelector.MatchLabels)); err != nil {
+		return nil, fmt.Errorf("listing clusterendpoints: %w", err)
+	}
+
+	r.records = map[string]okrav1alpha1.AWSRoute53WeightedRecord{}
+
+	var backends []backend
+
+	for _, ep := range endpoints.Items {
+		r.records[ep.Name] = okrav1alpha1.AWSRoute53WeightedRecord{
+			SetIdentifier: ep.Name,
+			Value:         ep.Spec.Host,
+			HealthCheckID: ep.Annotations[okrav1alpha1.ClusterEndpointAnnotationRoute53HealthCheckID],
+		}
+
+		backends = append(backends, backend{
+			Name:     ep.Name,
+			Labels:   ep.Labels,
+			Deleting: !ep.DeletionTimestamp.IsZero(),
+		})
+	}
+
+	return backends, nil
+}
+
+func (r *route53Router) get(ctx context.Context) ([]okrav1alpha1.ForwardTargetGroup, bool, error) {
+	key := types.NamespacedName{Namespace: r.cell.Namespace, Name: r.cell.Name}
+
+	if err := r.runtimeClient.Get(ctx, key, &r.recordConfig); err != nil {
+		if !kerrors.IsNotFound(err) {
+			return nil, false, err
+		}
+
+		return nil, false, nil
+	}
+
+	if r.records == nil {
+		r.records = map[string]okrav1alpha1.AWSRoute53WeightedRecord{}
+	}
+
+	for _, rec := range r.recordConfig.Spec.Records {
+		if _, ok := r.records[rec.SetIdentifier]; !ok {
+			known := rec
+			known.Weight = 0
+			r.records[rec.SetIdentifier] = known
+		}
+	}
+
+	return r.currentWeights(), true, nil
+}
+
+func (r *route53Router) currentWeights() []okrav1alpha1.ForwardTargetGroup {
+	var tgs []okrav1alpha1.ForwardTargetGroup
+
+	for _, rec := range r.recordConfig.Spec.Records {
+		tgs = append(tgs, okrav1alpha1.ForwardTargetGroup{
+			Name:   rec.SetIdentifier,
+			Weight: rec.Weight,
+		})
+	}
+
+	return tgs
+}
+
+// desiredSpec returns the spec of the AWSRoute53WeightedRecordConfig with the given records.
+func (r *route53Router) desiredSpec(tgs []okrav1alpha1.ForwardTargetGroup) (*okrav1alpha1.AWSRoute53WeightedRecordConfigSpec, error) {
+	config := r.config()
+
+	var records []okrav1alpha1.AWSRoute53WeightedRecord
+
+	for _, tg := range tgs {
+		rec, ok := r.records[tg.Name]
+		if !ok {
+			return nil, fmt.Errorf("no clusterendpoint found for record %s", tg.Name)
+		}
+
+		rec.Weight = tg.Weight
+
+		records = append(records, rec)
+	}
+
+	return &okrav1alpha1.AWSRoute53WeightedRecordConfigSpec{
+		HostedZoneID: config.HostedZoneID,
+		Name:         config.Name,
+		Type:         config.Type,
+		TTL:          config.TTL,
+		Records:      records,
+	}, nil
+}
+
+func (r *route53Router) create(ctx context.Context, tgs []okrav1alpha1.ForwardTargetGroup) error {
+	spec, err := r.desiredSpec(tgs)
+	if err != nil {
+		return err
+	}
+
+	recordConfig := okrav1alpha1.AWSRoute53WeightedRecordConfig{}
+	recordConfig.Namespace = r.cell.Namespace
+	recordConfig.Name = r.cell.Name
+	recordConfig.Spec = *spec
+	ctrl.SetControllerReference(&r.cell, &recordConfig, r.scheme)
+
+	metav1.SetMetaDataAnnotation(&recordConfig.ObjectMeta, LabelKeyRoute53ConfigHash, sync.ComputeHash(r.config()))
+
+	if err := r.runtimeClient.Create(ctx, &recordConfig); err != nil {
+		return fmt.Errorf("creating awsroute53weightedrecordconfig: %w", err)
+	}
+
+	return nil
+}
+
+func (r *route53Router) updateConfig(ctx context.Context) (bool, error) {
+	desiredHash := sync.ComputeHash(r.config())
+
+	if r.recordConfig.Annotations[LabelKeyRoute53ConfigHash] == desiredHash {
+		return false, nil
+	}
+
+	spec, err := r.desiredSpec(r.currentWeights())
+	if err != nil {
+		return false, err
+	}
+
+	metav1.SetMetaDataAnnotation(&r.recordConfig.ObjectMeta, LabelKeyRoute53ConfigHash, desiredHash)
+
+	r.recordConfig.Spec = *spec
+
+	if err := r.runtimeClient.Update(ctx, &r.recordConfig); err != nil {
+		return false, fmt.Errorf("updating awsroute53weightedrecordconfig: %w", err)
+	}
+
+	return true, nil
+}
+
+func (r *route53Router) update(ctx context.Context, tgs []okrav1alpha1.ForwardTargetGroup) (bool, error) {
+	spec, err := r.desiredSpec(tgs)
+	if err != nil {
+		return false, err
+	}
+
+	r.recordConfig.Spec = *spec
+
+	currentHash := r.recordConfig.Annotations[LabelKeyTemplateHash]
+	desiredHash := sync.ComputeHash(r.recordConfig.Spec)
+
+	if currentHash == desiredHash {
+		return false, nil
+	}
+
+	metav1.SetMetaDataAnnotation(&r.recordConfig.ObjectMeta, LabelKeyTemplateHash, desiredHash)
+
+	if err := r.runtimeClient.Update(ctx, &r.recordConfig); err != nil {
+		return false, fmt.Errorf("updating awsroute53weightedrecordconfig: %w", err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,103 @@
+package cell
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	rolloutsv1alpha1 "github.com/mumoshu/okra/api/rollouts/v1alpha1"
+	okrav1alpha1 "github.com/mumoshu/okra/api/v1alpha1"
+	"github.com/mumoshu/okra/pkg/clclient"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newRoute53Cell(steps ...rolloutsv1alpha1.CanaryStep) *okrav1alpha1.Cell {
+	return &okrav1alpha1.Cell{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "web",
+		},
+		Spec: okrav1alpha1.CellSpec{
+			Ingress: okrav1alpha1.CellIngress{
+				Type: okrav1alpha1.CellIngressTypeRoute53WeightedRecord,
+				Route53WeightedRecord: &okrav1alpha1.CellIngressRoute53WeightedRecord{
+					HostedZoneID: "ZONE1",
+					Name:         "web.example.com",
+					TTL:          30,
+					EndpointSelector: okrav1alpha1.ClusterEndpointSelector{
+						MatchLabels: map[string]string{"role": "web"},
+					},
+				},
+			},
+			UpdateStrategy: okrav1alpha1.CellUpdateStrategy{
+				Type: okrav1alpha1.CellUpdateStrategyTypeCanary,
+				Canary: &okrav1alpha1.CellUpdateStrategyCanary{
+					Steps: steps,
+				},
+			},
+		},
+	}
+}
+
+func recordWeights(t *testing.T, c client.Client) map[string]int {
+	t.Helper()
+
+	var config okrav1alpha1.AWSRoute53WeightedRecordConfig
+
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "web"}, &config); err != nil {
+		t.Fatalf("getting awsroute53weightedrecordconfig: %v", err)
+	}
+
+	weights := map[string]int{}
+	for _, r := range config.Spec.Records {
+		weights[r.SetIdentifier] = r.Weight
+	}
+
+	return weights
+}
+
+func TestSyncRoute53WeightedRecord(t *testing.T) {
+	scheme := clclient.Scheme()
+
+	cell := newRoute53Cell(
+		rolloutsv1alpha1.CanaryStep{SetWeight: pointer.Int32Ptr(20)},
+		rolloutsv1alpha1.CanaryStep{SetWeight: pointer.Int32Ptr(50)},
+	)
+
+	c := fake.NewFakeClientWithScheme(scheme, cell, newClusterEndpoint("web-1", "", "1.0.0"))
+
+	syncCell(t, c, scheme, cell)
+
+	if d := cmp.Diff(map[string]int{"web-1": 100}, recordWeights(t, c)); d != "" {
+		t.Fatalf("unexpected weights after creation: %s", d)
+	}
+
+	if err := c.Create(context.Background(), newClusterEndpoint("web-2", "", "2.0.0")); err != nil {
+		t.Fatal(err)
+	}
+
+	syncCell(t, c, scheme, cell)
+
+	// The second step must not be applied until the TTL passes
+	if d := cmp.Diff(map[string]int{"web-1": 80, "web-2": 20}, recordWeights(t, c)); d != "" {
+		t.Fatalf("unexpected weights after the first canary step: %s", d)
+	}
+
+	var pauses okrav1alpha1.PauseList
+	if err := c.List(context.Background(), &pauses); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pauses.Items) != 1 {
+		t.Fatalf("expected the setWeight step to create a pause for the TTL, got %d pauses", len(pauses.Items))
+	}
+
+	if got := pauses.Items[0].Spec.ExpireTime.Sub(time.Now()); got <= 0 || got > 30*time.Second {
+		t.Errorf("unexpected pause duration: %v", got)
+	}
+}
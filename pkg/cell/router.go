@@ -0,0 +1,189 @@
+package cell
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/blang/semver"
+	okrav1alpha1 "github.com/mumoshu/okra/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// trafficRouter is the loadbalancer-specific part of a cell.
+//
+// Sync computes the desired weights of backends in a loadbalancer-agnostic way,
+// and delegates discovering backends and reading and writing actual weights to a trafficRouter.
+type trafficRouter interface {
+	// versionLabelKeys returns the keys of labels whose values are used as backend versions.
+	versionLabelKeys() []string
+
+	// listBackends returns all the backends selected by the cell.
+	listBackends(ctx context.Context) ([]backend, error)
+
+	// get returns the backends and their weights that are currently registered to the loadbalancer.
+	// The second return value is false when the loadbalancer config is yet to be created.
+	get(ctx context.Context) ([]okrav1alpha1.ForwardTargetGroup, bool, error)
+
+	// create creates the loadbalancer config with the initial set of weighted backends.
+	create(ctx context.Context, tgs []okrav1alpha1.ForwardTargetGroup) error
+
+	// updateConfig updates the loadbalancer config other than backends and weights, if necessary.
+	// It returns true when it updated anything.
+	updateConfig(ctx context.Context) (bool, error)
+
+	// update updates the weighted backends. It returns true when it updated anything.
+	update(ctx context.Context, tgs []okrav1alpha1.ForwardTargetGroup) (bool, error)
+}
+
+// minStepDurationRouter is implemented by a trafficRouter whose weight changes take time to be observed by clients,
+// like DNS-based ones.
+type minStepDurationRouter interface {
+	// minStepDuration returns the minimum duration to wait after each setWeight step.
+	minStepDuration() time.Duration
+}
+
+// backend is a loadbalancer-agnostic representation of a cluster endpoint,
+// like an AWS target group, a Kubernetes service, or a ClusterEndpoint.
+type backend struct {
+	Name   string
+	ARN    string
+	Labels map[string]string
+	// Deleting is true when the object is being deleted but held by a finalizer, like the TrafficGuard's.
+	// Such a backend keeps being known to the router so that it can keep receiving traffic while it is drained,
+	// but it is never selected as a desired backend.
+	Deleting bool
+}
+
+func newTrafficRouter(cell okrav1alpha1.Cell, runtimeClient client.Client, scheme *runtime.Scheme) (trafficRouter, error) {
+	ingress := cell.Spec.Ingress
+
+	switch ingress.Type {
+	case "", okrav1alpha1.CellIngressTypeAWSApplicationLoadBalancer:
+		if ingress.AWSApplicationLoadBalancer == nil {
+			return nil, fmt.Errorf("cell %s/%s: missing ingress.awsApplicationLoadBalancer", cell.Namespace, cell.Name)
+		}
+
+		return &albRouter{cell: cell, runtimeClient: runtimeClient, scheme: scheme}, nil
+	case okrav1alpha1.CellIngressTypeGatewayAPIHTTPRoute:
+		if ingress.GatewayAPIHTTPRoute == nil {
+			return nil, fmt.Errorf("cell %s/%s: missing ingress.gatewayAPIHTTPRoute", cell.Namespace, cell.Name)
+		}
+
+		return &httpRouteRouter{cell: cell, runtimeClient: runtimeClient, scheme: scheme}, nil
+	case okrav1alpha1.CellIngressTypeIstioVirtualService:
+		if ingress.IstioVirtualService == nil {
+			return nil, fmt.Errorf("cell %s/%s: missing ingress.istioVirtualService", cell.Namespace, cell.Name)
+		}
+
+		return &virtualServiceRouter{cell: cell, runtimeClient: runtimeClient, scheme: scheme}, nil
+	case okrav1alpha1.CellIngressTypeIngressNginx:
+		if ingress.IngressNginx == nil {
+			return nil, fmt.Errorf("cell %s/%s: missing ingress.ingressNginx", cell.Namespace, cell.Name)
+		}
+
+		return &ingressNginxRouter{cell: cell, runtimeClient: runtimeClient, scheme: scheme}, nil
+	case okrav1alpha1.CellIngressTypeEnvoy:
+		if ingress.Envoy == nil {
+			return nil, fmt.Errorf("cell %s/%s: missing ingress.envoy", cell.Namespace, cell.Name)
+		}
+
+		if (ingress.Envoy.TargetGroupSelector == nil) == (ingress.Envoy.EndpointSelector == nil) {
+			return nil, fmt.Errorf("cell %s/%s: exactly one of ingress.envoy.targetGroupSelector or ingress.envoy.endpointSelector must be specified", cell.Namespace, cell.Name)
+		}
+
+		return &envoyRouter{cell: cell, runtimeClient: runtimeClient, scheme: scheme}, nil
+	case okrav1alpha1.CellIngressTypeRoute53WeightedRecord:
+		if ingress.Route53WeightedRecord == nil {
+			return nil, fmt.Errorf("cell %s/%s: missing ingress.route53WeightedRecord", cell.Namespace, cell.Name)
+		}
+
+		return &route53Router{cell: cell, runtimeClient: runtimeClient, scheme: scheme}, nil
+	case okrav1alpha1.CellIngressTypeAWSGlobalAccelerator:
+		if ingress.AWSGlobalAccelerator == nil {
+			return nil, fmt.Errorf("cell %s/%s: missing ingress.awsGlobalAccelerator", cell.Namespace, cell.Name)
+		}
+
+		return &globalAcceleratorRouter{cell: cell, runtimeClient: runtimeClient, scheme: scheme}, nil
+	default:
+		return nil, fmt.Errorf("cell %s/%s: unsupported ingress type %q", cell.Namespace, cell.Name, ingress.Type)
+	}
+}
+
+// selectableBackends returns the backends that are not being deleted.
+func selectableBackends(backends []backend) []backend {
+	var selectable []backend
+
+	for _, b := range backends {
+		if !b.Deleting {
+			selectable = append(selectable, b)
+		}
+	}
+
+	return selectable
+}
+
+func defaultVersionLabelKeys(labelKeys []string) []string {
+	if len(labelKeys) == 0 {
+		return []string{okrav1alpha1.DefaultVersionLabelKey}
+	}
+
+	return labelKeys
+}
+
+func backendVersion(b backend, labelKeys []string) string {
+	for _, l := range labelKeys {
+		if v, ok := b.Labels[l]; ok && v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// latestBackends returns the backends of the latest version, or the specified version when it's not empty.
+// The returned version is nil when there are no backends at all.
+func latestBackends(backends []backend, labelKeys []string, version string) (*semver.Version, []backend, error) {
+	var latestVer *semver.Version
+
+	if version != "" {
+		v, err := semver.Parse(version)
+		if err != nil {
+			return nil, nil, err
+		}
+		latestVer = &v
+	}
+
+	versioned := map[string][]backend{}
+
+	var maxVer *semver.Version
+
+	for _, b := range backends {
+		verStr := backendVersion(b, labelKeys)
+		if verStr == "" {
+			return nil, nil, fmt.Errorf("no semver label found on backend %s: %v", b.Name, b.Labels)
+		}
+
+		ver, err := semver.Parse(verStr)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if maxVer == nil || maxVer.LT(ver) {
+			maxVer = &ver
+		}
+
+		versioned[ver.String()] = append(versioned[ver.String()], b)
+	}
+
+	if latestVer == nil {
+		latestVer = maxVer
+	}
+
+	if latestVer == nil {
+		return nil, nil, nil
+	}
+
+	return latestVer, versioned[latestVer.String()], nil
+}
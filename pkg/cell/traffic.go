@@ -0,0 +1,213 @@
+package cell
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	okrav1alpha1 "github.com/mumoshu/okra/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// TrafficError is returned when a target group or a cluster still receives traffic.
+type TrafficError struct {
+	// Target is the target group or the cluster that still receives traffic
+	Target string
+	// Sources are the cells and the AWSApplicationLoadBalancerConfigs that route traffic to the target
+	Sources []string
+}
+
+func (e *TrafficError) Error() string {
+	return fmt.Sprintf("%s still receives traffic from %s", e.Target, strings.Join(e.Sources, ", "))
+}
+
+// TrafficGuard tells if target groups and clusters still receive traffic, so that
+// okra never deletes cluster secrets and AWSTargetGroups that are still serving live traffic.
+//
+// It covers the cells of every ingress type, by reading the weights of their backends via their traffic routers,
+// along with the AWSApplicationLoadBalancerConfigs that are not managed by cells.
+type TrafficGuard struct {
+	client client.Client
+
+	// arns are the sources keyed by the ARNs of the target groups they route traffic to with non-zero weights
+	arns map[string][]string
+	// backends are the sources keyed by the namespaced names of the backends they route traffic to with non-zero weights
+	backends map[string][]string
+	// clusters are the sources keyed by the namespaced names of the clusters backing the backends
+	// they route traffic to with non-zero weights
+	clusters map[string][]string
+}
+
+// NewTrafficGuard returns a TrafficGuard for the backend weights of all the cells and AWSApplicationLoadBalancerConfigs.
+func NewTrafficGuard(ctx context.Context, c client.Client) (*TrafficGuard, error) {
+	g := &TrafficGuard{
+		client:   c,
+		arns:     map[string][]string{},
+		backends: map[string][]string{},
+		clusters: map[string][]string{},
+	}
+
+	var cells okrav1alpha1.CellList
+
+	if err := c.List(ctx, &cells); err != nil {
+		return nil, fmt.Errorf("listing cells: %w", err)
+	}
+
+	for _, cell := range cells.Items {
+		if err := g.addCell(ctx, cell); err != nil {
+			return nil, err
+		}
+	}
+
+	var albConfigs okrav1alpha1.AWSApplicationLoadBalancerConfigList
+
+	if err := c.List(ctx, &albConfigs); err != nil {
+		return nil, fmt.Errorf("listing awsapplicationloadbalancerconfigs: %w", err)
+	}
+
+	for _, config := range albConfigs.Items {
+		source := "AWSApplicationLoadBalancerConfig " + config.Namespace + "/" + config.Name
+
+		for _, tg := range config.Spec.Listener.Rule.Forward.TargetGroups {
+			if tg.Weight == 0 {
+				continue
+			}
+
+			g.add(g.arns, tg.ARN, source)
+
+			if tg.Name != "" {
+				g.add(g.backends, config.Namespace+"/"+tg.Name, source)
+			}
+		}
+	}
+
+	for _, m := range []map[string][]string{g.arns, g.backends, g.clusters} {
+		for _, sources := range m {
+			sort.Strings(sources)
+		}
+	}
+
+	return g, nil
+}
+
+// addCell records the backends the cell routes traffic to with non-zero weights.
+func (g *TrafficGuard) addCell(ctx context.Context, cell okrav1alpha1.Cell) error {
+	router, err := newTrafficRouter(cell, g.client, nil)
+	if err != nil {
+		return err
+	}
+
+	// Some routers need the backends to be listed before reading the current weights,
+	// as the loadbalancer config does not contain the backend names
+	backends, err := router.listBackends(ctx)
+	if err != nil {
+		return fmt.Errorf("cell %s/%s: listing backends: %w", cell.Namespace, cell.Name, err)
+	}
+
+	tgs, _, err := router.get(ctx)
+	if err != nil {
+		return fmt.Errorf("cell %s/%s: getting backend weights: %w", cell.Namespace, cell.Name, err)
+	}
+
+	backendsByName := map[string]backend{}
+	for _, b := range backends {
+		backendsByName[b.Name] = b
+	}
+
+	source := "Cell " + cell.Namespace + "/" + cell.Name
+
+	for _, tg := range tgs {
+		if tg.Weight == 0 {
+			continue
+		}
+
+		b := backendsByName[tg.Name]
+
+		g.add(g.backends, cell.Namespace+"/"+tg.Name, source)
+		g.add(g.arns, tg.ARN, source)
+		g.add(g.arns, b.ARN, source)
+
+		if cluster := b.Labels[okrav1alpha1.AWSTargetGroupLabelBindingCluster]; cluster != "" {
+			g.add(g.clusters, cell.Namespace+"/"+cluster, source)
+		}
+	}
+
+	return nil
+}
+
+func (g *TrafficGuard) add(m map[string][]string, key, source string) {
+	if key == "" {
+		return
+	}
+
+	for _, s := range m[key] {
+		if s == source {
+			return
+		}
+	}
+
+	m[key] = append(m[key], source)
+}
+
+// CheckTargetGroup returns a *TrafficError when the AWSTargetGroup is routed traffic with a non-zero weight
+// by any cell or AWSApplicationLoadBalancerConfig.
+func (g *TrafficGuard) CheckTargetGroup(tg okrav1alpha1.AWSTargetGroup) error {
+	var sources []string
+
+	if tg.Spec.ARN != "" {
+		sources = append(sources, g.arns[tg.Spec.ARN]...)
+	}
+
+	for _, s := range g.backends[tg.Namespace+"/"+tg.Name] {
+		if !contains(sources, s) {
+			sources = append(sources, s)
+		}
+	}
+
+	if len(sources) > 0 {
+		sort.Strings(sources)
+
+		return &TrafficError{Target: "target group " + tg.Name, Sources: sources}
+	}
+
+	return nil
+}
+
+// CheckCluster returns a *TrafficError when any of the backends of the cluster in the namespace still receives traffic.
+// The backends of a cluster, like AWSTargetGroups, Services, and ClusterEndpoints, are identified by
+// the okra.mumo.co/target-group-binding-cluster label.
+func (g *TrafficGuard) CheckCluster(ctx context.Context, ns, cluster string) error {
+	if sources := g.clusters[ns+"/"+cluster]; len(sources) > 0 {
+		return &TrafficError{Target: "cluster " + cluster, Sources: sources}
+	}
+
+	var list okrav1alpha1.AWSTargetGroupList
+
+	if err := g.client.List(ctx, &list, client.InNamespace(ns), client.MatchingLabels{okrav1alpha1.AWSTargetGroupLabelBindingCluster: cluster}); err != nil {
+		return fmt.Errorf("listing awstargetgroups for cluster %s: %w", cluster, err)
+	}
+
+	for _, tg := range list.Items {
+		if err := g.CheckTargetGroup(tg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ForceDeleteRequested returns true when the object is annotated to be deleted regardless of the traffic.
+func ForceDeleteRequested(annotations map[string]string) bool {
+	return annotations[okrav1alpha1.AnnotationForceDelete] == "true"
+}
+
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,101 @@
+package cell
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mumoshu/okra/api/v1alpha1"
+	"github.com/mumoshu/okra/pkg/clclient"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	crfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestTrafficGuard(t *testing.T) {
+	targetGroup := func(name, cluster, arn string) *v1alpha1.AWSTargetGroup {
+		return &v1alpha1.AWSTargetGroup{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      name,
+				Labels:    map[string]string{v1alpha1.AWSTargetGroupLabelBindingCluster: cluster},
+			},
+			Spec: v1alpha1.AWSTargetGroupSpec{ARN: arn},
+		}
+	}
+
+	web3 := newClusterService("web3-svc", "1.0.0")
+	web3.Labels[v1alpha1.AWSTargetGroupLabelBindingCluster] = "web3"
+
+	scheme := clclient.Scheme()
+
+	c := crfake.NewFakeClientWithScheme(scheme,
+		&v1alpha1.AWSApplicationLoadBalancerConfig{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "alb"},
+			Spec: v1alpha1.AWSApplicationLoadBalancerConfigSpec{
+				Listener: v1alpha1.Listener{
+					Rule: v1alpha1.ListenerRule{
+						Forward: v1alpha1.Forward{
+							TargetGroups: []v1alpha1.ForwardTargetGroup{
+								{Name: "web1-tg", ARN: "arn:tg1", Weight: 80},
+								{Name: "web2-tg", ARN: "arn:tg2", Weight: 0},
+							},
+						},
+					},
+				},
+			},
+		},
+		targetGroup("web1-tg", "web1", "arn:tg1"),
+		targetGroup("web2-tg", "web2", "arn:tg2"),
+		web3,
+	)
+
+	ctx := context.Background()
+
+	// A cell routing traffic to the service of web3 via an HTTPRoute
+	cell := newHTTPRouteCell()
+	if err := c.Create(ctx, cell); err != nil {
+		t.Fatal(err)
+	}
+
+	syncCell(t, c, scheme, cell)
+
+	guard, err := NewTrafficGuard(ctx, c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var trafficErr *TrafficError
+
+	if err := guard.CheckTargetGroup(*targetGroup("web1-tg", "web1", "arn:tg1")); !errors.As(err, &trafficErr) {
+		t.Errorf("expected target group with traffic to be protected, got %v", err)
+	} else if d := cmp.Diff([]string{"AWSApplicationLoadBalancerConfig default/alb"}, trafficErr.Sources); d != "" {
+		t.Errorf("unexpected sources: %s", d)
+	}
+
+	if err := guard.CheckTargetGroup(*targetGroup("web2-tg", "web2", "arn:tg2")); err != nil {
+		t.Errorf("expected drained target group to be deletable, got %v", err)
+	}
+
+	if err := guard.CheckCluster(ctx, "default", "web1"); !errors.As(err, &trafficErr) {
+		t.Errorf("expected cluster with traffic to be protected, got %v", err)
+	}
+
+	if err := guard.CheckCluster(ctx, "default", "web2"); err != nil {
+		t.Errorf("expected drained cluster to be deletable, got %v", err)
+	}
+
+	if err := guard.CheckCluster(ctx, "default", "web3"); !errors.As(err, &trafficErr) {
+		t.Errorf("expected cluster with traffic from the cell to be protected, got %v", err)
+	} else if d := cmp.Diff([]string{"Cell default/web"}, trafficErr.Sources); d != "" {
+		t.Errorf("unexpected sources: %s", d)
+	}
+
+	if err := guard.CheckCluster(ctx, "other", "web3"); err != nil {
+		t.Errorf("expected cluster in another namespace to be deletable, got %v", err)
+	}
+
+	if !ForceDeleteRequested(map[string]string{v1alpha1.AnnotationForceDelete: "true"}) {
+		t.Error("expected force delete to be requested")
+	}
+}
@@ -0,0 +1,247 @@
+package cell
+
+import (
+	"context"
+	"fmt"
+
+	istiov1beta1 "github.com/mumoshu/okra/api/istio/v1beta1"
+	okrav1alpha1 "github.com/mumoshu/okra/api/v1alpha1"
+	"github.com/mumoshu/okra/pkg/sync"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const LabelKeyVirtualServiceConfigHash = "virtualservice-config-hash"
+
+// virtualServiceRouter routes traffic to ClusterEndpoints via an Istio VirtualService
+// that is named after the cell.
+type virtualServiceRouter struct {
+	cell          okrav1alpha1.Cell
+	runtimeClient client.Client
+	scheme        *runtime.Scheme
+
+	vs istiov1beta1.VirtualService
+
+	// destinations maps each backend name to the destination in the VirtualService.
+	// It contains both selected ClusterEndpoints and the destinations that are already in the VirtualService,
+	// so that we can keep routing to a destination whose ClusterEndpoint has gone.
+	destinations map[string]istiov1beta1.Destination
+}
+
+func (r *virtualServiceRouter) config() okrav1alpha1.CellIngressIstioVirtualService {
+	return *r.cell.Spec.Ingress.IstioVirtualService
+}
+
+func (r *virtualServiceRouter) versionLabelKeys() []string {
+	return defaultVersionLabelKeys(r.config().EndpointSelector.VersionLabels)
+}
+
+func (r *virtualServiceRouter) listBackends(ctx context.Context) ([]backend, error) {
+	var endpoints okrav1alpha1.ClusterEndpointList
+
+	if err := r.runtimeClient.List(ctx, &endpoints, client.InNamespace(r.cell.Namespace), client.MatchingLabels(r.config().EndpointSelector.MatchLabels)); err != nil {
+		return nil, fmt.Errorf("listing clusterendpoints: %w", err)
+	}
+
+	if r.destinations == nil {
+		r.destinations = map[string]istiov1beta1.Destination{}
+	}
+
+	var backends []backend
+
+	for _, ep := range endpoints.Items {
+		r.destinations[ep.Name] = endpointDestination(ep)
+
+		backends = append(backends, backend{
+			Name:     ep.Name,
+			Labels:   ep.Labels,
+			Deleting: !ep.DeletionTimestamp.IsZero(),
+		})
+	}
+
+	return backends, nil
+}
+
+func endpointDestination(ep okrav1alpha1.ClusterEndpoint) istiov1beta1.Destination {
+	d := istiov1beta1.Destination{
+		Host:   ep.Spec.Host,
+		Subset: ep.Spec.Subset,
+	}
+
+	if ep.Spec.Port != 0 {
+		d.Port = &istiov1beta1.PortSelector{Number: uint32(ep.Spec.Port)}
+	}
+
+	return d
+}
+
+func destinationKey(d istiov1beta1.Destination) string {
+	key := d.Host + "/" + d.Subset
+
+	if d.Port != nil {
+		key = fmt.Sprintf("%s:%d", key, d.Port.Number)
+	}
+
+	return key
+}
+
+func (r *virtualServiceRouter) get(ctx context.Context) ([]okrav1alpha1.ForwardTargetGroup, bool, error) {
+	key := types.NamespacedName{Namespace: r.cell.Namespace, Name: r.cell.Name}
+
+	if err := r.runtimeClient.Get(ctx, key, &r.vs); err != nil {
+		if !kerrors.IsNotFound(err) {
+			return nil, false, err
+		}
+
+		return nil, false, nil
+	}
+
+	return r.currentWeights(), true, nil
+}
+
+func (r *virtualServiceRouter) currentWeights() []okrav1alpha1.ForwardTargetGroup {
+	names := map[string]string{}
+	for name, d := range r.destinations {
+		names[destinationKey(d)] = name
+	}
+
+	if r.destinations == nil {
+		r.destinations = map[string]istiov1beta1.Destination{}
+	}
+
+	var tgs []okrav1alpha1.ForwardTargetGroup
+
+	for _, route := range r.vs.Spec.HTTP {
+		for _, dest := range route.Route {
+			key := destinationKey(dest.Destination)
+
+			// The ClusterEndpoint for the destination might have been deleted.
+			// We use the destination key as the name so that the destination can still be
+			// weighted until it's removed.
+			name, ok := names[key]
+			if !ok {
+				name = key
+				r.destinations[name] = dest.Destination
+			}
+
+			tgs = append(tgs, okrav1alpha1.ForwardTargetGroup{
+				Name:   name,
+				Weight: int(dest.Weight),
+			})
+		}
+	}
+
+	return tgs
+}
+
+func (r *virtualServiceRouter) routeDestinations(tgs []okrav1alpha1.ForwardTargetGroup) ([]istiov1beta1.HTTPRouteDestination, error) {
+	var dests []istiov1beta1.HTTPRouteDestination
+
+	for _, tg := range tgs {
+		d, ok := r.destinations[tg.Name]
+		if !ok {
+			return nil, fmt.Errorf("no destination found for clusterendpoint %s", tg.Name)
+		}
+
+		dests = append(dests, istiov1beta1.HTTPRouteDestination{
+			Destination: d,
+			Weight:      int32(tg.Weight),
+		})
+	}
+
+	return dests, nil
+}
+
+// desiredSpec returns the spec of the VirtualService with the given destinations.
+func (r *virtualServiceRouter) desiredSpec(tgs []okrav1alpha1.ForwardTargetGroup) (*istiov1beta1.VirtualServiceSpec, error) {
+	config := r.config()
+
+	dests, err := r.routeDestinations(tgs)
+	if err != nil {
+		return nil, err
+	}
+
+	return &istiov1beta1.VirtualServiceSpec{
+		Hosts:    config.Hosts,
+		Gateways: config.Gateways,
+		HTTP: []istiov1beta1.HTTPRoute{
+			{
+				Name:  r.cell.Name,
+				Match: config.Match,
+				Route: dests,
+			},
+		},
+	}, nil
+}
+
+func (r *virtualServiceRouter) create(ctx context.Context, tgs []okrav1alpha1.ForwardTargetGroup) error {
+	spec, err := r.desiredSpec(tgs)
+	if err != nil {
+		return err
+	}
+
+	vs := istiov1beta1.VirtualService{}
+	vs.Namespace = r.cell.Namespace
+	vs.Name = r.cell.Name
+	vs.Spec = *spec
+	ctrl.SetControllerReference(&r.cell, &vs, r.scheme)
+
+	metav1.SetMetaDataAnnotation(&vs.ObjectMeta, LabelKeyVirtualServiceConfigHash, sync.ComputeHash(r.config()))
+
+	if err := r.runtimeClient.Create(ctx, &vs); err != nil {
+		return fmt.Errorf("creating virtualservice: %w", err)
+	}
+
+	return nil
+}
+
+func (r *virtualServiceRouter) updateConfig(ctx context.Context) (bool, error) {
+	desiredHash := sync.ComputeHash(r.config())
+
+	if r.vs.Annotations[LabelKeyVirtualServiceConfigHash] == desiredHash {
+		return false, nil
+	}
+
+	spec, err := r.desiredSpec(r.currentWeights())
+	if err != nil {
+		return false, err
+	}
+
+	metav1.SetMetaDataAnnotation(&r.vs.ObjectMeta, LabelKeyVirtualServiceConfigHash, desiredHash)
+
+	r.vs.Spec = *spec
+
+	if err := r.runtimeClient.Update(ctx, &r.vs); err != nil {
+		return false, fmt.Errorf("updating virtualservice: %w", err)
+	}
+
+	return true, nil
+}
+
+func (r *virtualServiceRouter) update(ctx context.Context, tgs []okrav1alpha1.ForwardTargetGroup) (bool, error) {
+	spec, err := r.desiredSpec(tgs)
+	if err != nil {
+		return false, err
+	}
+
+	r.vs.Spec = *spec
+
+	currentHash := r.vs.Annotations[LabelKeyTemplateHash]
+	desiredHash := sync.ComputeHash(r.vs.Spec)
+
+	if currentHash == desiredHash {
+		return false, nil
+	}
+
+	metav1.SetMetaDataAnnotation(&r.vs.ObjectMeta, LabelKeyTemplateHash, desiredHash)
+
+	if err := r.runtimeClient.Update(ctx, &r.vs); err != nil {
+		return false, fmt.Errorf("updating virtualservice: %w", err)
+	}
+
+	return true, nil
+}
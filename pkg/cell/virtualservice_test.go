@@ -0,0 +1,107 @@
+package cell
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	istiov1beta1 "github.com/mumoshu/okra/api/istio/v1beta1"
+	rolloutsv1alpha1 "github.com/mumoshu/okra/api/rollouts/v1alpha1"
+	okrav1alpha1 "github.com/mumoshu/okra/api/v1alpha1"
+	"github.com/mumoshu/okra/pkg/clclient"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newVirtualServiceCell(steps ...rolloutsv1alpha1.CanaryStep) *okrav1alpha1.Cell {
+	return &okrav1alpha1.Cell{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "web",
+		},
+		Spec: okrav1alpha1.CellSpec{
+			Ingress: okrav1alpha1.CellIngress{
+				Type: okrav1alpha1.CellIngressTypeIstioVirtualService,
+				IstioVirtualService: &okrav1alpha1.CellIngressIstioVirtualService{
+					Hosts:    []string{"web.example.com"},
+					Gateways: []string{"istio-system/ingressgateway"},
+					EndpointSelector: okrav1alpha1.ClusterEndpointSelector{
+						MatchLabels: map[string]string{"role": "web"},
+					},
+				},
+			},
+			UpdateStrategy: okrav1alpha1.CellUpdateStrategy{
+				Type: okrav1alpha1.CellUpdateStrategyTypeCanary,
+				Canary: &okrav1alpha1.CellUpdateStrategyCanary{
+					Steps: steps,
+				},
+			},
+		},
+	}
+}
+
+func newClusterEndpoint(name, subset, version string) *okrav1alpha1.ClusterEndpoint {
+	return &okrav1alpha1.ClusterEndpoint{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      name,
+			Labels: map[string]string{
+				"role":                              "web",
+				okrav1alpha1.DefaultVersionLabelKey: version,
+			},
+		},
+		Spec: okrav1alpha1.ClusterEndpointSpec{
+			Host:   "web.default.svc.cluster.local",
+			Subset: subset,
+		},
+	}
+}
+
+func destinationWeights(t *testing.T, c client.Client) map[string]int32 {
+	t.Helper()
+
+	var vs istiov1beta1.VirtualService
+
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "web"}, &vs); err != nil {
+		t.Fatalf("getting virtualservice: %v", err)
+	}
+
+	weights := map[string]int32{}
+	for _, route := range vs.Spec.HTTP {
+		for _, dest := range route.Route {
+			weights[dest.Destination.Subset] = dest.Weight
+		}
+	}
+
+	return weights
+}
+
+func TestSyncIstioVirtualService(t *testing.T) {
+	scheme := clclient.Scheme()
+
+	cell := newVirtualServiceCell(
+		rolloutsv1alpha1.CanaryStep{SetWeight: pointer.Int32Ptr(20)},
+		rolloutsv1alpha1.CanaryStep{Pause: &rolloutsv1alpha1.RolloutPause{}},
+	)
+
+	c := fake.NewFakeClientWithScheme(scheme, cell, newClusterEndpoint("web-1", "cluster1", "1.0.0"))
+
+	syncCell(t, c, scheme, cell)
+
+	if d := cmp.Diff(map[string]int32{"cluster1": 100}, destinationWeights(t, c)); d != "" {
+		t.Fatalf("unexpected weights after creation: %s", d)
+	}
+
+	if err := c.Create(context.Background(), newClusterEndpoint("web-2", "cluster2", "2.0.0")); err != nil {
+		t.Fatal(err)
+	}
+
+	syncCell(t, c, scheme, cell)
+
+	if d := cmp.Diff(map[string]int32{"cluster1": 80, "cluster2": 20}, destinationWeights(t, c)); d != "" {
+		t.Fatalf("unexpected weights after the first canary step: %s", d)
+	}
+}
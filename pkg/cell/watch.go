@@ -0,0 +1,95 @@
+package cell
+
+import (
+	okrav1alpha1 "github.com/mumoshu/okra/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// indexValueAnyTargetGroup is the index value of cells whose target group selector has no labels to match,
+// that select all the AWSTargetGroups in the namespace.
+const indexValueAnyTargetGroup = "*"
+
+// TargetGroupSelector returns the selector of the AWSTargetGroups the cell routes traffic to,
+// or nil when the cell routes traffic to other kinds of backends.
+func TargetGroupSelector(cell okrav1alpha1.Cell) *okrav1alpha1.TargetGroupSelector {
+	ingress := cell.Spec.Ingress
+
+	switch ingress.Type {
+	case "", okrav1alpha1.CellIngressTypeAWSApplicationLoadBalancer:
+		if ingress.AWSApplicationLoadBalancer != nil {
+			return &ingress.AWSApplicationLoadBalancer.TargetGroupSelector
+		}
+	case okrav1alpha1.CellIngressTypeEnvoy:
+		if ingress.Envoy != nil {
+			return ingress.Envoy.TargetGroupSelector
+		}
+	}
+
+	return nil
+}
+
+// TargetGroupSelectorIndexValues returns the values to index the cell by its target group selector.
+// Each label to match becomes a `key=value` value, so that cells can be looked up by any label of an AWSTargetGroup.
+func TargetGroupSelectorIndexValues(cell okrav1alpha1.Cell) []string {
+	sel := TargetGroupSelector(cell)
+	if sel == nil {
+		return nil
+	}
+
+	if len(sel.MatchLabels) == 0 {
+		return []string{indexValueAnyTargetGroup}
+	}
+
+	var values []string
+
+	for k, v := range sel.MatchLabels {
+		values = append(values, k+"="+v)
+	}
+
+	return values
+}
+
+// TargetGroupIndexValues returns the index values to look up the cells that may select an AWSTargetGroup with the labels.
+// The looked up cells need to be filtered with SelectsTargetGroup, as a cell matching one of the labels may not match the others.
+func TargetGroupIndexValues(tgLabels map[string]string) []string {
+	values := []string{indexValueAnyTargetGroup}
+
+	for k, v := range tgLabels {
+		values = append(values, k+"="+v)
+	}
+
+	return values
+}
+
+// SelectsTargetGroup returns true when the cell routes traffic to AWSTargetGroups with the labels.
+func SelectsTargetGroup(cell okrav1alpha1.Cell, tgLabels map[string]string) bool {
+	sel := TargetGroupSelector(cell)
+	if sel == nil {
+		return false
+	}
+
+	return labels.SelectorFromSet(sel.MatchLabels).Matches(labels.Set(tgLabels))
+}
+
+// TargetGroupUpdateAffectsCells returns true when the update of the AWSTargetGroup may change the result of syncing the cells selecting it.
+// The periodic target health probes that only bump the probe time are ignored, so that they do not enqueue the cells every time.
+func TargetGroupUpdateAffectsCells(old, new okrav1alpha1.AWSTargetGroup) bool {
+	if old.Generation != new.Generation || old.DeletionTimestamp.IsZero() != new.DeletionTimestamp.IsZero() {
+		return true
+	}
+
+	if !labels.Equals(old.Labels, new.Labels) {
+		return true
+	}
+
+	oldHealth, newHealth := old.Status.TargetHealth, new.Status.TargetHealth
+	if oldHealth == nil || newHealth == nil {
+		return oldHealth != newHealth
+	}
+
+	o, n := *oldHealth, *newHealth
+	o.LastProbeTime, n.LastProbeTime = metav1.Time{}, metav1.Time{}
+
+	return o != n
+}
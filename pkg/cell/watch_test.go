@@ -0,0 +1,107 @@
+package cell
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	okrav1alpha1 "github.com/mumoshu/okra/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestTargetGroupIndex(t *testing.T) {
+	albCell := func(matchLabels map[string]string) okrav1alpha1.Cell {
+		var c okrav1alpha1.Cell
+		c.Spec.Ingress.AWSApplicationLoadBalancer = &okrav1alpha1.CellIngressAWSApplicationLoadBalancer{
+			TargetGroupSelector: okrav1alpha1.TargetGroupSelector{MatchLabels: matchLabels},
+		}
+		return c
+	}
+
+	web := albCell(map[string]string{"role": "web", "env": "prod"})
+	all := albCell(nil)
+
+	var nginx okrav1alpha1.Cell
+	nginx.Spec.Ingress.Type = okrav1alpha1.CellIngressTypeIngressNginx
+	nginx.Spec.Ingress.IngressNginx = &okrav1alpha1.CellIngressIngressNginx{}
+
+	values := TargetGroupSelectorIndexValues(web)
+	sort.Strings(values)
+
+	if d := cmp.Diff([]string{"env=prod", "role=web"}, values); d != "" {
+		t.Errorf("unexpected index values: -want +got\n%s", d)
+	}
+
+	if d := cmp.Diff([]string{indexValueAnyTargetGroup}, TargetGroupSelectorIndexValues(all)); d != "" {
+		t.Errorf("unexpected index values: -want +got\n%s", d)
+	}
+
+	if values := TargetGroupSelectorIndexValues(nginx); len(values) != 0 {
+		t.Errorf("unexpected index values: %v", values)
+	}
+
+	tgLabels := map[string]string{"role": "web", "env": "prod", "version": "1.0.0"}
+
+	lookup := map[string]bool{}
+	for _, v := range TargetGroupIndexValues(tgLabels) {
+		lookup[v] = true
+	}
+
+	for _, c := range []okrav1alpha1.Cell{web, all} {
+		found := false
+		for _, v := range TargetGroupSelectorIndexValues(c) {
+			found = found || lookup[v]
+		}
+
+		if !found {
+			t.Errorf("cell with selector %v is not looked up", TargetGroupSelector(c))
+		}
+
+		if !SelectsTargetGroup(c, tgLabels) {
+			t.Errorf("cell with selector %v does not select %v", TargetGroupSelector(c), tgLabels)
+		}
+	}
+
+	if SelectsTargetGroup(web, map[string]string{"role": "web", "env": "stg"}) {
+		t.Errorf("cell selects a target group with unmatched labels")
+	}
+
+	if SelectsTargetGroup(nginx, tgLabels) {
+		t.Errorf("ingress-nginx cell selects a target group")
+	}
+}
+
+func TestTargetGroupUpdateAffectsCells(t *testing.T) {
+	probedAt := func(min int) metav1.Time {
+		return metav1.NewTime(time.Date(2022, 1, 1, 0, min, 0, 0, time.UTC))
+	}
+
+	tg := func(healthy int32, probeTime metav1.Time, labels map[string]string) okrav1alpha1.AWSTargetGroup {
+		var tg okrav1alpha1.AWSTargetGroup
+		tg.Labels = labels
+		tg.Status.TargetHealth = &okrav1alpha1.AWSTargetGroupTargetHealth{Healthy: healthy, Total: 2, LastProbeTime: probeTime}
+		return tg
+	}
+
+	web := map[string]string{"role": "web"}
+
+	testcases := []struct {
+		name     string
+		old, new okrav1alpha1.AWSTargetGroup
+		want     bool
+	}{
+		{name: "probe time only", old: tg(2, probedAt(0), web), new: tg(2, probedAt(1), web)},
+		{name: "health changed", old: tg(1, probedAt(0), web), new: tg(2, probedAt(1), web), want: true},
+		{name: "first probe", old: okrav1alpha1.AWSTargetGroup{}, new: tg(0, probedAt(0), nil), want: true},
+		{name: "labels changed", old: tg(2, probedAt(0), web), new: tg(2, probedAt(0), map[string]string{"role": "api"}), want: true},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := TargetGroupUpdateAffectsCells(tc.old, tc.new); got != tc.want {
+				t.Errorf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
@@ -31,14 +31,14 @@ func redistributeWeights(totalWeight int, desiredTGs []okrav1alpha1.ForwardTarge
 	return result
 }
 
-func distributeWeights(totalWeight int, desiredTGs []okrav1alpha1.AWSTargetGroup) map[string]okrav1alpha1.ForwardTargetGroup {
+func distributeWeights(totalWeight int, desiredTGs []backend) map[string]okrav1alpha1.ForwardTargetGroup {
 	numTGs := len(desiredTGs)
 	result := map[string]okrav1alpha1.ForwardTargetGroup{}
 
 	for i, tg := range desiredTGs {
 		result[tg.Name] = okrav1alpha1.ForwardTargetGroup{
 			Name:   tg.Name,
-			ARN:    tg.Spec.ARN,
+			ARN:    tg.ARN,
 			Weight: getWeightAt(totalWeight, numTGs, i),
 		}
 	}
@@ -32,6 +32,7 @@ import (
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/client-go/transport"
 )
 
 // ConnectionStatus represents the status indicator for a connection to a remote resource
@@ -229,6 +230,7 @@ func SetK8SConfigDefaults(config *rest.Config) error {
 	config.TLSClientConfig = rest.TLSClientConfig{}
 	config.AuthProvider = nil
 	config.ExecProvider = nil
+	config.WrapTransport = nil
 
 	config.Transport = tr
 	return nil
@@ -264,18 +266,12 @@ func (c *Cluster) RawRestConfig() *rest.Config {
 			CAData:     c.Config.TLSClientConfig.CAData,
 		}
 		if c.Config.AWSAuthConfig != nil {
-			args := []string{"eks", "get-token", "--cluster-name", c.Config.AWSAuthConfig.ClusterName}
-			if c.Config.AWSAuthConfig.RoleARN != "" {
-				args = append(args, "--role-arn", c.Config.AWSAuthConfig.RoleARN)
-			}
+			// Unlike ArgoCD, tokens are generated in-process so that no aws executable is required
+			ts := eksTokenSourceFor(c.Config.AWSAuthConfig.ClusterName, c.Config.AWSAuthConfig.RoleARN)
 			config = &rest.Config{
 				Host:            c.Server,
 				TLSClientConfig: tlsClientConfig,
-				ExecProvider: &api.ExecConfig{
-					APIVersion: "client.authentication.k8s.io/v1alpha1",
-					Command:    "aws",
-					Args:       args,
-				},
+				WrapTransport:   transport.TokenSourceWrapTransport(ts),
 			}
 		} else if c.Config.ExecProviderConfig != nil {
 			var env []api.ExecEnvVar
@@ -3,12 +3,13 @@ package clclient
 import (
 	"encoding/json"
 	"fmt"
-	"os/exec"
 	"strconv"
 	"strings"
 	"time"
 
 	elbv2v1beta1 "github.com/mumoshu/okra/api/elbv2/v1beta1"
+	gatewayv1beta1 "github.com/mumoshu/okra/api/gateway/v1beta1"
+	istiov1beta1 "github.com/mumoshu/okra/api/istio/v1beta1"
 	rolloutsv1alpha1 "github.com/mumoshu/okra/api/rollouts/v1alpha1"
 	okrav1alpha1 "github.com/mumoshu/okra/api/v1alpha1"
 	"github.com/mumoshu/okra/pkg/okraerror"
@@ -35,6 +36,8 @@ func init() {
 	_ = okrav1alpha1.AddToScheme(scheme)
 	_ = rolloutsv1alpha1.AddToScheme(scheme)
 	_ = elbv2v1beta1.AddToScheme(scheme)
+	_ = gatewayv1beta1.AddToScheme(scheme)
+	_ = istiov1beta1.AddToScheme(scheme)
 	// +kubebuilder:scaffold:scheme
 }
 
@@ -118,12 +121,6 @@ func NewFromClusterSecret(clusterSecret corev1.Secret) (client.Client, error) {
 		return nil, fmt.Errorf("secret to cluster: %w", err)
 	}
 
-	if cluster.Config.AWSAuthConfig != nil && cluster.Config.AWSAuthConfig.ClusterName != "" {
-		if _, err := exec.LookPath("aws"); err != nil {
-			return nil, okraerror.New(fmt.Errorf("looking for executable \"aws\": %v", err))
-		}
-	}
-
 	return NewFromRestConfig(cluster.RESTConfig())
 }
 
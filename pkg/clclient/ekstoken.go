@@ -0,0 +1,79 @@
+package clclient
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mumoshu/okra/pkg/awsclicompat"
+	"golang.org/x/oauth2"
+	"sigs.k8s.io/aws-iam-authenticator/pkg/token"
+)
+
+// eksTokenRefreshBefore is how long before the expiry an EKS token is refreshed,
+// so that requests in flight never carry an expired token.
+const eksTokenRefreshBefore = time.Minute
+
+var (
+	eksTokenSourcesMu sync.Mutex
+	eksTokenSources   = map[string]oauth2.TokenSource{}
+)
+
+// eksTokenSourceFor returns the token source for the EKS cluster, shared across all the clients for the cluster
+// so that a token is generated only once until it gets close to the expiry.
+func eksTokenSourceFor(clusterName, roleARN string) oauth2.TokenSource {
+	eksTokenSourcesMu.Lock()
+	defer eksTokenSourcesMu.Unlock()
+
+	key := clusterName + "/" + roleARN
+
+	ts, ok := eksTokenSources[key]
+	if !ok {
+		ts = oauth2.ReuseTokenSource(nil, &eksTokenSource{
+			clusterName: clusterName,
+			roleARN:     roleARN,
+			get:         getEKSToken,
+		})
+
+		eksTokenSources[key] = ts
+	}
+
+	return ts
+}
+
+// eksTokenSource generates tokens for the EKS cluster in-process, the same way as `aws eks get-token` does.
+// The token is for the role when roleARN is set, or for the default credentials otherwise.
+type eksTokenSource struct {
+	clusterName string
+	roleARN     string
+
+	get func(clusterName, roleARN string) (token.Token, error)
+}
+
+func (s *eksTokenSource) Token() (*oauth2.Token, error) {
+	tok, err := s.get(s.clusterName, s.roleARN)
+	if err != nil {
+		return nil, fmt.Errorf("getting token for eks cluster %q: %w", s.clusterName, err)
+	}
+
+	return &oauth2.Token{
+		AccessToken: tok.Token,
+		TokenType:   "Bearer",
+		Expiry:      tok.Expiration.Add(-eksTokenRefreshBefore),
+	}, nil
+}
+
+// getEKSToken replicates the behavior of `aws eks get-token --cluster-name $CLUSTER_NAME --role-arn $ROLE_ARN`
+// by using aws-iam-authenticator, which is the original implementation of the token generator.
+func getEKSToken(clusterName, roleARN string) (token.Token, error) {
+	gen, err := token.NewGenerator(true, false)
+	if err != nil {
+		return token.Token{}, err
+	}
+
+	return gen.GetWithOptions(&token.GetTokenOptions{
+		ClusterID:     clusterName,
+		AssumeRoleARN: roleARN,
+		Session:       awsclicompat.NewSession("", ""),
+	})
+}
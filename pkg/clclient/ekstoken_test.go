@@ -0,0 +1,99 @@
+package clclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+	"sigs.k8s.io/aws-iam-authenticator/pkg/token"
+)
+
+func TestEKSTokenSourceRefreshesBeforeExpiry(t *testing.T) {
+	var calls int
+
+	expiration := time.Now().Add(15 * time.Minute)
+
+	ts := oauth2.ReuseTokenSource(nil, &eksTokenSource{
+		clusterName: "web1",
+		get: func(clusterName, roleARN string) (token.Token, error) {
+			calls++
+
+			return token.Token{Token: "k8s-aws-v1.token", Expiration: expiration}, nil
+		},
+	})
+
+	for i := 0; i < 2; i++ {
+		tok, err := ts.Token()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if tok.AccessToken != "k8s-aws-v1.token" {
+			t.Errorf("unexpected token: %s", tok.AccessToken)
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("expected the token to be cached, but generated %d times", calls)
+	}
+
+	// A token that expires within eksTokenRefreshBefore is refreshed
+	expiration = time.Now().Add(30 * time.Second)
+
+	ts = oauth2.ReuseTokenSource(nil, &eksTokenSource{
+		clusterName: "web1",
+		get: func(clusterName, roleARN string) (token.Token, error) {
+			calls++
+
+			return token.Token{Token: "k8s-aws-v1.token", Expiration: expiration}, nil
+		},
+	})
+
+	for i := 0; i < 2; i++ {
+		if _, err := ts.Token(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if calls != 3 {
+		t.Errorf("expected the token to be refreshed, but generated %d times in total", calls)
+	}
+}
+
+func TestRESTConfigWithAWSAuthConfig(t *testing.T) {
+	var authorization string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+	}))
+	defer srv.Close()
+
+	eksTokenSourcesMu.Lock()
+	eksTokenSources["web1/arn:aws:iam::123456789012:role/okra"] = oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "k8s-aws-v1.token"})
+	eksTokenSourcesMu.Unlock()
+
+	c := &Cluster{
+		Server: srv.URL,
+		Config: ClusterConfig{
+			AWSAuthConfig: &AWSAuthConfig{ClusterName: "web1", RoleARN: "arn:aws:iam::123456789012:role/okra"},
+		},
+	}
+
+	config := c.RESTConfig()
+
+	if config.ExecProvider != nil {
+		t.Errorf("unexpected exec provider: %+v", config.ExecProvider)
+	}
+
+	res, err := (&http.Client{Transport: config.Transport}).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	res.Body.Close()
+
+	if authorization != "Bearer k8s-aws-v1.token" {
+		t.Errorf("unexpected authorization header: %q", authorization)
+	}
+}
@@ -0,0 +1,94 @@
+package clclient
+
+import (
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultClusterClientPool is the pool shared by everything in the process that accesses clusters via cluster secrets.
+var DefaultClusterClientPool = NewClusterClientPool()
+
+// ClusterClientPool caches clients for the clusters of cluster secrets, so that REST mapper discovery and
+// credential setup are done once per cluster rather than on every access.
+//
+// Clients are keyed by the UID and the resourceVersion of the cluster secret. A client is created lazily on the first access,
+// and recreated once the secret changes, like when the server or the credentials are rotated.
+// Tokens need no care here, as EKS tokens are refreshed before the expiry by the token source shared across clients,
+// and exec credentials are refreshed by client-go.
+type ClusterClientPool struct {
+	mu      sync.Mutex
+	clients map[types.UID]*pooledClusterClient
+
+	// newClient defaults to NewFromClusterSecret. Overridden in tests.
+	newClient func(corev1.Secret) (client.Client, error)
+}
+
+type pooledClusterClient struct {
+	namespace       string
+	name            string
+	resourceVersion string
+	client          client.Client
+}
+
+func NewClusterClientPool() *ClusterClientPool {
+	return &ClusterClientPool{
+		clients:   map[types.UID]*pooledClusterClient{},
+		newClient: NewFromClusterSecret,
+	}
+}
+
+// Get returns the client for the cluster of the cluster secret, creating one if the pool has none for the secret's
+// current resourceVersion.
+// A secret without UID, which is not read from the API server, always gets a new client.
+func (p *ClusterClientPool) Get(secret corev1.Secret) (client.Client, error) {
+	if secret.UID == "" {
+		return p.newClient(secret)
+	}
+
+	p.mu.Lock()
+	pooled, ok := p.clients[secret.UID]
+	p.mu.Unlock()
+
+	if ok && pooled.resourceVersion == secret.ResourceVersion {
+		return pooled.client, nil
+	}
+
+	// The client is created out of the lock, so that a slow cluster does not block access to the others.
+	// Concurrent calls for the same secret may create more than one client, one of which is kept.
+	c, err := p.newClient(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.clients[secret.UID] = &pooledClusterClient{
+		namespace:       secret.Namespace,
+		name:            secret.Name,
+		resourceVersion: secret.ResourceVersion,
+		client:          c,
+	}
+
+	return c, nil
+}
+
+// Evict removes the client for the cluster secret from the pool. Call this when the secret is deleted.
+func (p *ClusterClientPool) Evict(namespace, name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for uid, pooled := range p.clients {
+		if pooled.namespace == namespace && pooled.name == name {
+			delete(p.clients, uid)
+		}
+	}
+}
+
+// ClusterClient returns the client for the cluster of the cluster secret from DefaultClusterClientPool.
+func ClusterClient(secret corev1.Secret) (client.Client, error) {
+	return DefaultClusterClientPool.Get(secret)
+}
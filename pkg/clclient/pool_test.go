@@ -0,0 +1,56 @@
+package clclient
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	crfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestClusterClientPool(t *testing.T) {
+	var created int
+
+	p := NewClusterClientPool()
+	p.newClient = func(corev1.Secret) (client.Client, error) {
+		created++
+
+		return crfake.NewFakeClientWithScheme(Scheme()), nil
+	}
+
+	secret := func(uid, rv string) corev1.Secret {
+		return corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web1", UID: types.UID(uid), ResourceVersion: rv},
+		}
+	}
+
+	get := func(uid, rv string) {
+		if _, err := p.Get(secret(uid, rv)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	steps := []struct {
+		name    string
+		do      func()
+		created int
+	}{
+		{name: "first access", do: func() { get("a", "1") }, created: 1},
+		{name: "same resourceVersion", do: func() { get("a", "1") }, created: 1},
+		{name: "updated secret", do: func() { get("a", "2") }, created: 2},
+		{name: "after update", do: func() { get("a", "2") }, created: 2},
+		{name: "recreated secret", do: func() { get("b", "3") }, created: 3},
+		{name: "evicted", do: func() { p.Evict("default", "web1"); get("b", "3") }, created: 4},
+		{name: "no uid", do: func() { get("", ""); get("", "") }, created: 6},
+	}
+
+	for _, s := range steps {
+		s.do()
+
+		if created != s.created {
+			t.Fatalf("%s: unexpected number of created clients: want %d, got %d", s.name, s.created, created)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package clclient
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// Probe checks the connectivity to the cluster described by the cluster secret,
+// by requesting `/readyz` and then the server version via the discovery API.
+// It returns the git version of the API server on success.
+func Probe(ctx context.Context, clusterSecret corev1.Secret, timeout time.Duration) (string, error) {
+	cluster, err := SecretToCluster(&clusterSecret)
+	if err != nil {
+		return "", fmt.Errorf("secret to cluster: %w", err)
+	}
+
+	config := cluster.RESTConfig()
+	config.Timeout = timeout
+
+	return probe(ctx, config)
+}
+
+func probe(ctx context.Context, config *rest.Config) (string, error) {
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return "", fmt.Errorf("new for config: %w", err)
+	}
+
+	if _, err := clientset.Discovery().RESTClient().Get().AbsPath("/readyz").DoRaw(ctx); err != nil {
+		return "", fmt.Errorf("requesting /readyz: %w", err)
+	}
+
+	v, err := clientset.Discovery().ServerVersion()
+	if err != nil {
+		return "", fmt.Errorf("getting server version: %w", err)
+	}
+
+	return v.GitVersion, nil
+}
@@ -0,0 +1,45 @@
+package clclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s.io/client-go/rest"
+)
+
+func TestProbe(t *testing.T) {
+	ready := true
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/readyz":
+			if !ready {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+			w.Write([]byte("ok"))
+		case "/version":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"gitVersion":"v1.21.2-eks-0389ca3"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	version, err := probe(context.Background(), &rest.Config{Host: srv.URL})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if version != "v1.21.2-eks-0389ca3" {
+		t.Errorf("unexpected version: %s", version)
+	}
+
+	ready = false
+
+	if _, err := probe(context.Background(), &rest.Config{Host: srv.URL}); err == nil {
+		t.Errorf("expected an error for the cluster that is not ready")
+	}
+}
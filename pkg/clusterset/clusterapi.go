@@ -0,0 +1,148 @@
+package clusterset
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/mumoshu/okra/pkg/clclient"
+	"golang.org/x/xerrors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var clusterAPIClusterListKind = schema.GroupVersionKind{
+	Group:   "cluster.x-k8s.io",
+	Version: "v1beta1",
+	Kind:    "ClusterList",
+}
+
+// ClusterAPISelector selects Cluster API clusters to generate cluster secrets from.
+type ClusterAPISelector struct {
+	// NS is the namespace of the Cluster API clusters and their kubeconfig secrets
+	NS          string
+	MatchLabels map[string]string
+}
+
+// clusterSecretsFromClusterAPI returns the ArgoCD cluster secrets for the Cluster API clusters
+// selected by the selector, by reading their `<name>-kubeconfig` secrets.
+// The labels of each Cluster API cluster are carried over to the cluster secret, followed by the rendered template labels.
+func clusterSecretsFromClusterAPI(ctx context.Context, c client.Client, ns string, sel ClusterAPISelector, tmpl SecretTemplate) ([]*corev1.Secret, error) {
+	log.Printf("Computing desired cluster secrets from Cluster API clusters...")
+
+	var result unstructured.UnstructuredList
+
+	result.SetGroupVersionKind(clusterAPIClusterListKind)
+
+	if err := c.List(ctx, &result, client.InNamespace(sel.NS), client.MatchingLabels(sel.MatchLabels)); err != nil {
+		return nil, xerrors.Errorf("listing cluster api clusters: %w", err)
+	}
+
+	log.Printf("Found %d clusters.", len(result.Items))
+
+	var secrets []*corev1.Secret
+
+	for _, cluster := range result.Items {
+		name := cluster.GetName()
+
+		if cluster.GetDeletionTimestamp() != nil {
+			log.Printf("Skipping cluster %s being deleted", name)
+			continue
+		}
+
+		var kubeconfigSecret corev1.Secret
+
+		if err := c.Get(ctx, types.NamespacedName{Namespace: sel.NS, Name: name + "-kubeconfig"}, &kubeconfigSecret); err != nil {
+			if kerrors.IsNotFound(err) {
+				log.Printf("Skipping cluster %s whose kubeconfig secret is not created yet", name)
+				continue
+			}
+
+			return nil, xerrors.Errorf("getting kubeconfig secret for cluster %s: %w", name, err)
+		}
+
+		sec, err := newClusterSecretFromKubeconfig(ns, name, cluster.GetLabels(), kubeconfigSecret.Data["value"])
+		if err != nil {
+			return nil, xerrors.Errorf("creating cluster secret for cluster %s: %w", name, err)
+		}
+
+		if err := tmpl.apply(sec, clusterAPITemplateData(sel.NS, name, cluster.GetLabels())); err != nil {
+			return nil, xerrors.Errorf("applying template to cluster secret %s: %w", name, err)
+		}
+
+		secrets = append(secrets, sec)
+	}
+
+	return secrets, nil
+}
+
+// newClusterSecretFromKubeconfig returns the ArgoCD cluster secret that has the server and the credentials
+// of the current context of the kubeconfig.
+func newClusterSecretFromKubeconfig(ns, name string, labels map[string]string, kubeconfig []byte) (*corev1.Secret, error) {
+	kc, err := clientcmd.Load(kubeconfig)
+	if err != nil {
+		return nil, xerrors.Errorf("loading kubeconfig: %w", err)
+	}
+
+	kctx, ok := kc.Contexts[kc.CurrentContext]
+	if !ok {
+		return nil, fmt.Errorf("current context %q not found in kubeconfig", kc.CurrentContext)
+	}
+
+	cluster, ok := kc.Clusters[kctx.Cluster]
+	if !ok {
+		return nil, fmt.Errorf("cluster %q not found in kubeconfig", kctx.Cluster)
+	}
+
+	config := clclient.ClusterConfig{
+		TLSClientConfig: clclient.TLSClientConfig{
+			Insecure: cluster.InsecureSkipTLSVerify,
+			CAData:   cluster.CertificateAuthorityData,
+		},
+	}
+
+	if auth, ok := kc.AuthInfos[kctx.AuthInfo]; ok {
+		config.BearerToken = auth.Token
+		config.Username = auth.Username
+		config.Password = auth.Password
+		config.TLSClientConfig.CertData = auth.ClientCertificateData
+		config.TLSClientConfig.KeyData = auth.ClientKeyData
+	}
+
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+
+	lbls := map[string]string{
+		SecretLabelKeyArgoCDType: SecretLabelValueArgoCDCluster,
+	}
+
+	for k, v := range labels {
+		lbls[k] = v
+	}
+
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+			Labels:    lbls,
+		},
+		StringData: map[string]string{
+			"name":   name,
+			"server": cluster.Server,
+			"config": string(configJSON),
+		},
+	}, nil
+}
@@ -0,0 +1,121 @@
+package clusterset
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mumoshu/okra/pkg/clclient"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	crfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: capi1
+  cluster:
+    server: https://capi1.example.com:6443
+    certificate-authority-data: Y2E=
+users:
+- name: capi1-admin
+  user:
+    client-certificate-data: Y2VydA==
+    client-key-data: a2V5
+contexts:
+- name: capi1-admin@capi1
+  context:
+    cluster: capi1
+    user: capi1-admin
+current-context: capi1-admin@capi1
+`
+
+func newClusterAPICluster(name string, labels map[string]string) *unstructured.Unstructured {
+	c := &unstructured.Unstructured{}
+	c.SetAPIVersion("cluster.x-k8s.io/v1beta1")
+	c.SetKind("Cluster")
+	c.SetNamespace("capi")
+	c.SetName(name)
+	c.SetLabels(labels)
+
+	return c
+}
+
+func TestClusterSecretsFromClusterAPI(t *testing.T) {
+	// The fake client needs the kinds of Cluster API clusters registered, unlike the real one reading them as unstructured
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	scheme.AddKnownTypeWithName(clusterAPIClusterListKind.GroupVersion().WithKind("Cluster"), &unstructured.Unstructured{})
+	scheme.AddKnownTypeWithName(clusterAPIClusterListKind, &unstructured.UnstructuredList{})
+
+	c := crfake.NewFakeClientWithScheme(scheme,
+		newClusterAPICluster("capi1", map[string]string{"env": "prod", "region": "us-east-1"}),
+		// The kubeconfig secret is not created yet
+		newClusterAPICluster("capi2", map[string]string{"env": "prod"}),
+		newClusterAPICluster("capi3", map[string]string{"env": "dev"}),
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "capi",
+				Name:      "capi1-kubeconfig",
+			},
+			Data: map[string][]byte{
+				"value": []byte(testKubeconfig),
+			},
+		},
+	)
+
+	secrets, err := clusterSecretsFromClusterAPI(context.Background(), c, "argocd",
+		ClusterAPISelector{NS: "capi", MatchLabels: map[string]string{"env": "prod"}},
+		SecretTemplate{Labels: map[string]string{"role": "web"}},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(secrets) != 1 {
+		t.Fatalf("expected 1 cluster secret, got %d", len(secrets))
+	}
+
+	sec := secrets[0]
+
+	wantLabels := map[string]string{
+		SecretLabelKeyArgoCDType: SecretLabelValueArgoCDCluster,
+		"env":                    "prod",
+		"region":                 "us-east-1",
+		"role":                   "web",
+	}
+
+	if d := cmp.Diff(wantLabels, sec.Labels); d != "" {
+		t.Errorf("unexpected labels: %s", d)
+	}
+
+	if sec.Namespace != "argocd" || sec.Name != "capi1" {
+		t.Errorf("unexpected secret %s/%s", sec.Namespace, sec.Name)
+	}
+
+	if got := sec.StringData["server"]; got != "https://capi1.example.com:6443" {
+		t.Errorf("unexpected server: %s", got)
+	}
+
+	var config clclient.ClusterConfig
+	if err := json.Unmarshal([]byte(sec.StringData["config"]), &config); err != nil {
+		t.Fatal(err)
+	}
+
+	want := clclient.TLSClientConfig{
+		CAData:   []byte("ca"),
+		CertData: []byte("cert"),
+		KeyData:  []byte("key"),
+	}
+
+	if d := cmp.Diff(want, config.TLSClientConfig); d != "" {
+		t.Errorf("unexpected tls client config: %s", d)
+	}
+}
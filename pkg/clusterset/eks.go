@@ -0,0 +1,423 @@
+package clusterset
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/eks/eksiface"
+	"github.com/mumoshu/okra/pkg/awsclicompat"
+	"golang.org/x/xerrors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+const (
+	// DefaultEKSConcurrency is the default number of concurrent DescribeCluster calls per source
+	DefaultEKSConcurrency = 8
+	// DefaultEKSQPS is the default rate limit of EKS API calls per source
+	DefaultEKSQPS = 10
+	// DefaultEKSClusterCacheTTL is the default TTL of the cached DescribeCluster results
+	DefaultEKSClusterCacheTTL = 5 * time.Minute
+
+	eksMaxAttempts = 5
+	eksMinBackoff  = 500 * time.Millisecond
+	eksMaxBackoff  = 30 * time.Second
+)
+
+// DiscoveryStats are the stats of the EKS API calls made to discover clusters.
+// The counters are updated atomically as clusters are described concurrently.
+type DiscoveryStats struct {
+	ListClustersCalls    int64
+	DescribeClusterCalls int64
+	CacheHits            int64
+	Throttles            int64
+	Duration             time.Duration
+}
+
+func (s *DiscoveryStats) inc(counter *int64) {
+	atomic.AddInt64(counter, 1)
+}
+
+// EKSClusterCache caches DescribeCluster results for the TTL, so that
+// consecutive syncs do not describe every cluster every time.
+// The results are keyed by the region, the role, and the name of the cluster,
+// which together identify the cluster ARN.
+type EKSClusterCache struct {
+	TTL time.Duration
+
+	mu      sync.Mutex
+	entries map[string]eksClusterCacheEntry
+	now     func() time.Time
+}
+
+type eksClusterCacheEntry struct {
+	output  *eks.DescribeClusterOutput
+	expires time.Time
+}
+
+// NewEKSClusterCache returns an EKSClusterCache whose entries expire after ttl.
+func NewEKSClusterCache(ttl time.Duration) *EKSClusterCache {
+	return &EKSClusterCache{
+		TTL:     ttl,
+		entries: map[string]eksClusterCacheEntry{},
+		now:     time.Now,
+	}
+}
+
+func (c *EKSClusterCache) get(key string) (*eks.DescribeClusterOutput, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok || !c.now().Before(e.expires) {
+		return nil, false
+	}
+
+	return e.output, true
+}
+
+func (c *EKSClusterCache) put(key string, output *eks.DescribeClusterOutput) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+
+	// Prune expired entries so that deleted clusters do not stay forever
+	for k, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+
+	c.entries[key] = eksClusterCacheEntry{output: output, expires: now.Add(c.TTL)}
+}
+
+// adaptiveBackoff delays EKS API calls after throttling errors.
+// The delay is shared across the concurrent calls for a source. It is doubled on every throttling error
+// and halved on every success, so that the calls slow down only while EKS throttles them.
+type adaptiveBackoff struct {
+	mu    sync.Mutex
+	delay time.Duration
+
+	sleep func(time.Duration)
+	stats *DiscoveryStats
+}
+
+func (b *adaptiveBackoff) do(f func() error) error {
+	for attempt := 1; ; attempt++ {
+		if d := b.current(); d > 0 {
+			b.sleep(d)
+		}
+
+		err := f()
+		if err == nil {
+			b.update(false)
+
+			return nil
+		}
+
+		if !request.IsErrorThrottle(err) || attempt >= eksMaxAttempts {
+			return err
+		}
+
+		b.stats.inc(&b.stats.Throttles)
+		b.update(true)
+	}
+}
+
+func (b *adaptiveBackoff) current() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.delay
+}
+
+func (b *adaptiveBackoff) update(throttled bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if throttled {
+		b.delay *= 2
+		if b.delay < eksMinBackoff {
+			b.delay = eksMinBackoff
+		}
+		if b.delay > eksMaxBackoff {
+			b.delay = eksMaxBackoff
+		}
+	} else {
+		b.delay /= 2
+		if b.delay < eksMinBackoff {
+			b.delay = 0
+		}
+	}
+}
+
+// eksDiscovery discovers EKS clusters in a source, by listing all the clusters and
+// describing them concurrently with the rate limit.
+type eksDiscovery struct {
+	client  eksiface.EKSAPI
+	region  string
+	roleARN string
+
+	concurrency int
+	limiter     flowcontrol.RateLimiter
+	backoff     *adaptiveBackoff
+	cache       *EKSClusterCache
+	stats       *DiscoveryStats
+}
+
+func (g *secretGenerator) newEKSDiscovery(client eksiface.EKSAPI, region, roleARN string) *eksDiscovery {
+	concurrency := g.eksConcurrency
+	if concurrency <= 0 {
+		concurrency = DefaultEKSConcurrency
+	}
+
+	qps := g.eksQPS
+	if qps <= 0 {
+		qps = DefaultEKSQPS
+	}
+
+	stats := g.stats
+	if stats == nil {
+		stats = &DiscoveryStats{}
+	}
+
+	return &eksDiscovery{
+		client:      client,
+		region:      region,
+		roleARN:     roleARN,
+		concurrency: concurrency,
+		limiter:     flowcontrol.NewTokenBucketRateLimiter(qps, concurrency),
+		backoff:     &adaptiveBackoff{sleep: time.Sleep, stats: stats},
+		cache:       g.eksCache,
+		stats:       stats,
+	}
+}
+
+func (d *eksDiscovery) call(f func() error) error {
+	return d.backoff.do(func() error {
+		d.limiter.Accept()
+
+		return f()
+	})
+}
+
+// listClusterNames returns the names of all the clusters, following NextToken until the last page.
+func (d *eksDiscovery) listClusterNames() ([]string, error) {
+	var (
+		names     []string
+		nextToken *string
+	)
+
+	for {
+		var result *eks.ListClustersOutput
+
+		err := d.call(func() error {
+			var err error
+
+			d.stats.inc(&d.stats.ListClustersCalls)
+
+			result, err = d.client.ListClusters(&eks.ListClustersInput{NextToken: nextToken})
+
+			return err
+		})
+		if err != nil {
+			return nil, xerrors.Errorf("listing clusters: %w", err)
+		}
+
+		names = append(names, aws.StringValueSlice(result.Clusters)...)
+
+		if aws.StringValue(result.NextToken) == "" {
+			return names, nil
+		}
+
+		nextToken = result.NextToken
+	}
+}
+
+// describeClusters describes the clusters concurrently.
+// The result for a cluster deleted after being listed is nil.
+func (d *eksDiscovery) describeClusters(names []string) ([]*eks.DescribeClusterOutput, error) {
+	var (
+		results = make([]*eks.DescribeClusterOutput, len(names))
+		errs    = make([]error, len(names))
+		sem     = make(chan struct{}, d.concurrency)
+		wg      sync.WaitGroup
+	)
+
+	for i, name := range names {
+		wg.Add(1)
+
+		sem <- struct{}{}
+
+		go func(i int, name string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			results[i], errs[i] = d.describeCluster(name)
+		}(i, name)
+	}
+
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, xerrors.Errorf("describing cluster %s: %w", names[i], err)
+		}
+	}
+
+	return results, nil
+}
+
+func (d *eksDiscovery) describeCluster(name string) (*eks.DescribeClusterOutput, error) {
+	key := fmt.Sprintf("%s/%s/%s", d.region, d.roleARN, name)
+
+	if result, ok := d.cache.get(key); ok {
+		d.stats.inc(&d.stats.CacheHits)
+
+		return result, nil
+	}
+
+	var result *eks.DescribeClusterOutput
+
+	err := d.call(func() error {
+		var err error
+
+		d.stats.inc(&d.stats.DescribeClusterCalls)
+
+		result, err = d.client.DescribeCluster(&eks.DescribeClusterInput{Name: aws.String(name)})
+
+		return err
+	})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == eks.ErrCodeResourceNotFoundException {
+			log.Printf("Skipping cluster %s deleted after being listed", name)
+
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	d.cache.put(key, result)
+
+	return result, nil
+}
+
+// clusterSecretsFromClusters returns the cluster secrets for the EKS clusters that match the tags,
+// discovered from each source. When no sources are given, clusters are discovered with the default session.
+func (g *secretGenerator) clusterSecretsFromClusters(sel AWSEKSSelector) ([]*corev1.Secret, error) {
+	sources := sel.Sources
+	if len(sources) == 0 {
+		sources = []AWSEKSSource{{}}
+	}
+
+	var secrets []*corev1.Secret
+
+	for _, src := range sources {
+		sess, err := newEKSSourceSession(src)
+		if err != nil {
+			return nil, err
+		}
+
+		region := aws.StringValue(sess.Config.Region)
+
+		log.Printf("Discovering EKS clusters in region %q with role %q...", region, src.RoleARN)
+
+		d := g.newEKSDiscovery(eks.New(sess), region, src.RoleARN)
+
+		secs, err := d.clusterSecrets(g.ns, sel.MatchTags, g.template)
+		if err != nil {
+			return nil, xerrors.Errorf("discovering eks clusters in region %q with role %q: %w", src.Region, src.RoleARN, err)
+		}
+
+		secrets = append(secrets, secs...)
+	}
+
+	return secrets, nil
+}
+
+// newEKSSourceSession returns the session for the region of the source,
+// whose credentials are obtained by assuming the role of the source if any.
+func newEKSSourceSession(src AWSEKSSource) (*session.Session, error) {
+	sess := awsclicompat.NewSession(src.Region, "")
+
+	if src.RoleARN == "" {
+		return sess, nil
+	}
+
+	assumed, _, err := awsclicompat.AssumeRole(sess, awsclicompat.AssumeRoleConfig{
+		RoleARN:     src.RoleARN,
+		ExternalID:  src.ExternalID,
+		SessionName: fmt.Sprintf("okra-%d", time.Now().Unix()),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return assumed, nil
+}
+
+func (d *eksDiscovery) clusterSecrets(ns string, tags map[string]string, tmpl SecretTemplate) ([]*corev1.Secret, error) {
+	log.Printf("Computing desired cluster secrets from EKS clusters...")
+
+	names, err := d.listClusterNames()
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Found %d clusters.", len(names))
+
+	results, err := d.describeClusters(names)
+	if err != nil {
+		return nil, err
+	}
+
+	var secrets []*corev1.Secret
+
+	for i, result := range results {
+		if result == nil {
+			continue
+		}
+
+		clusterName := names[i]
+
+		all := true
+		for k, v := range tags {
+			value := result.Cluster.Tags[k]
+
+			all = all && value != nil && *value == v
+		}
+
+		if !all {
+			log.Printf("Cluster %s with tags %v did not match selector %v", clusterName, aws.StringValueMap(result.Cluster.Tags), tags)
+			continue
+		}
+
+		sec := newClusterSecretFromCluster(ns, clusterName, nil, result, d.roleARN)
+
+		if err := tmpl.apply(sec, eksTemplateData(d.region, result)); err != nil {
+			return nil, xerrors.Errorf("applying template to cluster secret %s: %w", clusterName, err)
+		}
+
+		secrets = append(secrets, sec)
+	}
+
+	return secrets, nil
+}
@@ -0,0 +1,141 @@
+package clusterset
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/eks/eksiface"
+	"github.com/google/go-cmp/cmp"
+)
+
+type fakeEKS struct {
+	eksiface.EKSAPI
+
+	mu sync.Mutex
+	// pages are the names of the clusters returned by ListClusters per page
+	pages [][]string
+	// tags are the tags of the existing clusters
+	tags map[string]map[string]string
+	// throttles is the number of throttling errors returned by DescribeCluster per cluster
+	throttles map[string]int
+}
+
+func (f *fakeEKS) ListClusters(in *eks.ListClustersInput) (*eks.ListClustersOutput, error) {
+	page := 0
+	if in.NextToken != nil {
+		page = len(*in.NextToken)
+	}
+
+	out := &eks.ListClustersOutput{Clusters: aws.StringSlice(f.pages[page])}
+
+	if page+1 < len(f.pages) {
+		token := make([]byte, page+1)
+		for i := range token {
+			token[i] = 't'
+		}
+		out.NextToken = aws.String(string(token))
+	}
+
+	return out, nil
+}
+
+func (f *fakeEKS) DescribeCluster(in *eks.DescribeClusterInput) (*eks.DescribeClusterOutput, error) {
+	name := aws.StringValue(in.Name)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.throttles[name] > 0 {
+		f.throttles[name]--
+
+		return nil, awserr.New("TooManyRequestsException", "rate exceeded", nil)
+	}
+
+	tags, ok := f.tags[name]
+	if !ok {
+		return nil, awserr.New(eks.ErrCodeResourceNotFoundException, "not found", nil)
+	}
+
+	return &eks.DescribeClusterOutput{
+		Cluster: &eks.Cluster{
+			Name:                 aws.String(name),
+			Endpoint:             aws.String("https://" + name + ".example.com"),
+			CertificateAuthority: &eks.Certificate{Data: aws.String("Y2E=")},
+			Tags:                 aws.StringMap(tags),
+		},
+	}, nil
+}
+
+func TestEKSDiscovery(t *testing.T) {
+	client := &fakeEKS{
+		pages: [][]string{{"web1", "web2"}, {"web3", "db1"}, {"deleted"}},
+		tags: map[string]map[string]string{
+			"web1": {"role": "web"},
+			"web2": {"role": "web"},
+			"web3": {"role": "web"},
+			"db1":  {"role": "db"},
+		},
+		throttles: map[string]int{"web2": 2},
+	}
+
+	var stats DiscoveryStats
+
+	g := &secretGenerator{
+		ns:             "argocd",
+		eksConcurrency: 2,
+		eksQPS:         1000,
+		eksCache:       NewEKSClusterCache(time.Minute),
+		stats:          &stats,
+	}
+
+	discover := func() []string {
+		t.Helper()
+
+		d := g.newEKSDiscovery(client, "us-east-2", "")
+		d.backoff.sleep = func(time.Duration) {}
+
+		secrets, err := d.clusterSecrets("argocd", map[string]string{"role": "web"}, SecretTemplate{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var names []string
+
+		for _, s := range secrets {
+			names = append(names, s.Name)
+		}
+
+		sort.Strings(names)
+
+		return names
+	}
+
+	want := []string{"web1", "web2", "web3"}
+
+	if d := cmp.Diff(want, discover()); d != "" {
+		t.Errorf("unexpected clusters: %s", d)
+	}
+
+	if stats.ListClustersCalls != 3 {
+		t.Errorf("expected 3 ListClusters calls to follow NextToken, got %d", stats.ListClustersCalls)
+	}
+
+	// 5 clusters plus 2 retries after throttling errors
+	if stats.DescribeClusterCalls != 7 || stats.Throttles != 2 {
+		t.Errorf("unexpected DescribeCluster calls %d and throttles %d", stats.DescribeClusterCalls, stats.Throttles)
+	}
+
+	if d := cmp.Diff(want, discover()); d != "" {
+		t.Errorf("unexpected clusters from cache: %s", d)
+	}
+
+	// The deleted cluster is not cached and described again
+	if stats.CacheHits != 4 || stats.DescribeClusterCalls != 8 {
+		t.Errorf("unexpected cache hits %d and DescribeCluster calls %d", stats.CacheHits, stats.DescribeClusterCalls)
+	}
+}
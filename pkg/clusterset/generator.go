@@ -0,0 +1,226 @@
+package clusterset
+
+import (
+	"context"
+	"time"
+
+	"github.com/mumoshu/okra/api/v1alpha1"
+	"github.com/mumoshu/okra/pkg/generator"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Generator generates cluster secrets.
+// Exactly one of the fields should be set.
+type Generator struct {
+	AWSEKS     *AWSEKSSelector
+	ClusterAPI *ClusterAPISelector
+	Matrix     []Generator
+	Merge      []Generator
+}
+
+// AWSEKSSelector selects EKS clusters to generate cluster secrets from.
+type AWSEKSSelector struct {
+	MatchTags map[string]string
+	// Sources are the regions and the accounts to discover EKS clusters.
+	// When empty, clusters are discovered with the default session.
+	Sources []AWSEKSSource
+}
+
+// AWSEKSSource is a pair of the region and the optional role to discover EKS clusters.
+type AWSEKSSource struct {
+	Region     string
+	RoleARN    string
+	ExternalID string
+}
+
+// NewGenerators converts ClusterSet generators into Generators.
+// ns is the namespace of the ClusterSet.
+func NewGenerators(ns string, gens []v1alpha1.ClusterGenerator) []Generator {
+	var generators []Generator
+
+	for _, g := range gens {
+		var gen Generator
+
+		if g.Matrix != nil {
+			gen.Matrix = newBaseGenerators(ns, g.Matrix.Generators)
+		} else if g.Merge != nil {
+			gen.Merge = newBaseGenerators(ns, g.Merge.Generators)
+		} else {
+			gen = newBaseGenerator(ns, g.ClusterBaseGenerator)
+		}
+
+		generators = append(generators, gen)
+	}
+
+	return generators
+}
+
+func newBaseGenerators(ns string, gens []v1alpha1.ClusterBaseGenerator) []Generator {
+	var generators []Generator
+
+	for _, g := range gens {
+		generators = append(generators, newBaseGenerator(ns, g))
+	}
+
+	return generators
+}
+
+func newBaseGenerator(ns string, g v1alpha1.ClusterBaseGenerator) Generator {
+	var gen Generator
+
+	if g.ClusterAPI != nil {
+		capiNS := g.ClusterAPI.Namespace
+		if capiNS == "" {
+			capiNS = ns
+		}
+
+		gen.ClusterAPI = &ClusterAPISelector{
+			NS:          capiNS,
+			MatchLabels: g.ClusterAPI.Selector.MatchLabels,
+		}
+	} else if g.AWSEKS != nil {
+		gen.AWSEKS = &AWSEKSSelector{
+			MatchTags: g.AWSEKS.Selector.MatchTags,
+		}
+
+		for _, src := range g.AWSEKS.Sources {
+			gen.AWSEKS.Sources = append(gen.AWSEKS.Sources, AWSEKSSource{
+				Region:     src.Region,
+				RoleARN:    src.RoleARN,
+				ExternalID: src.ExternalID,
+			})
+		}
+	}
+
+	return gen
+}
+
+// generators returns the generators in the config.
+// When no generators are given, it falls back to the EKS or Cluster API selector in the config.
+func (config SyncInput) generators() []Generator {
+	if len(config.Generators) > 0 {
+		return config.Generators
+	}
+
+	if config.ClusterAPI != nil {
+		return []Generator{{ClusterAPI: config.ClusterAPI}}
+	}
+
+	return []Generator{{AWSEKS: &AWSEKSSelector{MatchTags: config.EKSTags, Sources: config.EKSSources}}}
+}
+
+// desiredClusterSecrets returns the union of the cluster secrets generated by all the generators.
+func desiredClusterSecrets(config SyncInput) ([]*corev1.Secret, error) {
+	g := &secretGenerator{
+		ctx:            config.context(),
+		ns:             config.NS,
+		template:       config.template(),
+		eksConcurrency: config.EKSConcurrency,
+		eksQPS:         config.EKSQPS,
+		eksCache:       config.EKSCache,
+		stats:          config.Stats,
+		client:         config.Client,
+	}
+
+	if config.Stats != nil {
+		start := time.Now()
+		defer func() {
+			config.Stats.Duration += time.Since(start)
+		}()
+	}
+
+	var results [][]metav1.Object
+
+	for _, gen := range config.generators() {
+		objs, err := g.generate(gen)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, objs)
+	}
+
+	var secrets []*corev1.Secret
+
+	for _, o := range generator.Union(results...) {
+		secrets = append(secrets, o.(*corev1.Secret))
+	}
+
+	return secrets, nil
+}
+
+type secretGenerator struct {
+	ctx      context.Context
+	ns       string
+	template SecretTemplate
+
+	eksConcurrency int
+	eksQPS         float32
+	eksCache       *EKSClusterCache
+	stats          *DiscoveryStats
+
+	// client reads Cluster API clusters and their kubeconfig secrets from the management cluster
+	client client.Client
+}
+
+func (g *secretGenerator) generate(gen Generator) ([]metav1.Object, error) {
+	switch {
+	case len(gen.Matrix) > 0:
+		results, err := g.generateAll(gen.Matrix)
+		if err != nil {
+			return nil, err
+		}
+
+		return generator.Matrix(results...), nil
+	case len(gen.Merge) > 0:
+		results, err := g.generateAll(gen.Merge)
+		if err != nil {
+			return nil, err
+		}
+
+		return generator.Merge(results...), nil
+	case gen.ClusterAPI != nil:
+		secrets, err := clusterSecretsFromClusterAPI(g.ctx, g.client, g.ns, *gen.ClusterAPI, g.template)
+		if err != nil {
+			return nil, err
+		}
+
+		return toObjects(secrets), nil
+	case gen.AWSEKS != nil:
+		secrets, err := g.clusterSecretsFromClusters(*gen.AWSEKS)
+		if err != nil {
+			return nil, err
+		}
+
+		return toObjects(secrets), nil
+	}
+
+	return nil, nil
+}
+
+func (g *secretGenerator) generateAll(gens []Generator) ([][]metav1.Object, error) {
+	var results [][]metav1.Object
+
+	for _, gen := range gens {
+		objs, err := g.generate(gen)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, objs)
+	}
+
+	return results, nil
+}
+
+func toObjects(secrets []*corev1.Secret) []metav1.Object {
+	var objs []metav1.Object
+
+	for _, s := range secrets {
+		objs = append(objs, s)
+	}
+
+	return objs
+}
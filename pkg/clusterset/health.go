@@ -0,0 +1,144 @@
+package clusterset
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mumoshu/okra/api/v1alpha1"
+	"github.com/mumoshu/okra/pkg/clclient"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultHealthCheckInterval is the default interval between connectivity checks against clusters
+	DefaultHealthCheckInterval = time.Minute
+	// DefaultHealthCheckTimeout is the default timeout of the requests made to check the connectivity to a cluster
+	DefaultHealthCheckTimeout = 10 * time.Second
+
+	healthCheckConcurrency = 8
+)
+
+// HealthCheckInput is the input to CheckHealth.
+type HealthCheckInput struct {
+	// Secrets are the cluster secrets to check the connectivity with
+	Secrets []corev1.Secret
+	Timeout time.Duration
+	// Previous is the result of the last check, used to detect transitions
+	Previous []v1alpha1.ClusterHealth
+	Now      metav1.Time
+
+	// probe defaults to clclient.Probe. Overridden in tests.
+	probe func(context.Context, corev1.Secret, time.Duration) (string, error)
+}
+
+// HealthTransition is a change of the connection status of a cluster.
+type HealthTransition struct {
+	Name    string
+	Status  string
+	Message string
+}
+
+// CheckHealth probes all the clusters concurrently and returns the results sorted by cluster name,
+// along with the transitions from the previous results.
+// A cluster that is unreachable on its first check is reported as a transition, too.
+func CheckHealth(ctx context.Context, in HealthCheckInput) ([]v1alpha1.ClusterHealth, []HealthTransition) {
+	probe := in.probe
+	if probe == nil {
+		probe = clclient.Probe
+	}
+
+	timeout := in.Timeout
+	if timeout <= 0 {
+		timeout = DefaultHealthCheckTimeout
+	}
+
+	previous := map[string]v1alpha1.ClusterHealth{}
+	for _, h := range in.Previous {
+		previous[h.Name] = h
+	}
+
+	var (
+		results = make([]v1alpha1.ClusterHealth, len(in.Secrets))
+		sem     = make(chan struct{}, healthCheckConcurrency)
+		wg      sync.WaitGroup
+	)
+
+	for i, sec := range in.Secrets {
+		wg.Add(1)
+
+		sem <- struct{}{}
+
+		go func(i int, sec corev1.Secret) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			h := v1alpha1.ClusterHealth{
+				Name:          sec.Name,
+				Status:        v1alpha1.ClusterConnectionStatusSuccessful,
+				LastProbeTime: in.Now,
+			}
+
+			version, err := probe(ctx, sec, timeout)
+			if err != nil {
+				h.Status = v1alpha1.ClusterConnectionStatusFailed
+				h.Message = err.Error()
+			} else {
+				h.ServerVersion = version
+			}
+
+			results[i] = h
+		}(i, sec)
+	}
+
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Name < results[j].Name
+	})
+
+	var transitions []HealthTransition
+
+	for i, h := range results {
+		prev, ok := previous[h.Name]
+
+		if ok && prev.Status == h.Status {
+			results[i].LastTransitionTime = prev.LastTransitionTime
+
+			continue
+		}
+
+		results[i].LastTransitionTime = in.Now
+
+		if ok || h.Status == v1alpha1.ClusterConnectionStatusFailed {
+			transitions = append(transitions, HealthTransition{Name: h.Name, Status: h.Status, Message: h.Message})
+		}
+	}
+
+	return results, transitions
+}
+
+// HealthCheckInterval returns the interval between the connectivity checks configured for the ClusterSet,
+// or zero when the checks are disabled.
+func HealthCheckInterval(hc *v1alpha1.ClusterSetHealthCheck) time.Duration {
+	if hc == nil {
+		return 0
+	}
+
+	if hc.Interval != nil && hc.Interval.Duration > 0 {
+		return hc.Interval.Duration
+	}
+
+	return DefaultHealthCheckInterval
+}
+
+// HealthCheckTimeout returns the timeout of the connectivity checks configured for the ClusterSet.
+func HealthCheckTimeout(hc *v1alpha1.ClusterSetHealthCheck) time.Duration {
+	if hc == nil || hc.Timeout == nil || hc.Timeout.Duration <= 0 {
+		return DefaultHealthCheckTimeout
+	}
+
+	return hc.Timeout.Duration
+}
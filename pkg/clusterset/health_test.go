@@ -0,0 +1,62 @@
+package clusterset
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mumoshu/okra/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCheckHealth(t *testing.T) {
+	t0 := metav1.NewTime(time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC))
+	t1 := metav1.NewTime(t0.Add(time.Minute))
+
+	secret := func(name string) corev1.Secret {
+		return corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	}
+
+	unreachable := map[string]bool{"web2": true, "web3": true}
+
+	probe := func(ctx context.Context, sec corev1.Secret, timeout time.Duration) (string, error) {
+		if unreachable[sec.Name] {
+			return "", errors.New("connection refused")
+		}
+
+		return "v1.21.2", nil
+	}
+
+	health, transitions := CheckHealth(context.Background(), HealthCheckInput{
+		Secrets: []corev1.Secret{secret("web3"), secret("web2"), secret("web1")},
+		Previous: []v1alpha1.ClusterHealth{
+			{Name: "web1", Status: v1alpha1.ClusterConnectionStatusFailed, LastTransitionTime: t0},
+			{Name: "web2", Status: v1alpha1.ClusterConnectionStatusFailed, LastTransitionTime: t0},
+		},
+		Now:   t1,
+		probe: probe,
+	})
+
+	wantHealth := []v1alpha1.ClusterHealth{
+		{Name: "web1", Status: v1alpha1.ClusterConnectionStatusSuccessful, ServerVersion: "v1.21.2", LastProbeTime: t1, LastTransitionTime: t1},
+		{Name: "web2", Status: v1alpha1.ClusterConnectionStatusFailed, Message: "connection refused", LastProbeTime: t1, LastTransitionTime: t0},
+		{Name: "web3", Status: v1alpha1.ClusterConnectionStatusFailed, Message: "connection refused", LastProbeTime: t1, LastTransitionTime: t1},
+	}
+
+	if d := cmp.Diff(wantHealth, health); d != "" {
+		t.Errorf("unexpected health: %s", d)
+	}
+
+	// web1 recovered and web3 is unreachable on its first check, whereas web2 is still unreachable
+	wantTransitions := []HealthTransition{
+		{Name: "web1", Status: v1alpha1.ClusterConnectionStatusSuccessful},
+		{Name: "web3", Status: v1alpha1.ClusterConnectionStatusFailed, Message: "connection refused"},
+	}
+
+	if d := cmp.Diff(wantTransitions, transitions); d != "" {
+		t.Errorf("unexpected transitions: %s", d)
+	}
+}
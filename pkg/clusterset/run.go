@@ -11,7 +11,9 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/mumoshu/okra/api/v1alpha1"
 	"github.com/mumoshu/okra/pkg/awsclicompat"
+	"github.com/mumoshu/okra/pkg/cell"
 	"github.com/mumoshu/okra/pkg/clclient"
 	"github.com/mumoshu/okra/pkg/okraerror"
 	"golang.org/x/xerrors"
@@ -23,6 +25,8 @@ import (
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/util/homedir"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/yaml"
 )
 
@@ -52,10 +56,59 @@ type CreateClusterInput struct {
 }
 
 type SyncInput struct {
-	DryRun  bool
-	NS      string
-	Labels  map[string]string
-	EKSTags map[string]string
+	DryRun bool
+	NS     string
+	Labels map[string]string
+	// Template is the template of the cluster secrets, whose values can be Go templates.
+	// Labels are merged into the template labels.
+	Template SecretTemplate
+	EKSTags  map[string]string
+	// EKSSources are the regions and the accounts to discover EKS clusters that match EKSTags
+	EKSSources []AWSEKSSource
+	// ClusterAPI selects Cluster API clusters to generate cluster secrets from.
+	// When nil, cluster secrets are generated from EKS clusters that match EKSTags.
+	ClusterAPI *ClusterAPISelector
+	// Generators generate cluster secrets. The union of the generated cluster secrets are synced.
+	// When empty, ClusterAPI or EKSTags is used instead.
+	Generators []Generator
+	// EKSConcurrency is the number of concurrent DescribeCluster calls per source. Defaults to DefaultEKSConcurrency.
+	EKSConcurrency int
+	// EKSQPS is the rate limit of EKS API calls per source. Defaults to DefaultEKSQPS.
+	EKSQPS float32
+	// EKSCache caches DescribeCluster results across syncs. Clusters are described on every sync when nil.
+	EKSCache *EKSClusterCache
+	// Stats, when set, collects the stats of the EKS API calls made in the sync
+	Stats *DiscoveryStats
+	// ForceDelete deletes outdated cluster secrets even when their clusters still receive traffic.
+	// A cluster secret annotated with okra.mumo.co/force-delete=true is deleted regardless of this.
+	ForceDelete bool
+
+	// Context defaults to context.Background()
+	Context context.Context
+	// Client is the client for the management cluster, like the cached client of the controller manager.
+	// Defaults to an uncached client created from the kubeconfig or the in-cluster config.
+	Client client.Client
+}
+
+func (config SyncInput) context() context.Context {
+	if config.Context != nil {
+		return config.Context
+	}
+
+	return context.Background()
+}
+
+// withClient returns the config with the client for the management cluster, creating one only when none is given,
+// so that a sync shares a single client across the discovery, creations, and deletions.
+func (config SyncInput) withClient() (SyncInput, error) {
+	c, err := newManagementClient(config.Client)
+	if err != nil {
+		return config, xerrors.Errorf("creating client: %w", err)
+	}
+
+	config.Client = c
+
+	return config, nil
 }
 
 type DeleteClusterInput struct {
@@ -93,7 +146,7 @@ func CreateCluster(config CreateClusterInput) error {
 			return err
 		}
 	} else {
-		object = newClusterSecretFromValues(ns, name, labels, endpoint, caData)
+		object = newClusterSecretFromValues(ns, name, labels, endpoint, caData, "")
 	}
 
 	if dryRun {
@@ -118,42 +171,165 @@ func CreateCluster(config CreateClusterInput) error {
 	return nil
 }
 
-func CreateMissingClusters(config SyncInput) error {
-	ns := config.NS
-	dryRun := config.DryRun
-
-	clientset, err := newClientset()
+// CreateMissingClusters creates the cluster secrets for the discovered clusters that are missing.
+// It returns an error when any of the cluster secrets failed to be created, along with the results for all the clusters.
+func CreateMissingClusters(config SyncInput) ([]SyncResult, error) {
+	config, err := config.withClient()
 	if err != nil {
-		return xerrors.Errorf("creating clientset: %w", err)
+		return nil, err
 	}
 
-	kubeclient := clientset.CoreV1().Secrets(ns)
+	objects, err := desiredClusterSecrets(config)
+	if err != nil {
+		return nil, err
+	}
 
-	objects, err := clusterSecretsFromClusters(ns, config.EKSTags, config.Labels)
+	results, err := createMissingClusters(config, objects)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	return results, resultsError(results)
+}
+
+func createMissingClusters(config SyncInput, objects []*corev1.Secret) ([]SyncResult, error) {
+	ctx := config.context()
+	dryRun := config.DryRun
+
+	c := config.Client
+
+	var results []SyncResult
+
 	for _, object := range objects {
+		result := SyncResult{Name: object.Name, Action: ActionCreate}
+
+		// The finalizer is removed only when the cluster no longer receives traffic, or the deletion is forced
+		controllerutil.AddFinalizer(object, v1alpha1.FinalizerTrafficGuard)
+
+		var current corev1.Secret
+
+		if err := c.Get(ctx, client.ObjectKey{Namespace: object.Namespace, Name: object.Name}, &current); err != nil {
+			if !kerrors.IsNotFound(err) {
+				result.Err = okraerror.New(xerrors.Errorf("getting cluster secret: %w", err))
+				results = append(results, result)
+				continue
+			}
+		} else if !current.DeletionTimestamp.IsZero() {
+			// The cluster secret was deleted by hand. It is recreated in the next sync once the deletion is done.
+			result.Action = ActionUnchanged
+			results = append(results, result)
+			continue
+		} else if updateClusterSecret(&current, object) {
+			result.Action = ActionUpdate
+		} else {
+			fmt.Printf("Cluster secret %q has no change\n", object.Name)
+
+			result.Action = ActionUnchanged
+			results = append(results, result)
+			continue
+		}
+
+		verb := "created"
+		if result.Action == ActionUpdate {
+			verb = "updated"
+		}
+
 		// Manage resource
 		if !dryRun {
-			_, err := kubeclient.Create(context.TODO(), object, metav1.CreateOptions{})
+			var err error
+
+			if result.Action == ActionCreate {
+				err = c.Create(ctx, object)
+			} else {
+				err = c.Update(ctx, &current)
+			}
+
 			if err != nil {
-				if kerrors.IsAlreadyExists(err) {
-					fmt.Printf("Cluster secret %q has no change\n", object.Name)
-				} else {
-					fmt.Fprintf(os.Stderr, "Failed creating object: %+v\n", object)
-					return okraerror.New(err)
-				}
+				fmt.Fprintf(os.Stderr, "Failed syncing object: %+v\n", object)
+
+				result.Err = okraerror.New(err)
 			} else {
-				fmt.Printf("Cluster secret %q created successfully\n", object.Name)
+				fmt.Printf("Cluster secret %q %s successfully\n", object.Name, verb)
 			}
 		} else {
-			fmt.Printf("Cluster secret %q created successfully (Dry Run)\n", object.Name)
+			fmt.Printf("Cluster secret %q %s successfully (Dry Run)\n", object.Name, verb)
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// clusterSecretDataKeys are the keys of the cluster secret data that are managed by okra.
+// A key that is no longer rendered from the template, like project, is removed from the existing cluster secret.
+var clusterSecretDataKeys = []string{"name", "server", "config", "project", "namespaces", "clusterResources"}
+
+// updateClusterSecret updates the existing cluster secret to have the labels, the annotations, the finalizers,
+// and the data of the desired one. It returns true when anything changed.
+// Labels and annotations not in the desired cluster secret are kept, as they can be set by others, like on decommission.
+func updateClusterSecret(current, desired *corev1.Secret) bool {
+	var changed bool
+
+	for k, v := range desired.Labels {
+		if cur, ok := current.Labels[k]; !ok || cur != v {
+			if current.Labels == nil {
+				current.Labels = map[string]string{}
+			}
+
+			current.Labels[k] = v
+			changed = true
 		}
 	}
 
-	return nil
+	for k, v := range desired.Annotations {
+		if cur, ok := current.Annotations[k]; !ok || cur != v {
+			metav1.SetMetaDataAnnotation(&current.ObjectMeta, k, v)
+			changed = true
+		}
+	}
+
+	for _, f := range desired.Finalizers {
+		if !controllerutil.ContainsFinalizer(current, f) {
+			controllerutil.AddFinalizer(current, f)
+			changed = true
+		}
+	}
+
+	data := map[string][]byte{}
+
+	for k, v := range desired.Data {
+		data[k] = v
+	}
+
+	for k, v := range desired.StringData {
+		data[k] = []byte(v)
+	}
+
+	if current.Data == nil {
+		current.Data = map[string][]byte{}
+	}
+
+	for _, k := range clusterSecretDataKeys {
+		want, ok := data[k]
+		cur, exists := current.Data[k]
+
+		if !ok {
+			if exists {
+				delete(current.Data, k)
+				changed = true
+			}
+
+			continue
+		}
+
+		if !exists || string(cur) != string(want) {
+			current.Data[k] = want
+			changed = true
+		}
+	}
+
+	return changed
 }
 
 func DeleteCluster(config DeleteClusterInput) error {
@@ -185,35 +361,51 @@ func DeleteCluster(config DeleteClusterInput) error {
 	return nil
 }
 
-func DeleteOutdatedClusters(config SyncInput) error {
-	ns := config.NS
-	dryRun := config.DryRun
+// DeleteOutdatedClusters deletes the cluster secrets for the clusters that are no longer discovered.
+// It returns an error when any of the cluster secrets failed to be deleted, along with the results for all the clusters.
+func DeleteOutdatedClusters(config SyncInput) ([]SyncResult, error) {
+	config, err := config.withClient()
+	if err != nil {
+		return nil, err
+	}
 
-	clientset, err := newClientset()
+	objects, err := desiredClusterSecrets(config)
 	if err != nil {
-		return xerrors.Errorf("creating clientset: %w", err)
+		return nil, err
 	}
 
-	kubeclient := clientset.CoreV1().Secrets(ns)
+	results, err := deleteOutdatedClusters(config, objects)
+	if err != nil {
+		return nil, err
+	}
+
+	return results, resultsError(results)
+}
+
+func deleteOutdatedClusters(config SyncInput, objects []*corev1.Secret) ([]SyncResult, error) {
+	ctx := config.context()
+	ns := config.NS
+	dryRun := config.DryRun
+
+	c := config.Client
 
 	labelSelectors := []string{
 		fmt.Sprintf("%s=%s", SecretLabelKeyArgoCDType, SecretLabelValueArgoCDCluster),
 	}
 
-	for k, v := range config.Labels {
+	for k, v := range config.template().staticLabels() {
 		labelSelectors = append(labelSelectors, fmt.Sprintf("%s=%s", k, v))
 	}
 
-	result, err := kubeclient.List(context.TODO(), metav1.ListOptions{
-		LabelSelector: strings.Join(labelSelectors, ","),
-	})
+	sel, err := labels.Parse(strings.Join(labelSelectors, ","))
 	if err != nil {
-		return xerrors.Errorf("listing cluster secrets: %w", err)
+		return nil, xerrors.Errorf("parsing cluster secret selector: %w", err)
 	}
 
-	objects, err := clusterSecretsFromClusters(ns, config.EKSTags, config.Labels)
-	if err != nil {
-		return err
+	var result corev1.SecretList
+
+	if err := c.List(ctx, &result, &client.ListOptions{Namespace: ns, LabelSelector: sel}); err != nil {
+		return nil, xerrors.Errorf("listing cluster secrets: %w", err)
 	}
 
 	desiredClusters := map[string]struct{}{}
@@ -222,41 +414,119 @@ func DeleteOutdatedClusters(config SyncInput) error {
 		desiredClusters[obj.Name] = struct{}{}
 	}
 
+	var (
+		results []SyncResult
+		guard   *cell.TrafficGuard
+	)
+
 	for _, item := range result.Items {
 		name := item.Name
 
-		if _, desired := desiredClusters[name]; !desired {
-			if dryRun {
-				fmt.Printf("Cluster secret %q deleted successfully (Dry Run)\n", name)
-			} else {
-				// Manage resource
-				err := kubeclient.Delete(context.TODO(), name, metav1.DeleteOptions{})
+		_, desired := desiredClusters[name]
+		terminating := !item.DeletionTimestamp.IsZero()
+
+		// A cluster secret of a desired cluster is deleted only when someone deleted it by hand.
+		// It is recreated in the next sync once the deletion is done.
+		if (desired && !terminating) || (terminating && !controllerutil.ContainsFinalizer(&item, v1alpha1.FinalizerTrafficGuard)) {
+			continue
+		}
+
+		r := SyncResult{Name: name, Action: ActionDelete}
+
+		if !config.ForceDelete && !cell.ForceDeleteRequested(item.Annotations) {
+			if guard == nil {
+				guard, err = cell.NewTrafficGuard(ctx, c)
 				if err != nil {
-					return err
+					return nil, err
 				}
+			}
+
+			if err := guard.CheckCluster(ctx, ns, name); err != nil {
+				fmt.Printf("Cluster secret %q is not deleted: %v\n", name, err)
+
+				r.Err = err
+				results = append(results, r)
+
+				continue
+			}
+		}
 
+		if dryRun {
+			fmt.Printf("Cluster secret %q deleted successfully (Dry Run)\n", name)
+		} else {
+			// Manage resource
+			item := item
+
+			if err := deleteClusterSecret(ctx, c, &item); err != nil {
+				r.Err = err
+			} else {
 				fmt.Printf("Cluster secret %q deleted successfully\n", name)
 			}
 		}
+
+		results = append(results, r)
+	}
+
+	return results, nil
+}
+
+// deleteClusterSecret removes the traffic guard finalizer from the cluster secret and deletes it, unless it is already being deleted.
+func deleteClusterSecret(ctx context.Context, c client.Client, sec *corev1.Secret) error {
+	if controllerutil.ContainsFinalizer(sec, v1alpha1.FinalizerTrafficGuard) {
+		controllerutil.RemoveFinalizer(sec, v1alpha1.FinalizerTrafficGuard)
+
+		if err := c.Update(ctx, sec); err != nil {
+			return xerrors.Errorf("removing finalizer from cluster secret: %w", err)
+		}
+	}
+
+	if !sec.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	if err := c.Delete(ctx, sec); err != nil && !kerrors.IsNotFound(err) {
+		return err
 	}
 
 	return nil
 }
 
+// newManagementClient returns the client when it is given, or a new uncached client for the management cluster.
+func newManagementClient(c client.Client) (client.Client, error) {
+	if c != nil {
+		return c, nil
+	}
+
+	restConfig, err := newRestConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return clclient.NewFromRestConfig(restConfig)
+}
+
 type ListClustersInput struct {
 	NS       string
 	Selector string
+
+	// Context defaults to context.Background()
+	Context context.Context
+	// Client defaults to an uncached client for the management cluster
+	Client client.Client
 }
 
 func ListClusters(config ListClustersInput) ([]clclient.Cluster, error) {
 	ns := config.NS
 
-	clientset, err := newClientset()
-	if err != nil {
-		return nil, xerrors.Errorf("creating clientset: %w", err)
+	ctx := config.Context
+	if ctx == nil {
+		ctx = context.Background()
 	}
 
-	kubeclient := clientset.CoreV1().Secrets(ns)
+	c, err := newManagementClient(config.Client)
+	if err != nil {
+		return nil, xerrors.Errorf("creating client: %w", err)
+	}
 
 	selStr := config.Selector
 	if selStr != "" {
@@ -270,10 +540,9 @@ func ListClusters(config ListClustersInput) ([]clclient.Cluster, error) {
 		return nil, err
 	}
 
-	result, err := kubeclient.List(context.TODO(), metav1.ListOptions{
-		LabelSelector: sel.String(),
-	})
-	if err != nil {
+	var result corev1.SecretList
+
+	if err := c.List(ctx, &result, &client.ListOptions{Namespace: ns, LabelSelector: sel}); err != nil {
 		return nil, xerrors.Errorf("listing cluster secrets: %w", err)
 	}
 
@@ -291,83 +560,34 @@ func ListClusters(config ListClustersInput) ([]clclient.Cluster, error) {
 	return clusters, nil
 }
 
-func Sync(config SyncInput) error {
-	if err := CreateMissingClusters(config); err != nil {
-		return xerrors.Errorf("creating missing cluster secrets: %w", err)
+// Sync creates missing cluster secrets and deletes outdated ones.
+// It returns the results for all the clusters, and an error when the clusters could not be discovered
+// or any of the cluster secrets failed to be synced.
+func Sync(config SyncInput) ([]SyncResult, error) {
+	config, err := config.withClient()
+	if err != nil {
+		return nil, err
 	}
 
-	if err := DeleteOutdatedClusters(config); err != nil {
-		return xerrors.Errorf("deleting redundant cluster secrets: %w", err)
+	objects, err := desiredClusterSecrets(config)
+	if err != nil {
+		return nil, xerrors.Errorf("discovering clusters: %w", err)
 	}
 
-	return nil
-}
-
-func clusterSecretsFromClusters(ns string, tags, labels map[string]string) ([]*corev1.Secret, error) {
-	sess := awsclicompat.NewSession("", "")
-
-	eksClient := eks.New(sess)
-
-	var secrets []*corev1.Secret
-
-	process := func(nextToken *string) (*string, error) {
-		log.Printf("Calling EKS ListClusters...")
-
-		result, err := eksClient.ListClusters(&eks.ListClustersInput{
-			NextToken: nextToken,
-		})
-
-		if err != nil {
-			return nil, xerrors.Errorf("listing clusters: %w", err)
-		}
-
-		log.Printf("Found %d clusters.", len(result.Clusters))
-
-		for _, clusterName := range result.Clusters {
-			log.Printf("Checking cluster %s...", *clusterName)
-
-			result, err := eksClient.DescribeCluster(&eks.DescribeClusterInput{Name: aws.String(*clusterName)})
-			if err != nil {
-				return nil, xerrors.Errorf("creating cluster secret: %w", err)
-			}
-
-			all := true
-			for k, v := range tags {
-				value := result.Cluster.Tags[k]
-
-				all = all && value != nil && *value == v
-			}
-
-			if all {
-				sec := newClusterSecretFromCluster(ns, *clusterName, labels, result)
-
-				secrets = append(secrets, sec)
-			} else {
-				log.Printf("Cluster %s with tags %v did not match selector %v", *clusterName, result.Cluster.Tags, tags)
-			}
-		}
-
-		return result.NextToken, nil
+	created, err := createMissingClusters(config, objects)
+	if err != nil {
+		return nil, xerrors.Errorf("creating missing cluster secrets: %w", err)
 	}
 
-	log.Printf("Computing desired cluster secrets from EKS clusters...")
-
-	nextToken, err := process(nil)
+	deleted, err := deleteOutdatedClusters(config, objects)
 	if err != nil {
-		return nil, xerrors.Errorf("processing first set of EKS clusters: %w", err)
+		return created, xerrors.Errorf("deleting redundant cluster secrets: %w", err)
 	}
 
-	for nextToken = nil; nextToken != nil; {
-		var err error
-
-		nextToken, err = process(nextToken)
+	all := append([]SyncResult{}, created...)
+	all = append(all, deleted...)
 
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	return secrets, nil
+	return all, resultsError(all)
 }
 
 func newClusterSecretFromName(ns, name string, labels map[string]string) (*corev1.Secret, error) {
@@ -394,11 +614,11 @@ func newClusterSecretFromName(ns, name string, labels map[string]string) (*corev
 		return nil, okraerror.New(fmt.Errorf("%w", err))
 	}
 
-	return newClusterSecretFromCluster(ns, name, labels, result), nil
+	return newClusterSecretFromCluster(ns, name, labels, result, ""), nil
 }
 
-func newClusterSecretFromCluster(ns, name string, labels map[string]string, result *eks.DescribeClusterOutput) *corev1.Secret {
-	return newClusterSecretFromValues(ns, name, labels, *result.Cluster.Endpoint, *result.Cluster.CertificateAuthority.Data)
+func newClusterSecretFromCluster(ns, name string, labels map[string]string, result *eks.DescribeClusterOutput, roleARN string) *corev1.Secret {
+	return newClusterSecretFromValues(ns, name, labels, *result.Cluster.Endpoint, *result.Cluster.CertificateAuthority.Data, roleARN)
 }
 
 const (
@@ -406,7 +626,9 @@ const (
 	SecretLabelValueArgoCDCluster = "cluster"
 )
 
-func newClusterSecretFromValues(ns, name string, labels map[string]string, server, base64CA string) *corev1.Secret {
+// newClusterSecretFromValues returns the ArgoCD cluster secret for the EKS cluster.
+// roleARN is the optional role to be assumed to obtain the token for the cluster, used for clusters in other accounts.
+func newClusterSecretFromValues(ns, name string, labels map[string]string, server, base64CA, roleARN string) *corev1.Secret {
 	lbls := map[string]string{
 		SecretLabelKeyArgoCDType: SecretLabelValueArgoCDCluster,
 	}
@@ -415,6 +637,12 @@ func newClusterSecretFromValues(ns, name string, labels map[string]string, serve
 		lbls[k] = v
 	}
 
+	var roleARNField string
+	if roleARN != "" {
+		roleARNField = fmt.Sprintf(`,
+        "roleARN": "%s"`, roleARN)
+	}
+
 	// Create resource object
 	object := &corev1.Secret{
 		TypeMeta: metav1.TypeMeta{
@@ -431,14 +659,14 @@ func newClusterSecretFromValues(ns, name string, labels map[string]string, serve
 			"server": server,
 			"config": fmt.Sprintf(`{
       "awsAuthConfig": {
-        "clusterName": "%s"
+        "clusterName": "%s"%s
       },
       "tlsClientConfig": {
         "insecure": false,
         "caData": "%s"
       }
     }
-`, name, base64CA),
+`, name, roleARNField, base64CA),
 		},
 	}
 
@@ -446,6 +674,20 @@ func newClusterSecretFromValues(ns, name string, labels map[string]string, serve
 }
 
 func newClientset() (*kubernetes.Clientset, error) {
+	config, err := newRestConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, xerrors.Errorf("new for config: %w", err)
+	}
+
+	return clientset, nil
+}
+
+func newRestConfig() (*rest.Config, error) {
 	var kubeconfig string
 	kubeconfig, ok := os.LookupEnv("KUBECONFIG")
 	if !ok {
@@ -474,10 +716,5 @@ func newClientset() (*kubernetes.Clientset, error) {
 		}
 	}
 
-	clientset, err := kubernetes.NewForConfig(config)
-	if err != nil {
-		return nil, xerrors.Errorf("new for config: %w", err)
-	}
-
-	return clientset, nil
+	return config, nil
 }
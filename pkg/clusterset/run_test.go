@@ -0,0 +1,266 @@
+package clusterset
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mumoshu/okra/api/v1alpha1"
+	"github.com/mumoshu/okra/pkg/cell"
+	"github.com/mumoshu/okra/pkg/clclient"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	crfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+func TestNewClusterSecretFromValues(t *testing.T) {
+	testcases := []struct {
+		name    string
+		roleARN string
+		want    clclient.AWSAuthConfig
+	}{
+		{
+			name: "same account",
+			want: clclient.AWSAuthConfig{ClusterName: "web1"},
+		},
+		{
+			name:    "cross account",
+			roleARN: "arn:aws:iam::123456789012:role/okra",
+			want:    clclient.AWSAuthConfig{ClusterName: "web1", RoleARN: "arn:aws:iam::123456789012:role/okra"},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			sec := newClusterSecretFromValues("argocd", "web1", map[string]string{"role": "web"}, "https://web1.example.com", "Y2E=", tc.roleARN)
+
+			var config clclient.ClusterConfig
+			if err := json.Unmarshal([]byte(sec.StringData["config"]), &config); err != nil {
+				t.Fatalf("unmarshalling config: %v", err)
+			}
+
+			if config.AWSAuthConfig == nil {
+				t.Fatal("missing awsAuthConfig")
+			}
+
+			if d := cmp.Diff(tc.want, *config.AWSAuthConfig); d != "" {
+				t.Errorf("unexpected awsAuthConfig: %s", d)
+			}
+
+			if got := string(config.TLSClientConfig.CAData); got != "ca" {
+				t.Errorf("unexpected caData: %q", got)
+			}
+		})
+	}
+}
+
+func TestSyncClusterSecretsWithClient(t *testing.T) {
+	labels := map[string]string{"role": "web"}
+
+	desiredSecret := func(name, server string) *corev1.Secret {
+		return newClusterSecretFromValues("default", name, labels, server, "Y2E=", "")
+	}
+
+	// existing returns the cluster secret as read from the API server, which has data instead of stringData
+	existing := func(name, server string) *corev1.Secret {
+		sec := desiredSecret(name, server)
+		sec.Finalizers = []string{v1alpha1.FinalizerTrafficGuard}
+		sec.Data = map[string][]byte{}
+
+		for k, v := range sec.StringData {
+			sec.Data[k] = []byte(v)
+		}
+
+		sec.StringData = nil
+
+		return sec
+	}
+
+	c := crfake.NewFakeClientWithScheme(clclient.Scheme(),
+		existing("web1", "https://web1.example.com"),
+		existing("web2", "https://web2.example.com"),
+		existing("web3", "https://web3.example.com"),
+		existing("web5", "https://web5-old.example.com"),
+		&v1alpha1.AWSApplicationLoadBalancerConfig{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+			Spec: v1alpha1.AWSApplicationLoadBalancerConfigSpec{
+				Listener: v1alpha1.Listener{
+					Rule: v1alpha1.ListenerRule{
+						Forward: v1alpha1.Forward{
+							TargetGroups: []v1alpha1.ForwardTargetGroup{
+								{Name: "web2-tg", ARN: "arn:tg2", Weight: 100},
+							},
+						},
+					},
+				},
+			},
+		},
+		&v1alpha1.AWSTargetGroup{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      "web2-tg",
+				Labels:    map[string]string{v1alpha1.AWSTargetGroupLabelBindingCluster: "web2"},
+			},
+			Spec: v1alpha1.AWSTargetGroupSpec{ARN: "arn:tg2"},
+		},
+	)
+
+	config := SyncInput{
+		NS:      "default",
+		Labels:  labels,
+		Context: context.Background(),
+		Client:  c,
+	}
+
+	desired := []*corev1.Secret{
+		desiredSecret("web1", "https://web1.example.com"),
+		desiredSecret("web4", "https://web4.example.com"),
+		desiredSecret("web5", "https://web5.example.com"),
+	}
+
+	created, err := createMissingClusters(config, desired)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	deleted, err := deleteOutdatedClusters(config, desired)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := map[string]string{}
+
+	for _, r := range append(created, deleted...) {
+		action := r.Action
+		if r.Err != nil {
+			action += " failed"
+		}
+
+		got[r.Name] = action
+	}
+
+	want := map[string]string{
+		"web1": ActionUnchanged,
+		"web2": ActionDelete + " failed",
+		"web3": ActionDelete,
+		"web4": ActionCreate,
+		"web5": ActionUpdate,
+	}
+
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("unexpected results: -want +got\n%s", d)
+	}
+
+	for name, exists := range map[string]bool{"web1": true, "web2": true, "web3": false, "web4": true, "web5": true} {
+		var sec corev1.Secret
+
+		err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: name}, &sec)
+		if exists != (err == nil) {
+			t.Errorf("unexpected existence of cluster secret %s: want %v, got error %v", name, exists, err)
+		}
+
+		if exists && !controllerutil.ContainsFinalizer(&sec, v1alpha1.FinalizerTrafficGuard) {
+			t.Errorf("expected cluster secret %s to have the traffic guard finalizer: %v", name, sec.Finalizers)
+		}
+	}
+
+	var web5 corev1.Secret
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "web5"}, &web5); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := string(web5.Data["server"]); got != "https://web5.example.com" {
+		t.Errorf("expected the server of the existing cluster secret to be updated, got %q", got)
+	}
+}
+
+func TestDeleteClusterSecretHeldByFinalizer(t *testing.T) {
+	labels := map[string]string{"role": "web"}
+
+	now := metav1.Now()
+
+	// The cluster secret was deleted by hand while its cluster still receives traffic
+	sec := newClusterSecretFromValues("default", "web1", labels, "https://web1.example.com", "Y2E=", "")
+	sec.Finalizers = []string{v1alpha1.FinalizerTrafficGuard}
+	sec.DeletionTimestamp = &now
+
+	c := crfake.NewFakeClientWithScheme(clclient.Scheme(),
+		sec,
+		&v1alpha1.AWSApplicationLoadBalancerConfig{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+			Spec: v1alpha1.AWSApplicationLoadBalancerConfigSpec{
+				Listener: v1alpha1.Listener{
+					Rule: v1alpha1.ListenerRule{
+						Forward: v1alpha1.Forward{
+							TargetGroups: []v1alpha1.ForwardTargetGroup{
+								{Name: "web1-tg", ARN: "arn:tg1", Weight: 100},
+							},
+						},
+					},
+				},
+			},
+		},
+		&v1alpha1.AWSTargetGroup{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      "web1-tg",
+				Labels:    map[string]string{v1alpha1.AWSTargetGroupLabelBindingCluster: "web1"},
+			},
+			Spec: v1alpha1.AWSTargetGroupSpec{ARN: "arn:tg1"},
+		},
+	)
+
+	config := SyncInput{
+		NS:      "default",
+		Labels:  labels,
+		Context: context.Background(),
+		Client:  c,
+	}
+
+	desired := []*corev1.Secret{newClusterSecretFromValues("default", "web1", labels, "https://web1.example.com", "Y2E=", "")}
+
+	finalizers := func() []string {
+		t.Helper()
+
+		var sec corev1.Secret
+		if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "web1"}, &sec); err != nil {
+			t.Fatal(err)
+		}
+
+		return sec.Finalizers
+	}
+
+	results, err := deleteOutdatedClusters(config, desired)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var trafficErr *cell.TrafficError
+	if len(results) != 1 || !errors.As(results[0].Err, &trafficErr) {
+		t.Fatalf("expected the deletion to be blocked, got %+v", results)
+	}
+
+	if d := cmp.Diff([]string{v1alpha1.FinalizerTrafficGuard}, finalizers()); d != "" {
+		t.Errorf("unexpected finalizers while blocked: %s", d)
+	}
+
+	// Forcing the deletion releases the finalizer
+	config.ForceDelete = true
+
+	results, err = deleteOutdatedClusters(config, desired)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(results) != 1 || results[0].Err != nil {
+		t.Fatalf("expected the deletion to succeed, got %+v", results)
+	}
+
+	if fs := finalizers(); len(fs) != 0 {
+		t.Errorf("expected the finalizer to be released, got %v", fs)
+	}
+}
@@ -0,0 +1,145 @@
+package clusterset
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mumoshu/okra/api/v1alpha1"
+	"github.com/mumoshu/okra/pkg/cell"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ActionCreate    = "Create"
+	ActionUpdate    = "Update"
+	ActionUnchanged = "Unchanged"
+	ActionDelete    = "Delete"
+)
+
+// SyncResult is the result of syncing the cluster secret for a cluster.
+type SyncResult struct {
+	Name   string
+	Action string
+	// Err is the error occurred while doing Action for the cluster secret, if any
+	Err error
+}
+
+// resultsError returns an error that summarizes the errors in the results, or nil if there are none.
+func resultsError(results []SyncResult) error {
+	var msgs []string
+
+	for _, r := range results {
+		if r.Err != nil {
+			msgs = append(msgs, fmt.Sprintf("%s %s: %v", strings.ToLower(r.Action), r.Name, r.Err))
+		}
+	}
+
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%d cluster secret(s) failed to sync: %s", len(msgs), strings.Join(msgs, "; "))
+}
+
+// deletionBlocked returns true when all the errors in the results are due to deletions blocked by live traffic.
+func deletionBlocked(results []SyncResult) bool {
+	var blocked bool
+
+	for _, r := range results {
+		if r.Err == nil {
+			continue
+		}
+
+		var trafficErr *cell.TrafficError
+		if !errors.As(r.Err, &trafficErr) {
+			return false
+		}
+
+		blocked = true
+	}
+
+	return blocked
+}
+
+// UpdateStatus updates the status of the ClusterSet with the results, the discovery stats, and the error of the sync.
+// The clusters are updated only when the clusters were discovered, so that the status keeps
+// the last known clusters when the discovery failed.
+func UpdateStatus(status *v1alpha1.ClusterSetStatus, results []SyncResult, stats *DiscoveryStats, err error, now metav1.Time) {
+	status.LastSyncTime = now
+
+	if stats != nil {
+		status.Discovery = &v1alpha1.ClusterSetStatusDiscovery{
+			ListClustersCalls:    stats.ListClustersCalls,
+			DescribeClusterCalls: stats.DescribeClusterCalls,
+			CacheHits:            stats.CacheHits,
+			Throttles:            stats.Throttles,
+			Duration:             metav1.Duration{Duration: stats.Duration},
+		}
+	}
+
+	if results != nil || err == nil {
+		var clusters v1alpha1.ClusterSetStatusClusters
+
+		for _, r := range results {
+			if r.Err != nil {
+				clusters.Errors = append(clusters.Errors, v1alpha1.MemberError{Name: r.Name, Message: r.Err.Error()})
+			}
+
+			switch r.Action {
+			case ActionCreate:
+				clusters.Names = append(clusters.Names, r.Name)
+				if r.Err == nil {
+					clusters.Created = append(clusters.Created, r.Name)
+				}
+			case ActionUpdate:
+				clusters.Names = append(clusters.Names, r.Name)
+				if r.Err == nil {
+					clusters.Updated = append(clusters.Updated, r.Name)
+				}
+			case ActionUnchanged:
+				clusters.Names = append(clusters.Names, r.Name)
+				clusters.Unchanged = append(clusters.Unchanged, r.Name)
+			case ActionDelete:
+				if r.Err == nil {
+					clusters.Deleted = append(clusters.Deleted, r.Name)
+				}
+			}
+		}
+
+		sort.Strings(clusters.Names)
+
+		status.Clusters = clusters
+	}
+
+	cond := metav1.Condition{
+		Type:               v1alpha1.ConditionTypeReady,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Synced",
+		Message:            fmt.Sprintf("%d cluster(s) synced", len(status.Clusters.Names)),
+		LastTransitionTime: now,
+	}
+
+	if err != nil {
+		status.Phase = "Failed"
+		status.Reason = "SyncFailed"
+		status.Message = err.Error()
+
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = "SyncFailed"
+		cond.Message = err.Error()
+
+		if deletionBlocked(results) {
+			status.Reason = "DeletionBlocked"
+			cond.Reason = "DeletionBlocked"
+		}
+	} else {
+		status.Phase = "Synced"
+		status.Reason = ""
+		status.Message = ""
+	}
+
+	meta.SetStatusCondition(&status.Conditions, cond)
+}
@@ -0,0 +1,77 @@
+package clusterset
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mumoshu/okra/api/v1alpha1"
+	"github.com/mumoshu/okra/pkg/cell"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestUpdateStatus(t *testing.T) {
+	var status v1alpha1.ClusterSetStatus
+
+	results := []SyncResult{
+		{Name: "web2", Action: ActionCreate},
+		{Name: "web1", Action: ActionUnchanged},
+		{Name: "web3", Action: ActionCreate, Err: errors.New("forbidden")},
+		{Name: "web0", Action: ActionDelete},
+		{Name: "web4", Action: ActionUpdate},
+	}
+
+	UpdateStatus(&status, results, &DiscoveryStats{ListClustersCalls: 1, DescribeClusterCalls: 2, CacheHits: 2}, resultsError(results), metav1.Now())
+
+	want := v1alpha1.ClusterSetStatusClusters{
+		Names:     []string{"web1", "web2", "web3", "web4"},
+		Created:   []string{"web2"},
+		Updated:   []string{"web4"},
+		Unchanged: []string{"web1"},
+		Deleted:   []string{"web0"},
+		Errors:    []v1alpha1.MemberError{{Name: "web3", Message: "forbidden"}},
+	}
+
+	if d := cmp.Diff(want, status.Clusters); d != "" {
+		t.Errorf("unexpected clusters: %s", d)
+	}
+
+	if status.Discovery == nil || status.Discovery.DescribeClusterCalls != 2 || status.Discovery.CacheHits != 2 {
+		t.Errorf("unexpected discovery stats: %+v", status.Discovery)
+	}
+
+	if !meta.IsStatusConditionFalse(status.Conditions, v1alpha1.ConditionTypeReady) {
+		t.Errorf("expected Ready to be False: %+v", status.Conditions)
+	}
+
+	UpdateStatus(&status, results[:2], nil, nil, metav1.Now())
+
+	if status.Phase != "Synced" || len(status.Clusters.Errors) != 0 {
+		t.Errorf("unexpected status: %+v", status)
+	}
+
+	if !meta.IsStatusConditionTrue(status.Conditions, v1alpha1.ConditionTypeReady) {
+		t.Errorf("expected Ready to be True: %+v", status.Conditions)
+	}
+}
+
+func TestUpdateStatusDeletionBlocked(t *testing.T) {
+	var status v1alpha1.ClusterSetStatus
+
+	results := []SyncResult{
+		{Name: "web1", Action: ActionUnchanged},
+		{Name: "web0", Action: ActionDelete, Err: &cell.TrafficError{Target: "cluster web0", Sources: []string{"Cell default/web"}}},
+	}
+
+	UpdateStatus(&status, results, nil, resultsError(results), metav1.Now())
+
+	cond := meta.FindStatusCondition(status.Conditions, v1alpha1.ConditionTypeReady)
+	if cond == nil || cond.Status != metav1.ConditionFalse || cond.Reason != "DeletionBlocked" {
+		t.Errorf("unexpected Ready condition: %+v", cond)
+	}
+
+	if len(status.Clusters.Deleted) != 0 || len(status.Clusters.Errors) != 1 {
+		t.Errorf("unexpected clusters: %+v", status.Clusters)
+	}
+}
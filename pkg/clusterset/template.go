@@ -0,0 +1,189 @@
+package clusterset
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"golang.org/x/xerrors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// SecretTemplate is the template of the cluster secrets.
+// Every value can be a Go template that is evaluated against the attributes of the discovered cluster,
+// like `{{.awseks.cluster.tags.version}}` for EKS clusters and `{{.clusterAPI.cluster.labels.env}}`
+// for Cluster API clusters.
+type SecretTemplate struct {
+	Labels      map[string]string
+	Annotations map[string]string
+	// Project is the ArgoCD project the cluster belongs to
+	Project string
+	// Namespaces restricts the namespaces ArgoCD can manage in the cluster
+	Namespaces       []string
+	ClusterResources bool
+}
+
+// template returns the secret template of the config, with the static labels merged into the template labels.
+func (config SyncInput) template() SecretTemplate {
+	t := config.Template
+
+	lbls := map[string]string{}
+
+	for k, v := range config.Labels {
+		lbls[k] = v
+	}
+
+	for k, v := range t.Labels {
+		lbls[k] = v
+	}
+
+	t.Labels = lbls
+
+	return t
+}
+
+// staticLabels returns the template labels that do not contain any template expression.
+// They are the same across all the generated cluster secrets, so that they can be used to select them.
+func (t SecretTemplate) staticLabels() map[string]string {
+	lbls := map[string]string{}
+
+	for k, v := range t.Labels {
+		if !strings.Contains(v, "{{") {
+			lbls[k] = v
+		}
+	}
+
+	return lbls
+}
+
+// apply renders the template against data and sets the results to the cluster secret.
+// Labels and annotations that render to empty strings are omitted, so that
+// a template referring to a missing tag does not produce an empty label.
+func (t SecretTemplate) apply(sec *corev1.Secret, data map[string]interface{}) error {
+	for k, v := range t.Labels {
+		r, err := render(v, data)
+		if err != nil {
+			return xerrors.Errorf("rendering label %s: %w", k, err)
+		}
+
+		if r == "" {
+			continue
+		}
+
+		if sec.Labels == nil {
+			sec.Labels = map[string]string{}
+		}
+
+		sec.Labels[k] = r
+	}
+
+	for k, v := range t.Annotations {
+		r, err := render(v, data)
+		if err != nil {
+			return xerrors.Errorf("rendering annotation %s: %w", k, err)
+		}
+
+		if r == "" {
+			continue
+		}
+
+		if sec.Annotations == nil {
+			sec.Annotations = map[string]string{}
+		}
+
+		sec.Annotations[k] = r
+	}
+
+	if t.Project != "" {
+		r, err := render(t.Project, data)
+		if err != nil {
+			return xerrors.Errorf("rendering project: %w", err)
+		}
+
+		sec.StringData["project"] = r
+	}
+
+	var namespaces []string
+
+	for _, ns := range t.Namespaces {
+		r, err := render(ns, data)
+		if err != nil {
+			return xerrors.Errorf("rendering namespace %s: %w", ns, err)
+		}
+
+		if r != "" {
+			namespaces = append(namespaces, r)
+		}
+	}
+
+	if len(namespaces) > 0 {
+		sec.StringData["namespaces"] = strings.Join(namespaces, ",")
+	}
+
+	if t.ClusterResources {
+		sec.StringData["clusterResources"] = strconv.FormatBool(t.ClusterResources)
+	}
+
+	return nil
+}
+
+func render(text string, data map[string]interface{}) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	tmpl, err := template.New("template").Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// eksTemplateData returns the data to render the secret template for the EKS cluster.
+func eksTemplateData(region string, result *eks.DescribeClusterOutput) map[string]interface{} {
+	c := result.Cluster
+
+	return map[string]interface{}{
+		"awseks": map[string]interface{}{
+			"cluster": map[string]interface{}{
+				"name":            aws.StringValue(c.Name),
+				"arn":             aws.StringValue(c.Arn),
+				"endpoint":        aws.StringValue(c.Endpoint),
+				"version":         aws.StringValue(c.Version),
+				"platformVersion": aws.StringValue(c.PlatformVersion),
+				"status":          aws.StringValue(c.Status),
+				"region":          region,
+				"tags":            aws.StringValueMap(c.Tags),
+			},
+		},
+	}
+}
+
+// clusterAPITemplateData returns the data to render the secret template for the Cluster API cluster.
+func clusterAPITemplateData(ns, name string, labels map[string]string) map[string]interface{} {
+	lbls := map[string]string{}
+
+	for k, v := range labels {
+		lbls[k] = v
+	}
+
+	return map[string]interface{}{
+		"clusterAPI": map[string]interface{}{
+			"cluster": map[string]interface{}{
+				"name":      name,
+				"namespace": ns,
+				"labels":    lbls,
+			},
+		},
+	}
+}
@@ -0,0 +1,78 @@
+package clusterset
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSecretTemplateApply(t *testing.T) {
+	tmpl := SecretTemplate{
+		Labels: map[string]string{
+			"env":     "prod",
+			"version": "{{.awseks.cluster.tags.version}}",
+			"role":    "{{.awseks.cluster.tags.role}}",
+			"missing": "{{.awseks.cluster.tags.missing}}",
+		},
+		Annotations: map[string]string{
+			"okra.mumo.co/region": "{{.awseks.cluster.region}}",
+		},
+		Project:          "{{.awseks.cluster.tags.team}}",
+		Namespaces:       []string{"{{.awseks.cluster.tags.role}}", "kube-system"},
+		ClusterResources: true,
+	}
+
+	result := &eks.DescribeClusterOutput{
+		Cluster: &eks.Cluster{
+			Name:     aws.String("web1"),
+			Endpoint: aws.String("https://web1.example.com"),
+			CertificateAuthority: &eks.Certificate{
+				Data: aws.String("Y2E="),
+			},
+			Tags: aws.StringMap(map[string]string{
+				"version": "v2",
+				"role":    "web",
+				"team":    "cart",
+			}),
+		},
+	}
+
+	sec := newClusterSecretFromCluster("argocd", "web1", nil, result, "")
+
+	if err := tmpl.apply(sec, eksTemplateData("us-east-2", result)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	wantLabels := map[string]string{
+		SecretLabelKeyArgoCDType: SecretLabelValueArgoCDCluster,
+		"env":                    "prod",
+		"version":                "v2",
+		"role":                   "web",
+	}
+
+	if d := cmp.Diff(wantLabels, sec.Labels); d != "" {
+		t.Errorf("unexpected labels: %s", d)
+	}
+
+	if d := cmp.Diff(map[string]string{"okra.mumo.co/region": "us-east-2"}, sec.Annotations); d != "" {
+		t.Errorf("unexpected annotations: %s", d)
+	}
+
+	wantData := map[string]string{
+		"project":          "cart",
+		"namespaces":       "web,kube-system",
+		"clusterResources": "true",
+	}
+
+	for k, v := range wantData {
+		if got := sec.StringData[k]; got != v {
+			t.Errorf("unexpected %s: want %q, got %q", k, v, got)
+		}
+	}
+
+	if d := cmp.Diff(map[string]string{"env": "prod"}, tmpl.staticLabels()); d != "" {
+		t.Errorf("unexpected static labels: %s", d)
+	}
+}
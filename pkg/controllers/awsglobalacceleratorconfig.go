@@ -0,0 +1,142 @@
+/*
+Copyright 2020 The Okra authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/mumoshu/okra/api/v1alpha1"
+	"github.com/mumoshu/okra/pkg/awsglobalaccelerator"
+)
+
+// AWSGlobalAcceleratorConfigReconciler reconciles a AWSGlobalAcceleratorConfig object
+type AWSGlobalAcceleratorConfigReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Recorder record.EventRecorder
+	Scheme   *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=okra.mumo.co,resources=awsglobalacceleratorconfigs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=okra.mumo.co,resources=awsglobalacceleratorconfigs/finalizers,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=okra.mumo.co,resources=awsglobalacceleratorconfigs/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+
+func (r *AWSGlobalAcceleratorConfigReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("awsGlobalAcceleratorConfig", req.NamespacedName)
+
+	var acceleratorConfig v1alpha1.AWSGlobalAcceleratorConfig
+	if err := r.Get(ctx, req.NamespacedName, &acceleratorConfig); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if acceleratorConfig.ObjectMeta.DeletionTimestamp.IsZero() {
+		finalizers, added := addFinalizer(acceleratorConfig.ObjectMeta.Finalizers)
+
+		if added {
+			updated := acceleratorConfig.DeepCopy()
+			updated.ObjectMeta.Finalizers = finalizers
+
+			if err := r.Update(ctx, updated); err != nil {
+				log.Error(err, "Failed to update AWSGlobalAcceleratorConfig")
+				return ctrl.Result{}, err
+			}
+
+			// Requeue explicitly as updating finalizers does not change the generation
+			return ctrl.Result{Requeue: true}, nil
+		}
+	} else {
+		finalizers, removed := removeFinalizer(acceleratorConfig.ObjectMeta.Finalizers)
+
+		if removed {
+			// Endpoint groups are intentionally left as-is, as removing endpoints would immediately cut the traffic
+
+			updated := acceleratorConfig.DeepCopy()
+			updated.ObjectMeta.Finalizers = finalizers
+
+			if err := r.Update(ctx, updated); err != nil {
+				log.Error(err, "Failed to update AWSGlobalAcceleratorConfig")
+				return ctrl.Result{}, err
+			}
+
+			log.Info("Removed AWSGlobalAcceleratorConfig")
+		}
+
+		return ctrl.Result{}, nil
+	}
+
+	changed, syncErr := awsglobalaccelerator.Sync(awsglobalaccelerator.SyncInput{Spec: acceleratorConfig.Spec})
+
+	updated := acceleratorConfig.DeepCopy()
+
+	if syncErr != nil {
+		log.Error(syncErr, "Syncing AWSGlobalAcceleratorConfig")
+
+		updated.Status.Phase = "Failed"
+		updated.Status.Reason = "SyncFailed"
+		updated.Status.Message = syncErr.Error()
+	} else {
+		updated.Status.Phase = "Synced"
+		updated.Status.Reason = ""
+		updated.Status.Message = ""
+
+		if changed || updated.Status.LastSyncTime.IsZero() {
+			updated.Status.LastSyncTime = metav1.Now()
+		}
+	}
+
+	// Update the status only when it changed, so that periodic resyncs don't write anything
+	if !equality.Semantic.DeepEqual(acceleratorConfig.Status, updated.Status) {
+		if err := r.Status().Update(ctx, updated); err != nil {
+			log.Error(err, "Failed to update AWSGlobalAcceleratorConfig status")
+			return ctrl.Result{}, err
+		}
+	}
+
+	if syncErr != nil {
+		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+
+	if changed {
+		r.Recorder.Event(&acceleratorConfig, corev1.EventTypeNormal, "SyncFinished", fmt.Sprintf("Sync finished on '%s'", acceleratorConfig.Name))
+	}
+
+	return ctrl.Result{}, nil
+}
+
+func (r *AWSGlobalAcceleratorConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.Recorder = mgr.GetEventRecorderFor("awsglobalacceleratorconfig-controller")
+
+	return ctrl.NewControllerManagedBy(mgr).
+		// Ignore status updates made by the reconciler itself
+		For(&v1alpha1.AWSGlobalAcceleratorConfig{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2020 The Okra authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/mumoshu/okra/api/v1alpha1"
+	"github.com/mumoshu/okra/pkg/awsroute53"
+)
+
+// AWSRoute53WeightedRecordConfigReconciler reconciles a AWSRoute53WeightedRecordConfig object
+type AWSRoute53WeightedRecordConfigReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Recorder record.EventRecorder
+	Scheme   *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=okra.mumo.co,resources=awsroute53weightedrecordconfigs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=okra.mumo.co,resources=awsroute53weightedrecordconfigs/finalizers,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=okra.mumo.co,resources=awsroute53weightedrecordconfigs/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+
+func (r *AWSRoute53WeightedRecordConfigReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("awsRoute53WeightedRecordConfig", req.NamespacedName)
+
+	var recordConfig v1alpha1.AWSRoute53WeightedRecordConfig
+	if err := r.Get(ctx, req.NamespacedName, &recordConfig); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if recordConfig.ObjectMeta.DeletionTimestamp.IsZero() {
+		finalizers, added := addFinalizer(recordConfig.ObjectMeta.Finalizers)
+
+		if added {
+			updated := recordConfig.DeepCopy()
+			updated.ObjectMeta.Finalizers = finalizers
+
+			if err := r.Update(ctx, updated); err != nil {
+				log.Error(err, "Failed to update AWSRoute53WeightedRecordConfig")
+				return ctrl.Result{}, err
+			}
+
+			// Requeue explicitly as updating finalizers does not change the generation
+			return ctrl.Result{Requeue: true}, nil
+		}
+	} else {
+		finalizers, removed := removeFinalizer(recordConfig.ObjectMeta.Finalizers)
+
+		if removed {
+			if err := awsroute53.Delete(awsroute53.SyncInput{Spec: recordConfig.Spec, Managed: recordConfig.Status.Managed}); err != nil {
+				log.Error(err, "Deleting Route53 record sets")
+
+				return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+			}
+
+			updated := recordConfig.DeepCopy()
+			updated.ObjectMeta.Finalizers = finalizers
+
+			if err := r.Update(ctx, updated); err != nil {
+				log.Error(err, "Failed to update AWSRoute53WeightedRecordConfig")
+				return ctrl.Result{}, err
+			}
+
+			log.Info("Removed AWSRoute53WeightedRecordConfig")
+		}
+
+		return ctrl.Result{}, nil
+	}
+
+	changed, syncErr := awsroute53.Sync(awsroute53.SyncInput{Spec: recordConfig.Spec, Managed: recordConfig.Status.Managed})
+
+	updated := recordConfig.DeepCopy()
+
+	if syncErr != nil {
+		log.Error(syncErr, "Syncing AWSRoute53WeightedRecordConfig")
+
+		updated.Status.Phase = "Failed"
+		updated.Status.Reason = "SyncFailed"
+		updated.Status.Message = syncErr.Error()
+	} else {
+		updated.Status.Phase = "Synced"
+		updated.Status.Reason = ""
+		updated.Status.Message = ""
+		updated.Status.Managed = awsroute53.Managed(recordConfig.Spec)
+
+		if changed || updated.Status.LastSyncTime.IsZero() {
+			updated.Status.LastSyncTime = metav1.Now()
+		}
+	}
+
+	// Update the status only when it changed, so that periodic resyncs don't write anything
+	if !equality.Semantic.DeepEqual(recordConfig.Status, updated.Status) {
+		if err := r.Status().Update(ctx, updated); err != nil {
+			log.Error(err, "Failed to update AWSRoute53WeightedRecordConfig status")
+			return ctrl.Result{}, err
+		}
+	}
+
+	if syncErr != nil {
+		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+
+	if changed {
+		r.Recorder.Event(&recordConfig, corev1.EventTypeNormal, "SyncFinished", fmt.Sprintf("Sync finished on '%s'", recordConfig.Name))
+	}
+
+	return ctrl.Result{}, nil
+}
+
+func (r *AWSRoute53WeightedRecordConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.Recorder = mgr.GetEventRecorderFor("awsroute53weightedrecordconfig-controller")
+
+	return ctrl.NewControllerManagedBy(mgr).
+		// Ignore status updates made by the reconciler itself
+		For(&v1alpha1.AWSRoute53WeightedRecordConfig{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
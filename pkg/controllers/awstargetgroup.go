@@ -0,0 +1,94 @@
+/*
+Copyright 2020 The Okra authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/mumoshu/okra/api/v1alpha1"
+	"github.com/mumoshu/okra/pkg/awstargetgroup"
+)
+
+// AWSTargetGroupReconciler records the health of the targets of an AWSTargetGroup
+type AWSTargetGroupReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+
+	// TargetHealthInterval is the interval between target health checks per AWSTargetGroup.
+	// Defaults to awstargetgroup.DefaultTargetHealthInterval.
+	TargetHealthInterval time.Duration
+}
+
+// +kubebuilder:rbac:groups=okra.mumo.co,resources=awstargetgroups,verbs=get;list;watch
+// +kubebuilder:rbac:groups=okra.mumo.co,resources=awstargetgroups/status,verbs=get;update;patch
+
+func (r *AWSTargetGroupReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("awsTargetGroup", req.NamespacedName)
+
+	var tg v1alpha1.AWSTargetGroup
+	if err := r.Get(ctx, req.NamespacedName, &tg); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !tg.DeletionTimestamp.IsZero() || tg.Spec.ARN == "" {
+		return ctrl.Result{}, nil
+	}
+
+	interval := r.TargetHealthInterval
+	if interval <= 0 {
+		interval = awstargetgroup.DefaultTargetHealthInterval
+	}
+
+	health, err := awstargetgroup.DescribeTargetHealth(awstargetgroup.DescribeTargetHealthInput{
+		ARN: tg.Spec.ARN,
+		Now: metav1.Now(),
+	})
+	if err != nil {
+		log.Error(err, "Describing target health")
+
+		return ctrl.Result{RequeueAfter: interval}, nil
+	}
+
+	updated := tg.DeepCopy()
+	updated.Status.TargetHealth = health
+
+	if err := r.Status().Update(ctx, updated); err != nil {
+		log.Error(err, "Failed to update AWSTargetGroup status")
+		return ctrl.Result{}, err
+	}
+
+	// Requeue to check the health periodically, as status updates do not trigger reconciliations
+	return ctrl.Result{RequeueAfter: interval}, nil
+}
+
+func (r *AWSTargetGroupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		// Ignore status updates made by the reconciler itself
+		For(&v1alpha1.AWSTargetGroup{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
@@ -23,14 +23,18 @@ import (
 
 	"github.com/go-logr/logr"
 	//"k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	corev1 "k8s.io/api/core/v1"
-	//metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/mumoshu/okra/api/v1alpha1"
 	"github.com/mumoshu/okra/pkg/awstargetgroupset"
@@ -42,11 +46,26 @@ type AWSTargetGroupSetReconciler struct {
 	Log      logr.Logger
 	Recorder record.EventRecorder
 	Scheme   *runtime.Scheme
+
+	// BindingEvents, when set, triggers syncs of the AWSTargetGroupSets sent by RemoteTargetGroupBindingReconciler
+	BindingEvents chan event.GenericEvent
 }
 
 // +kubebuilder:rbac:groups=okra.mumo.co,resources=awstargetgroupsets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=okra.mumo.co,resources=awstargetgroupsets/finalizers,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=okra.mumo.co,resources=awstargetgroupsets/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=okra.mumo.co,resources=awstargetgroups,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=okra.mumo.co,resources=awstargetgroups/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=okra.mumo.co,resources=awsapplicationloadbalancerconfigs,verbs=get;list;watch
+// +kubebuilder:rbac:groups=okra.mumo.co,resources=cells,verbs=get;list;watch
+// +kubebuilder:rbac:groups=okra.mumo.co,resources=clusterendpoints,verbs=get;list;watch
+// +kubebuilder:rbac:groups=okra.mumo.co,resources=envoyrouteconfigs,verbs=get;list;watch
+// +kubebuilder:rbac:groups=okra.mumo.co,resources=awsroute53weightedrecordconfigs,verbs=get;list;watch
+// +kubebuilder:rbac:groups=okra.mumo.co,resources=awsglobalacceleratorconfigs,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch
+// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=httproutes,verbs=get;list;watch
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch
+// +kubebuilder:rbac:groups=networking.istio.io,resources=virtualservices,verbs=get;list;watch
 // +kubebuilder:rbac:groups=core,resources=awstargetgroup,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=awstargetgroup/finalizers,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
@@ -72,7 +91,8 @@ func (r *AWSTargetGroupSetReconciler) Reconcile(req ctrl.Request) (ctrl.Result,
 				return ctrl.Result{}, err
 			}
 
-			return ctrl.Result{}, nil
+			// Requeue explicitly as updating finalizers does not change the generation
+			return ctrl.Result{Requeue: true}, nil
 		}
 	} else {
 		finalizers, removed := removeFinalizer(awsTargetGroupSet.ObjectMeta.Finalizers)
@@ -94,27 +114,41 @@ func (r *AWSTargetGroupSetReconciler) Reconcile(req ctrl.Request) (ctrl.Result,
 		return ctrl.Result{}, nil
 	}
 
-	awseks := awsTargetGroupSet.Spec.Generators[0].AWSEKS
+	if len(awsTargetGroupSet.Spec.Generators) == 0 {
+		log.Info("Skipped syncing AWSTargetGroups as no generators are specified")
 
-	clusterSelector := labels.SelectorFromSet(awseks.ClusterSelector.MatchLabels)
-	bindingSelector := labels.SelectorFromSet(awseks.BindingSelector.MatchLabels)
+		return ctrl.Result{}, nil
+	}
 
 	config := awstargetgroupset.SyncInput{
-		NS:              req.Namespace,
-		ClusterSelector: clusterSelector.String(),
-		BindingSelector: bindingSelector.String(),
-		Labels:          awsTargetGroupSet.Spec.Template.Metadata.Labels,
+		NS:                req.Namespace,
+		Labels:            awsTargetGroupSet.Spec.Template.Metadata.Labels,
+		LabelsFromCluster: awsTargetGroupSet.Spec.Template.LabelsFromCluster,
+		Generators:        awstargetgroupset.NewGenerators(awsTargetGroupSet.Spec.Generators),
+		SetName:           awsTargetGroupSet.Name,
+		Context:           ctx,
+		Client:            r.Client,
 	}
 
-	results, err := awstargetgroupset.Sync(config)
-	if err != nil {
-		log.Error(err, "Syncing AWSTargetGroupSets")
-
-		return ctrl.Result{RequeueAfter: 10 * time.Second}, err
+	results, syncErr := awstargetgroupset.Sync(config)
+	if syncErr != nil {
+		log.Error(syncErr, "Syncing AWSTargetGroupSets")
 	}
 
 	for _, r := range results {
-		log.Info("%s AWSTargetGroup %s for cluster %s", r.Action, r.Name, r.Cluster)
+		log.Info("Synced AWSTargetGroup", "action", r.Action, "name", r.Name, "cluster", r.Cluster)
+	}
+
+	updated := awsTargetGroupSet.DeepCopy()
+	awstargetgroupset.UpdateStatus(&updated.Status, results, syncErr, metav1.Now())
+
+	if err := r.Status().Update(ctx, updated); err != nil {
+		log.Error(err, "Failed to update AWSTargetGroupSet status")
+		return ctrl.Result{}, err
+	}
+
+	if syncErr != nil {
+		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
 	}
 
 	r.Recorder.Event(&awsTargetGroupSet, corev1.EventTypeNormal, "SyncFinished", fmt.Sprintf("Sync finished on '%s'", awsTargetGroupSet.Name))
@@ -125,8 +159,14 @@ func (r *AWSTargetGroupSetReconciler) Reconcile(req ctrl.Request) (ctrl.Result,
 func (r *AWSTargetGroupSetReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.Recorder = mgr.GetEventRecorderFor("awstargetgroupset-controller")
 
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&v1alpha1.AWSTargetGroupSet{}).
-		Owns(&v1alpha1.AWSTargetGroup{}).
-		Complete(r)
+	b := ctrl.NewControllerManagedBy(mgr).
+		// Ignore status updates made by the reconciler itself
+		For(&v1alpha1.AWSTargetGroupSet{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Owns(&v1alpha1.AWSTargetGroup{})
+
+	if r.BindingEvents != nil {
+		b = b.Watches(&source.Channel{Source: r.BindingEvents}, &handler.EnqueueRequestForObject{})
+	}
+
+	return b.Complete(r)
 }
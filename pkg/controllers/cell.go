@@ -25,12 +25,19 @@ import (
 	//"k8s.io/apimachinery/pkg/api/errors"
 
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	corev1 "k8s.io/api/core/v1"
-	//metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	rolloutsv1alpha1 "github.com/mumoshu/okra/api/rollouts/v1alpha1"
 	okrav1alpha1 "github.com/mumoshu/okra/api/v1alpha1"
@@ -49,8 +56,19 @@ type CellReconciler struct {
 // +kubebuilder:rbac:groups=okra.mumo.co,resources=cells/finalizers,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=okra.mumo.co,resources=cells/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=okra.mumo.co,resources=versionblocklists,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=okra.mumo.co,resources=awstargetgroups,verbs=get;list;watch
+// +kubebuilder:rbac:groups=okra.mumo.co,resources=awstargetgroups/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=okra.mumo.co,resources=awsapplicationloadbalancerconfigs,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=secrets/finalizers,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch
+// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=httproutes,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=okra.mumo.co,resources=clusterendpoints,verbs=get;list;watch
+// +kubebuilder:rbac:groups=okra.mumo.co,resources=envoyrouteconfigs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=okra.mumo.co,resources=awsroute53weightedrecordconfigs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=okra.mumo.co,resources=awsglobalacceleratorconfigs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=networking.istio.io,resources=virtualservices,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 
 func (r *CellReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
@@ -97,9 +115,10 @@ func (r *CellReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	}
 
 	err := cell.Sync(cell.SyncInput{
-		Cell:   &cellResource,
-		Client: r.Client,
-		Scheme: r.Scheme,
+		Cell:    &cellResource,
+		Client:  r.Client,
+		Scheme:  r.Scheme,
+		Context: ctx,
 	})
 	if err != nil {
 		log.Error(err, "Syncing Cell")
@@ -115,17 +134,95 @@ func (r *CellReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 
 	r.Recorder.Event(&cellResource, corev1.EventTypeNormal, "SyncFinished", fmt.Sprintf("Sync finished on '%s'", cellResource.Name))
 
+	decommission, err := cell.Decommission(cell.DecommissionInput{
+		Cell:     &cellResource,
+		Client:   r.Client,
+		Context:  ctx,
+		Now:      metav1.Now(),
+		Recorder: r.Recorder,
+	})
+	if err != nil {
+		log.Error(err, "Decommissioning drained clusters")
+
+		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+
+	if decommission.RequeueAfter > 0 {
+		return ctrl.Result{RequeueAfter: decommission.RequeueAfter}, nil
+	}
+
 	return ctrl.Result{}, nil
 }
 
+// cellTargetGroupSelectorIndexField is the field index of cells by the labels of their target group selectors
+const cellTargetGroupSelectorIndexField = "spec.ingress.targetGroupSelector.matchLabels"
+
 func (r *CellReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.Recorder = mgr.GetEventRecorderFor("cell-controller")
 
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &okrav1alpha1.Cell{}, cellTargetGroupSelectorIndexField, func(o runtime.Object) []string {
+		return cell.TargetGroupSelectorIndexValues(*o.(*okrav1alpha1.Cell))
+	}); err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&okrav1alpha1.Cell{}).
 		Owns(&okrav1alpha1.AWSApplicationLoadBalancerConfig{}).
+		Owns(&okrav1alpha1.AWSRoute53WeightedRecordConfig{}).
+		Owns(&okrav1alpha1.AWSGlobalAcceleratorConfig{}).
 		Owns(&okrav1alpha1.Pause{}).
 		Owns(&rolloutsv1alpha1.AnalysisRun{}).
 		Owns(&rolloutsv1alpha1.Experiment{}).
+		// Start rollouts as soon as enough target groups exist, instead of waiting for the next resync
+		Watches(&source.Kind{Type: &okrav1alpha1.AWSTargetGroup{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(r.cellsForTargetGroup),
+		}, builder.WithPredicates(predicate.Funcs{
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				oldTG, ok := e.ObjectOld.(*okrav1alpha1.AWSTargetGroup)
+				if !ok {
+					return true
+				}
+
+				newTG, ok := e.ObjectNew.(*okrav1alpha1.AWSTargetGroup)
+				if !ok {
+					return true
+				}
+
+				return cell.TargetGroupUpdateAffectsCells(*oldTG, *newTG)
+			},
+		})).
+		// A VersionBlocklist is named after the cell it blocks versions for
+		Watches(&source.Kind{Type: &okrav1alpha1.VersionBlocklist{}}, &handler.EnqueueRequestForObject{}).
 		Complete(r)
 }
+
+// cellsForTargetGroup returns the requests for the cells whose target group selectors match the AWSTargetGroup.
+func (r *CellReconciler) cellsForTargetGroup(o handler.MapObject) []reconcile.Request {
+	ctx := context.Background()
+
+	seen := map[string]bool{}
+
+	var reqs []reconcile.Request
+
+	for _, v := range cell.TargetGroupIndexValues(o.Meta.GetLabels()) {
+		var cells okrav1alpha1.CellList
+
+		if err := r.List(ctx, &cells, client.InNamespace(o.Meta.GetNamespace()), client.MatchingFields{cellTargetGroupSelectorIndexField: v}); err != nil {
+			r.Log.Error(err, "Listing cells for AWSTargetGroup", "awsTargetGroup", o.Meta.GetNamespace()+"/"+o.Meta.GetName())
+			return nil
+		}
+
+		for _, c := range cells.Items {
+			if seen[c.Name] || !cell.SelectsTargetGroup(c, o.Meta.GetLabels()) {
+				continue
+			}
+
+			seen[c.Name] = true
+
+			reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: c.Namespace, Name: c.Name}})
+		}
+	}
+
+	return reqs
+}
@@ -22,14 +22,17 @@ import (
 	"time"
 
 	"github.com/go-logr/logr"
-	//"k8s.io/apimachinery/pkg/api/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	corev1 "k8s.io/api/core/v1"
-	//metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/mumoshu/okra/api/v1alpha1"
 	"github.com/mumoshu/okra/pkg/clusterset"
@@ -45,6 +48,9 @@ type ClusterSetReconciler struct {
 	Log      logr.Logger
 	Recorder record.EventRecorder
 	Scheme   *runtime.Scheme
+
+	// eksCache caches EKS DescribeCluster results across reconciliations
+	eksCache *clusterset.EKSClusterCache
 }
 
 // +kubebuilder:rbac:groups=okra.mumo.co,resources=clustersets,verbs=get;list;watch;create;update;patch;delete
@@ -52,6 +58,18 @@ type ClusterSetReconciler struct {
 // +kubebuilder:rbac:groups=okra.mumo.co,resources=clustersets/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=secrets/finalizers,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters,verbs=get;list;watch
+// +kubebuilder:rbac:groups=okra.mumo.co,resources=awsapplicationloadbalancerconfigs,verbs=get;list;watch
+// +kubebuilder:rbac:groups=okra.mumo.co,resources=awstargetgroups,verbs=get;list;watch
+// +kubebuilder:rbac:groups=okra.mumo.co,resources=cells,verbs=get;list;watch
+// +kubebuilder:rbac:groups=okra.mumo.co,resources=clusterendpoints,verbs=get;list;watch
+// +kubebuilder:rbac:groups=okra.mumo.co,resources=envoyrouteconfigs,verbs=get;list;watch
+// +kubebuilder:rbac:groups=okra.mumo.co,resources=awsroute53weightedrecordconfigs,verbs=get;list;watch
+// +kubebuilder:rbac:groups=okra.mumo.co,resources=awsglobalacceleratorconfigs,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch
+// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=httproutes,verbs=get;list;watch
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch
+// +kubebuilder:rbac:groups=networking.istio.io,resources=virtualservices,verbs=get;list;watch
 // +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 
 func (r *ClusterSetReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
@@ -75,7 +93,8 @@ func (r *ClusterSetReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error)
 				return ctrl.Result{}, err
 			}
 
-			return ctrl.Result{}, nil
+			// Requeue explicitly as updating finalizers does not change the generation
+			return ctrl.Result{Requeue: true}, nil
 		}
 	} else {
 		finalizers, removed := removeFinalizer(clusterSet.ObjectMeta.Finalizers)
@@ -97,29 +116,111 @@ func (r *ClusterSetReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error)
 		return ctrl.Result{}, nil
 	}
 
+	if len(clusterSet.Spec.Generators) == 0 {
+		log.Info("Skipped syncing clusters as no generators are specified")
+
+		return ctrl.Result{}, nil
+	}
+
+	var stats clusterset.DiscoveryStats
+
 	config := clusterset.SyncInput{
-		DryRun:  false,
-		NS:      req.Namespace,
-		EKSTags: clusterSet.Spec.Generators[0].AWSEKS.Selector.MatchTags,
-		Labels:  clusterSet.Spec.Template.Metadata.Labels,
+		DryRun: false,
+		NS:     req.Namespace,
+		Template: clusterset.SecretTemplate{
+			Labels:           clusterSet.Spec.Template.Metadata.Labels,
+			Annotations:      clusterSet.Spec.Template.Metadata.Annotations,
+			Project:          clusterSet.Spec.Template.Project,
+			Namespaces:       clusterSet.Spec.Template.Namespaces,
+			ClusterResources: clusterSet.Spec.Template.ClusterResources,
+		},
+		Generators: clusterset.NewGenerators(req.Namespace, clusterSet.Spec.Generators),
+		EKSCache:   r.eksCache,
+		Stats:      &stats,
+		Context:    ctx,
+		Client:     r.Client,
+	}
+
+	results, syncErr := clusterset.Sync(config)
+	if syncErr != nil {
+		log.Error(syncErr, "Syncing clusters")
 	}
 
-	if err := clusterset.Sync(config); err != nil {
-		log.Error(err, "Syncing clusters")
+	updated := clusterSet.DeepCopy()
+	now := metav1.Now()
+	clusterset.UpdateStatus(&updated.Status, results, &stats, syncErr, now)
 
-		return ctrl.Result{RequeueAfter: 10 * time.Second}, err
+	interval := clusterset.HealthCheckInterval(clusterSet.Spec.HealthCheck)
+	if interval > 0 {
+		if err := r.checkHealth(ctx, updated, now); err != nil {
+			log.Error(err, "Checking cluster health")
+			return ctrl.Result{}, err
+		}
+	} else {
+		updated.Status.ClusterHealth = nil
+	}
+
+	if err := r.Status().Update(ctx, updated); err != nil {
+		log.Error(err, "Failed to update clusterSet status")
+		return ctrl.Result{}, err
+	}
+
+	if syncErr != nil {
+		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
 	}
 
 	r.Recorder.Event(&clusterSet, corev1.EventTypeNormal, "SyncFinished", fmt.Sprintf("Sync finished on '%s'", clusterSet.Name))
 
-	return ctrl.Result{}, nil
+	// Requeue to probe the clusters periodically, as status updates do not trigger reconciliations
+	return ctrl.Result{RequeueAfter: interval}, nil
+}
+
+// checkHealth probes the clusters of the ClusterSet, records the results in its status,
+// and emits events on the clusters whose connection status changed.
+func (r *ClusterSetReconciler) checkHealth(ctx context.Context, clusterSet *v1alpha1.ClusterSet, now metav1.Time) error {
+	var secrets []corev1.Secret
+
+	for _, name := range clusterSet.Status.Clusters.Names {
+		var sec corev1.Secret
+
+		if err := r.Get(ctx, types.NamespacedName{Namespace: clusterSet.Namespace, Name: name}, &sec); err != nil {
+			if kerrors.IsNotFound(err) {
+				continue
+			}
+
+			return fmt.Errorf("getting cluster secret %s: %w", name, err)
+		}
+
+		secrets = append(secrets, sec)
+	}
+
+	health, transitions := clusterset.CheckHealth(ctx, clusterset.HealthCheckInput{
+		Secrets:  secrets,
+		Timeout:  clusterset.HealthCheckTimeout(clusterSet.Spec.HealthCheck),
+		Previous: clusterSet.Status.ClusterHealth,
+		Now:      now,
+	})
+
+	clusterSet.Status.ClusterHealth = health
+
+	for _, t := range transitions {
+		if t.Status == v1alpha1.ClusterConnectionStatusSuccessful {
+			r.Recorder.Eventf(clusterSet, corev1.EventTypeNormal, "ClusterReachable", "Cluster '%s' is reachable", t.Name)
+		} else {
+			r.Recorder.Eventf(clusterSet, corev1.EventTypeWarning, "ClusterUnreachable", "Cluster '%s' is unreachable: %s", t.Name, t.Message)
+		}
+	}
+
+	return nil
 }
 
 func (r *ClusterSetReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.Recorder = mgr.GetEventRecorderFor("clusterset-controller")
+	r.eksCache = clusterset.NewEKSClusterCache(clusterset.DefaultEKSClusterCacheTTL)
 
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&v1alpha1.ClusterSet{}).
+		// Ignore status updates made by the reconciler itself
+		For(&v1alpha1.ClusterSet{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
 		Owns(&corev1.Secret{}).
 		Complete(r)
 }
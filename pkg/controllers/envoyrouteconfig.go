@@ -0,0 +1,85 @@
+/*
+Copyright 2020 The Okra authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/mumoshu/okra/api/v1alpha1"
+	"github.com/mumoshu/okra/pkg/envoyxds"
+)
+
+// EnvoyRouteConfigReconciler reports the versions of the xDS snapshots served for EnvoyRouteConfig objects in their status
+type EnvoyRouteConfigReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Recorder record.EventRecorder
+	Scheme   *runtime.Scheme
+	Server   *envoyxds.Server
+}
+
+// +kubebuilder:rbac:groups=okra.mumo.co,resources=envoyrouteconfigs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=okra.mumo.co,resources=envoyrouteconfigs/status,verbs=get;update;patch
+
+func (r *EnvoyRouteConfigReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("envoyRouteConfig", req.NamespacedName)
+
+	var routeConfig v1alpha1.EnvoyRouteConfig
+	if err := r.Get(ctx, req.NamespacedName, &routeConfig); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	// Snapshots are computed by the xDS syncer that runs on every replica, including this one.
+	// Return an error until it catches up, so that the rate-limited workqueue backs off while the sync keeps failing.
+	version, synced := r.Server.SnapshotVersion(routeConfig)
+	if !synced {
+		return ctrl.Result{}, fmt.Errorf("xDS snapshot for EnvoyRouteConfig %s is not served yet", req.NamespacedName)
+	}
+
+	if routeConfig.Status.Version != version {
+		updated := routeConfig.DeepCopy()
+		updated.Status.Version = version
+		updated.Status.LastSyncTime = metav1.Now()
+		updated.Status.Phase = "Synced"
+
+		if err := r.Status().Update(ctx, updated); err != nil {
+			log.Error(err, "Failed to update EnvoyRouteConfig status")
+			return ctrl.Result{}, err
+		}
+
+		log.Info("Served new xDS snapshot", "nodeCluster", routeConfig.Spec.NodeCluster, "version", version)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+func (r *EnvoyRouteConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.Recorder = mgr.GetEventRecorderFor("envoyrouteconfig-controller")
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.EnvoyRouteConfig{}).
+		Complete(r)
+}
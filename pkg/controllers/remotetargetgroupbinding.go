@@ -0,0 +1,229 @@
+/*
+Copyright 2020 The Okra authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	toolscache "k8s.io/client-go/tools/cache"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/mumoshu/okra/api/elbv2/v1beta1"
+	"github.com/mumoshu/okra/api/v1alpha1"
+	"github.com/mumoshu/okra/pkg/awstargetgroupset"
+	"github.com/mumoshu/okra/pkg/clclient"
+	"github.com/mumoshu/okra/pkg/clusterset"
+)
+
+// RemoteTargetGroupBindingReconciler maintains an informer on TargetGroupBindings per cluster secret,
+// and enqueues the AWSTargetGroupSets generating AWSTargetGroups from the cluster whenever a binding changes,
+// so that new bindings are synced in seconds instead of the sync period.
+type RemoteTargetGroupBindingReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+
+	// Events receives a generic event per AWSTargetGroupSet to be synced
+	Events chan event.GenericEvent
+	// ClusterClients, when set, gets the client for the cluster evicted once the cluster secret is deleted
+	ClusterClients *clclient.ClusterClientPool
+
+	mu      sync.Mutex
+	watches map[types.NamespacedName]*remoteBindingWatch
+}
+
+type remoteBindingWatch struct {
+	// version is the UID and the resourceVersion of the cluster secret the watch was started for
+	version string
+	stop    chan struct{}
+}
+
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=okra.mumo.co,resources=awstargetgroupsets,verbs=get;list;watch
+
+func (r *RemoteTargetGroupBindingReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("secret", req.NamespacedName)
+
+	var secret corev1.Secret
+	if err := r.Get(ctx, req.NamespacedName, &secret); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{}, err
+		}
+
+		r.stopWatch(req.NamespacedName)
+
+		return ctrl.Result{}, nil
+	}
+
+	if !secret.DeletionTimestamp.IsZero() || !isClusterSecret(&secret) {
+		r.stopWatch(req.NamespacedName)
+
+		return ctrl.Result{}, nil
+	}
+
+	version := fmt.Sprintf("%s/%s", secret.UID, secret.ResourceVersion)
+
+	r.mu.Lock()
+	w, ok := r.watches[req.NamespacedName]
+	r.mu.Unlock()
+
+	if ok && w.version == version {
+		return ctrl.Result{}, nil
+	}
+
+	// The cluster secret has changed, so that the informer needs to be recreated with the new connection details
+	r.stopWatch(req.NamespacedName)
+
+	stop, err := r.startWatch(secret)
+	if err != nil {
+		log.Error(err, "Starting TargetGroupBinding informer")
+
+		return ctrl.Result{RequeueAfter: time.Minute}, nil
+	}
+
+	r.mu.Lock()
+	r.watches[req.NamespacedName] = &remoteBindingWatch{version: version, stop: stop}
+	r.mu.Unlock()
+
+	log.Info("Started TargetGroupBinding informer", "version", version)
+
+	return ctrl.Result{}, nil
+}
+
+func (r *RemoteTargetGroupBindingReconciler) startWatch(secret corev1.Secret) (chan struct{}, error) {
+	cluster, err := clclient.SecretToCluster(&secret)
+	if err != nil {
+		return nil, fmt.Errorf("secret to cluster: %w", err)
+	}
+
+	c, err := cache.New(cluster.RESTConfig(), cache.Options{Scheme: clclient.Scheme()})
+	if err != nil {
+		return nil, fmt.Errorf("creating cache: %w", err)
+	}
+
+	// This does not block as the cache is not started yet
+	informer, err := c.GetInformer(context.Background(), &v1beta1.TargetGroupBinding{})
+	if err != nil {
+		return nil, fmt.Errorf("getting informer: %w", err)
+	}
+
+	key := types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name}
+
+	informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			r.enqueueAWSTargetGroupSets(key)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			o, ok1 := oldObj.(*v1beta1.TargetGroupBinding)
+			n, ok2 := newObj.(*v1beta1.TargetGroupBinding)
+
+			// Skip periodic resyncs
+			if ok1 && ok2 && o.ResourceVersion == n.ResourceVersion {
+				return
+			}
+
+			r.enqueueAWSTargetGroupSets(key)
+		},
+		DeleteFunc: func(obj interface{}) {
+			r.enqueueAWSTargetGroupSets(key)
+		},
+	})
+
+	stop := make(chan struct{})
+
+	go func() {
+		if err := c.Start(stop); err != nil {
+			r.Log.Error(err, "Running TargetGroupBinding informer", "secret", key)
+		}
+	}()
+
+	return stop, nil
+}
+
+func (r *RemoteTargetGroupBindingReconciler) stopWatch(key types.NamespacedName) {
+	if r.ClusterClients != nil {
+		r.ClusterClients.Evict(key.Namespace, key.Name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if w, ok := r.watches[key]; ok {
+		close(w.stop)
+		delete(r.watches, key)
+
+		r.Log.Info("Stopped TargetGroupBinding informer", "secret", key)
+	}
+}
+
+// enqueueAWSTargetGroupSets sends an event for every AWSTargetGroupSet that generates AWSTargetGroups from the cluster.
+func (r *RemoteTargetGroupBindingReconciler) enqueueAWSTargetGroupSets(key types.NamespacedName) {
+	ctx := context.Background()
+
+	var secret corev1.Secret
+	if err := r.Get(ctx, key, &secret); err != nil {
+		r.Log.Error(err, "Getting cluster secret", "secret", key)
+		return
+	}
+
+	var sets v1alpha1.AWSTargetGroupSetList
+	if err := r.List(ctx, &sets, client.InNamespace(key.Namespace)); err != nil {
+		r.Log.Error(err, "Listing AWSTargetGroupSets", "namespace", key.Namespace)
+		return
+	}
+
+	for i := range sets.Items {
+		set := &sets.Items[i]
+
+		if !awstargetgroupset.SelectsCluster(awstargetgroupset.NewGenerators(set.Spec.Generators), secret) {
+			continue
+		}
+
+		r.Events <- event.GenericEvent{Meta: set, Object: set}
+	}
+}
+
+func isClusterSecret(secret *corev1.Secret) bool {
+	return secret.Labels[clusterset.SecretLabelKeyArgoCDType] == clusterset.SecretLabelValueArgoCDCluster
+}
+
+func (r *RemoteTargetGroupBindingReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.watches = map[types.NamespacedName]*remoteBindingWatch{}
+
+	clusterSecrets := predicate.NewPredicateFuncs(func(meta metav1.Object, _ runtime.Object) bool {
+		return meta.GetLabels()[clusterset.SecretLabelKeyArgoCDType] == clusterset.SecretLabelValueArgoCDCluster
+	})
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("remotetargetgroupbinding").
+		For(&corev1.Secret{}, builder.WithPredicates(clusterSecrets)).
+		Complete(r)
+}
@@ -0,0 +1,408 @@
+package envoyxds
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	endpoint "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/cache/types"
+	cachev3 "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	okrav1alpha1 "github.com/mumoshu/okra/api/v1alpha1"
+	"github.com/mumoshu/okra/pkg/awsclicompat"
+	"github.com/mumoshu/okra/pkg/sync"
+	"golang.org/x/xerrors"
+)
+
+const (
+	DefaultListenerPort = 10000
+	DefaultPathPrefix   = "/"
+
+	connectTimeout = 5 * time.Second
+)
+
+type SyncInput struct {
+	// RouteConfigs is the list of all the EnvoyRouteConfigs to be served
+	RouteConfigs []okrav1alpha1.EnvoyRouteConfig
+	Server       *Server
+
+	Session *session.Session
+	// Address is the endpoint of the ELBv2 API. Used only for testing.
+	Address string
+}
+
+// Sync computes xDS snapshots from all the EnvoyRouteConfigs and serves them.
+// It returns the snapshot version per node cluster.
+func Sync(d SyncInput) (map[string]string, error) {
+	resolved, err := resolveEndpoints(d)
+	if err != nil {
+		return nil, err
+	}
+
+	byNodeCluster := map[string][]okrav1alpha1.EnvoyRouteConfig{}
+
+	for _, rc := range resolved {
+		byNodeCluster[rc.Spec.NodeCluster] = append(byNodeCluster[rc.Spec.NodeCluster], rc)
+	}
+
+	snapshots := map[string]cachev3.Snapshot{}
+	versions := map[string]string{}
+
+	for nodeCluster, rcs := range byNodeCluster {
+		snapshot, version, err := newSnapshot(rcs)
+		if err != nil {
+			return nil, xerrors.Errorf("computing snapshot for node cluster %s: %w", nodeCluster, err)
+		}
+
+		snapshots[nodeCluster] = *snapshot
+		versions[nodeCluster] = version
+	}
+
+	if err := d.Server.setSnapshots(snapshots, snapshotVersion(resolved)); err != nil {
+		return nil, err
+	}
+
+	d.Server.setSynced(versions, d.RouteConfigs)
+
+	return versions, nil
+}
+
+// resolveEndpoints returns the copy of the route configs whose clusters have
+// target group ARNs resolved into the addresses of the targets.
+func resolveEndpoints(d SyncInput) ([]okrav1alpha1.EnvoyRouteConfig, error) {
+	var svc *elbv2.ELBV2
+
+	var resolved []okrav1alpha1.EnvoyRouteConfig
+
+	for _, rc := range d.RouteConfigs {
+		rc := *rc.DeepCopy()
+
+		for i, c := range rc.Spec.Clusters {
+			if c.TargetGroupARN == "" {
+				continue
+			}
+
+			if svc == nil {
+				sess := d.Session
+				if sess == nil {
+					sess = awsclicompat.NewSession("", "")
+				}
+
+				if d.Address != "" {
+					sess.Config.Endpoint = &d.Address
+				}
+
+				svc = elbv2.New(sess)
+			}
+
+			addrs, err := targetGroupAddresses(svc, c.TargetGroupARN)
+			if err != nil {
+				return nil, xerrors.Errorf("envoyrouteconfig %s/%s: cluster %s: %w", rc.Namespace, rc.Name, c.Name, err)
+			}
+
+			rc.Spec.Clusters[i].Addresses = append(c.Addresses, addrs...)
+		}
+
+		resolved = append(resolved, rc)
+	}
+
+	return resolved, nil
+}
+
+// targetGroupAddresses returns the addresses of the healthy targets in the target group.
+// When no target is healthy, it returns all the targets that are not draining,
+// as AWS load balancers fail open in that case.
+func targetGroupAddresses(svc *elbv2.ELBV2, arn string) ([]string, error) {
+	result, err := svc.DescribeTargetHealth(&elbv2.DescribeTargetHealthInput{
+		TargetGroupArn: aws.String(arn),
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("calling elbv2.DescribeTargetHealth: %w", err)
+	}
+
+	var healthy, all []string
+
+	for _, d := range result.TargetHealthDescriptions {
+		if d.Target == nil || d.Target.Id == nil || d.Target.Port == nil {
+			continue
+		}
+
+		if net.ParseIP(*d.Target.Id) == nil {
+			log.Printf("Skipping target %s in target group %s: only targets registered by IP addresses are supported", *d.Target.Id, arn)
+			continue
+		}
+
+		addr := net.JoinHostPort(*d.Target.Id, strconv.Itoa(int(*d.Target.Port)))
+
+		var state string
+		if d.TargetHealth != nil && d.TargetHealth.State != nil {
+			state = *d.TargetHealth.State
+		}
+
+		if state == elbv2.TargetHealthStateEnumHealthy {
+			healthy = append(healthy, addr)
+		}
+
+		if state != elbv2.TargetHealthStateEnumDraining {
+			all = append(all, addr)
+		}
+	}
+
+	if len(healthy) > 0 {
+		return healthy, nil
+	}
+
+	return all, nil
+}
+
+// snapshotVersion computes the version of the snapshot from the identities and the specs of the route configs.
+// We intentionally exclude the rest of the metadata and the status, so that updating the status
+// with the version doesn't change the version.
+func snapshotVersion(rcs []okrav1alpha1.EnvoyRouteConfig) string {
+	type versioned struct {
+		Namespace, Name string
+		Spec            okrav1alpha1.EnvoyRouteConfigSpec
+	}
+
+	var vs []versioned
+
+	for _, rc := range rcs {
+		vs = append(vs, versioned{Namespace: rc.Namespace, Name: rc.Name, Spec: rc.Spec})
+	}
+
+	return sync.ComputeHash(vs)
+}
+
+// ClusterName returns the name of the Envoy cluster for the okra cluster in the route config.
+func ClusterName(rc okrav1alpha1.EnvoyRouteConfig, c okrav1alpha1.EnvoyCluster) string {
+	return fmt.Sprintf("%s/%s/%s", rc.Namespace, rc.Name, c.Name)
+}
+
+func listenerPort(rc okrav1alpha1.EnvoyRouteConfig) uint32 {
+	if rc.Spec.Listener.Port == 0 {
+		return DefaultListenerPort
+	}
+
+	return uint32(rc.Spec.Listener.Port)
+}
+
+func routeName(port uint32) string {
+	return fmt.Sprintf("okra_%d", port)
+}
+
+// newSnapshot returns the snapshot for all the route configs of a node cluster.
+// Route configs are grouped by listener ports, and each route config becomes a virtual host
+// of the route configuration for the port.
+func newSnapshot(rcs []okrav1alpha1.EnvoyRouteConfig) (*cachev3.Snapshot, string, error) {
+	sort.Slice(rcs, func(i, j int) bool {
+		if rcs[i].Namespace != rcs[j].Namespace {
+			return rcs[i].Namespace < rcs[j].Namespace
+		}
+		return rcs[i].Name < rcs[j].Name
+	})
+
+	version := snapshotVersion(rcs)
+
+	var (
+		listeners, routes, clusters []types.Resource
+		ports                       []uint32
+	)
+
+	virtualHosts := map[uint32][]*route.VirtualHost{}
+
+	for _, rc := range rcs {
+		port := listenerPort(rc)
+
+		if _, ok := virtualHosts[port]; !ok {
+			ports = append(ports, port)
+		}
+
+		virtualHosts[port] = append(virtualHosts[port], newVirtualHost(rc))
+
+		for _, c := range rc.Spec.Clusters {
+			cl, err := newCluster(ClusterName(rc, c), c.Addresses)
+			if err != nil {
+				return nil, "", xerrors.Errorf("envoyrouteconfig %s/%s: %w", rc.Namespace, rc.Name, err)
+			}
+
+			clusters = append(clusters, cl)
+		}
+	}
+
+	for _, port := range ports {
+		l, err := newListener(port)
+		if err != nil {
+			return nil, "", err
+		}
+
+		listeners = append(listeners, l)
+
+		routes = append(routes, &route.RouteConfiguration{
+			Name:         routeName(port),
+			VirtualHosts: virtualHosts[port],
+		})
+	}
+
+	snapshot := cachev3.NewSnapshot(version, nil, clusters, routes, listeners, nil, nil)
+
+	return &snapshot, version, nil
+}
+
+func newVirtualHost(rc okrav1alpha1.EnvoyRouteConfig) *route.VirtualHost {
+	domains := rc.Spec.Listener.Domains
+	if len(domains) == 0 {
+		domains = []string{"*"}
+	}
+
+	prefix := rc.Spec.Listener.PathPrefix
+	if prefix == "" {
+		prefix = DefaultPathPrefix
+	}
+
+	var (
+		weighted []*route.WeightedCluster_ClusterWeight
+		total    uint32
+	)
+
+	for _, c := range rc.Spec.Clusters {
+		weighted = append(weighted, &route.WeightedCluster_ClusterWeight{
+			Name:   ClusterName(rc, c),
+			Weight: &wrappers.UInt32Value{Value: uint32(c.Weight)},
+		})
+
+		total += uint32(c.Weight)
+	}
+
+	return &route.VirtualHost{
+		Name:    fmt.Sprintf("%s/%s", rc.Namespace, rc.Name),
+		Domains: domains,
+		Routes: []*route.Route{{
+			Match: &route.RouteMatch{
+				PathSpecifier: &route.RouteMatch_Prefix{
+					Prefix: prefix,
+				},
+			},
+			Action: &route.Route_Route{
+				Route: &route.RouteAction{
+					ClusterSpecifier: &route.RouteAction_WeightedClusters{
+						WeightedClusters: &route.WeightedCluster{
+							Clusters:    weighted,
+							TotalWeight: &wrappers.UInt32Value{Value: total},
+						},
+					},
+				},
+			},
+		}},
+	}
+}
+
+func newCluster(name string, addrs []string) (*cluster.Cluster, error) {
+	var lbEndpoints []*endpoint.LbEndpoint
+
+	for _, addr := range addrs {
+		host, portStr, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, xerrors.Errorf("parsing address %q of cluster %s: %w", addr, name, err)
+		}
+
+		port, err := strconv.ParseUint(portStr, 10, 32)
+		if err != nil {
+			return nil, xerrors.Errorf("parsing port of address %q of cluster %s: %w", addr, name, err)
+		}
+
+		lbEndpoints = append(lbEndpoints, &endpoint.LbEndpoint{
+			HostIdentifier: &endpoint.LbEndpoint_Endpoint{
+				Endpoint: &endpoint.Endpoint{
+					Address: &core.Address{
+						Address: &core.Address_SocketAddress{
+							SocketAddress: &core.SocketAddress{
+								Protocol: core.SocketAddress_TCP,
+								Address:  host,
+								PortSpecifier: &core.SocketAddress_PortValue{
+									PortValue: uint32(port),
+								},
+							},
+						},
+					},
+				},
+			},
+		})
+	}
+
+	return &cluster.Cluster{
+		Name:                 name,
+		ConnectTimeout:       ptypes.DurationProto(connectTimeout),
+		ClusterDiscoveryType: &cluster.Cluster_Type{Type: cluster.Cluster_STRICT_DNS},
+		LbPolicy:             cluster.Cluster_ROUND_ROBIN,
+		LoadAssignment: &endpoint.ClusterLoadAssignment{
+			ClusterName: name,
+			Endpoints: []*endpoint.LocalityLbEndpoints{{
+				LbEndpoints: lbEndpoints,
+			}},
+		},
+	}, nil
+}
+
+func newListener(port uint32) (*listener.Listener, error) {
+	manager := &hcm.HttpConnectionManager{
+		CodecType:  hcm.HttpConnectionManager_AUTO,
+		StatPrefix: routeName(port),
+		RouteSpecifier: &hcm.HttpConnectionManager_Rds{
+			Rds: &hcm.Rds{
+				ConfigSource: &core.ConfigSource{
+					ResourceApiVersion: resource.DefaultAPIVersion,
+					ConfigSourceSpecifier: &core.ConfigSource_Ads{
+						Ads: &core.AggregatedConfigSource{},
+					},
+				},
+				RouteConfigName: routeName(port),
+			},
+		},
+		HttpFilters: []*hcm.HttpFilter{{
+			Name: wellknown.Router,
+		}},
+	}
+
+	typedConfig, err := ptypes.MarshalAny(manager)
+	if err != nil {
+		return nil, xerrors.Errorf("marshaling http connection manager: %w", err)
+	}
+
+	return &listener.Listener{
+		Name: routeName(port),
+		Address: &core.Address{
+			Address: &core.Address_SocketAddress{
+				SocketAddress: &core.SocketAddress{
+					Protocol: core.SocketAddress_TCP,
+					Address:  "0.0.0.0",
+					PortSpecifier: &core.SocketAddress_PortValue{
+						PortValue: port,
+					},
+				},
+			},
+		},
+		FilterChains: []*listener.FilterChain{{
+			Filters: []*listener.Filter{{
+				Name: wellknown.HTTPConnectionManager,
+				ConfigType: &listener.Filter_TypedConfig{
+					TypedConfig: typedConfig,
+				},
+			}},
+		}},
+	}, nil
+}
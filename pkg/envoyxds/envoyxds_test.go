@@ -0,0 +1,158 @@
+package envoyxds
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"github.com/google/go-cmp/cmp"
+	okrav1alpha1 "github.com/mumoshu/okra/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const describeTargetHealthResponse = `<DescribeTargetHealthResponse xmlns="http://elasticloadbalancing.amazonaws.com/doc/2015-12-01/">
+  <DescribeTargetHealthResult>
+    <TargetHealthDescriptions>
+      <member>
+        <Target><Id>10.0.0.1</Id><Port>8080</Port></Target>
+        <TargetHealth><State>healthy</State></TargetHealth>
+      </member>
+      <member>
+        <Target><Id>10.0.0.2</Id><Port>8080</Port></Target>
+        <TargetHealth><State>unhealthy</State></TargetHealth>
+      </member>
+    </TargetHealthDescriptions>
+  </DescribeTargetHealthResult>
+  <ResponseMetadata><RequestId>1</RequestId></ResponseMetadata>
+</DescribeTargetHealthResponse>`
+
+func newRouteConfig(name string, clusters ...okrav1alpha1.EnvoyCluster) okrav1alpha1.EnvoyRouteConfig {
+	return okrav1alpha1.EnvoyRouteConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      name,
+		},
+		Spec: okrav1alpha1.EnvoyRouteConfigSpec{
+			NodeCluster: "front",
+			Listener: okrav1alpha1.EnvoyListener{
+				Domains: []string{name + ".example.com"},
+			},
+			Clusters: clusters,
+		},
+	}
+}
+
+func TestSync(t *testing.T) {
+	elbv2 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/xml")
+		w.Write([]byte(describeTargetHealthResponse))
+	}))
+	defer elbv2.Close()
+
+	sess := session.Must(session.NewSession(&aws.Config{
+		Region:      aws.String("us-east-1"),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+	}))
+
+	server := NewServer(":0")
+
+	versions, err := Sync(SyncInput{
+		RouteConfigs: []okrav1alpha1.EnvoyRouteConfig{
+			newRouteConfig("web",
+				okrav1alpha1.EnvoyCluster{Name: "web-1", Weight: 80, Addresses: []string{"web-1.example.com:80"}},
+				okrav1alpha1.EnvoyCluster{Name: "web-2", Weight: 20, TargetGroupARN: "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/web-2/1"},
+			),
+		},
+		Server:  server,
+		Session: sess,
+		Address: elbv2.URL,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	snapshot, err := server.cache.GetSnapshot("front")
+	if err != nil {
+		t.Fatalf("getting snapshot: %v", err)
+	}
+
+	if got := snapshot.GetVersion(resource.ListenerType); got != versions["front"] {
+		t.Errorf("unexpected snapshot version: want %s, got %s", versions["front"], got)
+	}
+
+	routes := snapshot.GetResources(resource.RouteType)
+	rc, ok := routes["okra_10000"].(*route.RouteConfiguration)
+	if !ok {
+		t.Fatalf("missing route configuration okra_10000: %v", routes)
+	}
+
+	weights := map[string]uint32{}
+	for _, c := range rc.VirtualHosts[0].Routes[0].GetRoute().GetWeightedClusters().Clusters {
+		weights[c.Name] = c.Weight.Value
+	}
+
+	if d := cmp.Diff(map[string]uint32{"default/web/web-1": 80, "default/web/web-2": 20}, weights); d != "" {
+		t.Errorf("unexpected weights: %s", d)
+	}
+
+	clusters := snapshot.GetResources(resource.ClusterType)
+	c, ok := clusters["default/web/web-2"].(*cluster.Cluster)
+	if !ok {
+		t.Fatalf("missing cluster default/web/web-2: %v", clusters)
+	}
+
+	var addrs []string
+	for _, e := range c.LoadAssignment.Endpoints[0].LbEndpoints {
+		addrs = append(addrs, e.GetEndpoint().Address.GetSocketAddress().Address)
+	}
+
+	if d := cmp.Diff([]string{"10.0.0.1"}, addrs); d != "" {
+		t.Errorf("unexpected endpoints of the target group: %s", d)
+	}
+
+	// Deleting all the route configs should result in an empty snapshot
+	if _, err := Sync(SyncInput{Server: server}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	snapshot, err = server.cache.GetSnapshot("front")
+	if err != nil {
+		t.Fatalf("getting snapshot: %v", err)
+	}
+
+	if n := len(snapshot.GetResources(resource.ListenerType)); n != 0 {
+		t.Errorf("expected no listeners, got %d", n)
+	}
+}
+
+func TestSnapshotVersion(t *testing.T) {
+	server := NewServer(":0")
+
+	rc := newRouteConfig("web", okrav1alpha1.EnvoyCluster{Name: "web-1", Weight: 100, Addresses: []string{"web-1.example.com:80"}})
+	rc.Generation = 1
+
+	if _, synced := server.SnapshotVersion(rc); synced {
+		t.Errorf("expected the route config to be not synced yet")
+	}
+
+	versions, err := Sync(SyncInput{RouteConfigs: []okrav1alpha1.EnvoyRouteConfig{rc}, Server: server})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if version, synced := server.SnapshotVersion(rc); !synced || version != versions["front"] {
+		t.Errorf("unexpected snapshot version: want %s, got %s (synced=%v)", versions["front"], version, synced)
+	}
+
+	rc.Generation = 2
+
+	if _, synced := server.SnapshotVersion(rc); synced {
+		t.Errorf("expected the updated route config to be not synced yet")
+	}
+}
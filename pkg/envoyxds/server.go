@@ -0,0 +1,145 @@
+package envoyxds
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	cachev3 "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	serverv3 "github.com/envoyproxy/go-control-plane/pkg/server/v3"
+	okrav1alpha1 "github.com/mumoshu/okra/api/v1alpha1"
+	"google.golang.org/grpc"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Server is an xDS server that serves Envoy configurations computed from EnvoyRouteConfigs
+// over the Aggregated Discovery Service (ADS).
+//
+// Envoy nodes are identified by their cluster names, so that all the Envoy front proxies
+// that share the same `--service-cluster` receive the same configuration.
+type Server struct {
+	// Address is the address the gRPC server listens on, like `:18000`
+	Address string
+
+	cache cachev3.SnapshotCache
+
+	mu sync.Mutex
+	// nodeClusters is the set of node clusters that we have set snapshots for
+	nodeClusters map[string]struct{}
+	// versions are the snapshot versions per node cluster
+	versions map[string]string
+	// generations are the generations of the route configs the snapshots are computed from
+	generations map[types.NamespacedName]int64
+}
+
+// nodeClusterHash uses the cluster name of the Envoy node as the node hash.
+type nodeClusterHash struct{}
+
+func (nodeClusterHash) ID(node *core.Node) string {
+	if node == nil {
+		return ""
+	}
+
+	return node.Cluster
+}
+
+func NewServer(addr string) *Server {
+	return &Server{
+		Address: addr,
+		cache:   cachev3.NewSnapshotCache(true, nodeClusterHash{}, nil),
+	}
+}
+
+// Start runs the gRPC server until the stop channel is closed.
+// It implements controller-runtime's manager.Runnable.
+func (s *Server) Start(stop <-chan struct{}) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	lis, err := net.Listen("tcp", s.Address)
+	if err != nil {
+		return fmt.Errorf("listening on %s: %w", s.Address, err)
+	}
+
+	grpcServer := grpc.NewServer()
+
+	discovery.RegisterAggregatedDiscoveryServiceServer(grpcServer, serverv3.NewServer(ctx, s.cache, nil))
+
+	go func() {
+		<-stop
+		grpcServer.GracefulStop()
+	}()
+
+	log.Printf("Starting xDS server on %s", s.Address)
+
+	return grpcServer.Serve(lis)
+}
+
+// NeedLeaderElection returns false so that every replica of okrad serves xDS.
+// Every replica fills its own snapshot cache with a Syncer.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+// SnapshotVersion returns the version of the snapshot served for the node cluster of the route config.
+// The second return value is false when the snapshot is yet to be computed from the current generation of the route config.
+func (s *Server) SnapshotVersion(rc okrav1alpha1.EnvoyRouteConfig) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	gen, ok := s.generations[types.NamespacedName{Namespace: rc.Namespace, Name: rc.Name}]
+	if !ok || gen != rc.Generation {
+		return "", false
+	}
+
+	return s.versions[rc.Spec.NodeCluster], true
+}
+
+// setSynced records the snapshot versions and the route configs they are computed from.
+func (s *Server) setSynced(versions map[string]string, rcs []okrav1alpha1.EnvoyRouteConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.versions = versions
+	s.generations = map[types.NamespacedName]int64{}
+
+	for _, rc := range rcs {
+		s.generations[types.NamespacedName{Namespace: rc.Namespace, Name: rc.Name}] = rc.Generation
+	}
+}
+
+// setSnapshots replaces all the snapshots with the given ones.
+// Node clusters that are missing in the given snapshots get empty snapshots
+// so that Envoy nodes remove the configuration previously served by okra.
+func (s *Server) setSnapshots(snapshots map[string]cachev3.Snapshot, emptyVersion string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for nodeCluster := range s.nodeClusters {
+		if _, ok := snapshots[nodeCluster]; !ok {
+			if err := s.cache.SetSnapshot(nodeCluster, cachev3.NewSnapshot(emptyVersion, nil, nil, nil, nil, nil, nil)); err != nil {
+				return fmt.Errorf("clearing snapshot for node cluster %s: %w", nodeCluster, err)
+			}
+		}
+	}
+
+	s.nodeClusters = map[string]struct{}{}
+
+	for nodeCluster, snapshot := range snapshots {
+		if err := snapshot.Consistent(); err != nil {
+			return fmt.Errorf("inconsistent snapshot for node cluster %s: %w", nodeCluster, err)
+		}
+
+		if err := s.cache.SetSnapshot(nodeCluster, snapshot); err != nil {
+			return fmt.Errorf("setting snapshot for node cluster %s: %w", nodeCluster, err)
+		}
+
+		s.nodeClusters[nodeCluster] = struct{}{}
+	}
+
+	return nil
+}
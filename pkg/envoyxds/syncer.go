@@ -0,0 +1,103 @@
+package envoyxds
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	okrav1alpha1 "github.com/mumoshu/okra/api/v1alpha1"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+)
+
+// syncRetryInterval is the interval to retry a failed sync
+const syncRetryInterval = 10 * time.Second
+
+// Syncer keeps the snapshots of the server in sync with the EnvoyRouteConfigs in the cache.
+// It runs on every replica of okrad, like the server, so that Envoy nodes connected to any replica
+// receive the configuration, regardless of which replica is the leader.
+type Syncer struct {
+	Cache  cache.Cache
+	Server *Server
+}
+
+// Start syncs the snapshots whenever an EnvoyRouteConfig changes, until the stop channel is closed.
+// It implements controller-runtime's manager.Runnable.
+func (s *Syncer) Start(stop <-chan struct{}) error {
+	informer, err := s.Cache.GetInformer(context.Background(), &okrav1alpha1.EnvoyRouteConfig{})
+	if err != nil {
+		return fmt.Errorf("getting envoyrouteconfig informer: %w", err)
+	}
+
+	changed := make(chan struct{}, 1)
+
+	notify := func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}
+
+	informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) { notify() },
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			o, ok1 := oldObj.(*okrav1alpha1.EnvoyRouteConfig)
+			n, ok2 := newObj.(*okrav1alpha1.EnvoyRouteConfig)
+
+			// Skip status updates, but not periodic resyncs that resolve target group addresses again
+			if ok1 && ok2 && o.Generation == n.Generation && o.ResourceVersion != n.ResourceVersion {
+				return
+			}
+
+			notify()
+		},
+		DeleteFunc: func(obj interface{}) { notify() },
+	})
+
+	if !s.Cache.WaitForCacheSync(stop) {
+		return fmt.Errorf("waiting for envoyrouteconfig cache to sync")
+	}
+
+	notify()
+
+	var retry <-chan time.Time
+
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-changed:
+		case <-retry:
+		}
+
+		retry = nil
+
+		if err := s.sync(); err != nil {
+			log.Printf("Failed syncing xDS snapshots. Retrying in %s: %v", syncRetryInterval, err)
+
+			retry = time.After(syncRetryInterval)
+		}
+	}
+}
+
+// NeedLeaderElection returns false so that every replica of okrad fills its own snapshot cache.
+func (s *Syncer) NeedLeaderElection() bool {
+	return false
+}
+
+func (s *Syncer) sync() error {
+	// An xDS snapshot contains all the route configs for the node cluster,
+	// so we need to recompute snapshots from all the route configs, even on deletion.
+	var routeConfigs okrav1alpha1.EnvoyRouteConfigList
+	if err := s.Cache.List(context.Background(), &routeConfigs); err != nil {
+		return err
+	}
+
+	_, err := Sync(SyncInput{
+		RouteConfigs: routeConfigs.Items,
+		Server:       s.Server,
+	})
+
+	return err
+}
@@ -0,0 +1,103 @@
+// Package generator combines the objects generated by ClusterSet and AWSTargetGroupSet generators.
+// Generated objects are identified by their names, like ApplicationSet generators' parameters are
+// identified by merge keys.
+package generator
+
+import (
+	"log"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Union returns all the objects generated by the generators, deduplicated by name.
+// When two or more generators generate objects with the same name, the object from the earliest generator wins.
+func Union(results ...[]metav1.Object) []metav1.Object {
+	var union []metav1.Object
+
+	seen := map[string]int{}
+
+	for i, objs := range results {
+		for _, o := range objs {
+			if j, ok := seen[o.GetName()]; ok {
+				if j != i {
+					log.Printf("Ignoring %s generated by generator %d, as it is already generated by generator %d", o.GetName(), i, j)
+				}
+
+				continue
+			}
+
+			seen[o.GetName()] = i
+
+			union = append(union, o)
+		}
+	}
+
+	return union
+}
+
+// Merge returns the objects generated by the first generator, whose labels are merged with the labels of
+// the objects of the same names generated by the rest of the generators.
+// Later generators take precedence on conflicting label keys.
+func Merge(results ...[]metav1.Object) []metav1.Object {
+	if len(results) == 0 {
+		return nil
+	}
+
+	return combine(Union(results[0]), results[1:], false)
+}
+
+// Matrix returns the objects generated by all the generators, whose labels are merged in order.
+// An object is omitted unless every generator generates an object of the same name.
+func Matrix(results ...[]metav1.Object) []metav1.Object {
+	if len(results) == 0 {
+		return nil
+	}
+
+	return combine(Union(results[0]), results[1:], true)
+}
+
+func combine(base []metav1.Object, rest [][]metav1.Object, intersect bool) []metav1.Object {
+	var combined []metav1.Object
+
+	for _, o := range base {
+		labels := map[string]string{}
+
+		for k, v := range o.GetLabels() {
+			labels[k] = v
+		}
+
+		found := true
+
+		for _, objs := range rest {
+			other := find(objs, o.GetName())
+			if other == nil {
+				found = false
+				continue
+			}
+
+			for k, v := range other.GetLabels() {
+				labels[k] = v
+			}
+		}
+
+		if intersect && !found {
+			continue
+		}
+
+		o.SetLabels(labels)
+
+		combined = append(combined, o)
+	}
+
+	return combined
+}
+
+func find(objs []metav1.Object, name string) metav1.Object {
+	for _, o := range objs {
+		if o.GetName() == name {
+			return o
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func objs(nameAndLabels ...interface{}) []metav1.Object {
+	var os []metav1.Object
+
+	for i := 0; i < len(nameAndLabels); i += 2 {
+		os = append(os, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   nameAndLabels[i].(string),
+				Labels: nameAndLabels[i+1].(map[string]string),
+			},
+		})
+	}
+
+	return os
+}
+
+func summary(os []metav1.Object) map[string]map[string]string {
+	s := map[string]map[string]string{}
+	for _, o := range os {
+		s[o.GetName()] = o.GetLabels()
+	}
+
+	return s
+}
+
+func TestCombinators(t *testing.T) {
+	testcases := []struct {
+		name    string
+		combine func(...[]metav1.Object) []metav1.Object
+		results [][]metav1.Object
+		want    map[string]map[string]string
+	}{
+		{
+			name:    "union keeps the first object on conflict",
+			combine: Union,
+			results: [][]metav1.Object{
+				objs("a", map[string]string{"from": "1"}, "b", map[string]string{"from": "1"}),
+				objs("b", map[string]string{"from": "2"}, "c", map[string]string{"from": "2"}),
+			},
+			want: map[string]map[string]string{
+				"a": {"from": "1"},
+				"b": {"from": "1"},
+				"c": {"from": "2"},
+			},
+		},
+		{
+			name:    "merge keeps the objects from the first generator",
+			combine: Merge,
+			results: [][]metav1.Object{
+				objs("a", map[string]string{"from": "1", "x": "1"}, "b", map[string]string{"from": "1"}),
+				objs("b", map[string]string{"from": "2", "y": "2"}, "c", map[string]string{"from": "2"}),
+			},
+			want: map[string]map[string]string{
+				"a": {"from": "1", "x": "1"},
+				"b": {"from": "2", "y": "2"},
+			},
+		},
+		{
+			name:    "matrix keeps the objects generated by all the generators",
+			combine: Matrix,
+			results: [][]metav1.Object{
+				objs("a", map[string]string{"x": "1"}, "b", map[string]string{"x": "1"}),
+				objs("b", map[string]string{"y": "2"}, "c", map[string]string{"y": "2"}),
+			},
+			want: map[string]map[string]string{
+				"b": {"x": "1", "y": "2"},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if d := cmp.Diff(tc.want, summary(tc.combine(tc.results...))); d != "" {
+				t.Errorf("unexpected result: %s", d)
+			}
+		})
+	}
+}
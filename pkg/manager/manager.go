@@ -8,8 +8,10 @@ import (
 
 	"github.com/mumoshu/okra/pkg/clclient"
 	"github.com/mumoshu/okra/pkg/controllers"
+	"github.com/mumoshu/okra/pkg/envoyxds"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	// +kubebuilder:scaffold:imports
 )
@@ -22,6 +24,7 @@ type Manager struct {
 	MetricsAddr          string
 	EnableLeaderElection bool
 	SyncPeriod           time.Duration
+	XDSAddr              string
 }
 
 func (m *Manager) AddFlags(fs flag.FlagSet) {
@@ -29,6 +32,7 @@ func (m *Manager) AddFlags(fs flag.FlagSet) {
 	fs.BoolVar(&m.EnableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. Enabling this will ensure there is only one active controller manager.")
 	fs.DurationVar(&m.SyncPeriod, "sync-period", 30*time.Second, "Determines the minimum frequency at which K8s resources managed by this controller are reconciled.")
+	fs.StringVar(&m.XDSAddr, "xds-addr", "", "The address the Envoy xDS (ADS) server binds to, like :18000. The xDS server is disabled when empty.")
 
 	//	flag.Parse()
 }
@@ -38,6 +42,7 @@ func (m *Manager) AddPFlags(fs *pflag.FlagSet) {
 	fs.BoolVar(&m.EnableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. Enabling this will ensure there is only one active controller manager.")
 	fs.DurationVar(&m.SyncPeriod, "sync-period", 30*time.Second, "Determines the minimum frequency at which K8s resources managed by this controller are reconciled.")
+	fs.StringVar(&m.XDSAddr, "xds-addr", "", "The address the Envoy xDS (ADS) server binds to, like :18000. The xDS server is disabled when empty.")
 
 	//	flag.Parse()
 }
@@ -77,10 +82,14 @@ func (m *Manager) Run() error {
 		return err
 	}
 
+	// bindingEvents carries AWSTargetGroupSets to be synced on TargetGroupBinding changes in the clusters
+	bindingEvents := make(chan event.GenericEvent, 1024)
+
 	awsTargetGroupSetReconciler := &controllers.AWSTargetGroupSetReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("AWSTargetGroupSet"),
-		Scheme: mgr.GetScheme(),
+		Client:        mgr.GetClient(),
+		Log:           ctrl.Log.WithName("controllers").WithName("AWSTargetGroupSet"),
+		Scheme:        mgr.GetScheme(),
+		BindingEvents: bindingEvents,
 	}
 
 	if err = awsTargetGroupSetReconciler.SetupWithManager(mgr); err != nil {
@@ -88,6 +97,30 @@ func (m *Manager) Run() error {
 		return err
 	}
 
+	remoteTargetGroupBindingReconciler := &controllers.RemoteTargetGroupBindingReconciler{
+		Client:         mgr.GetClient(),
+		Log:            ctrl.Log.WithName("controllers").WithName("RemoteTargetGroupBinding"),
+		Scheme:         mgr.GetScheme(),
+		Events:         bindingEvents,
+		ClusterClients: clclient.DefaultClusterClientPool,
+	}
+
+	if err = remoteTargetGroupBindingReconciler.SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "RemoteTargetGroupBinding")
+		return err
+	}
+
+	awsTargetGroupReconciler := &controllers.AWSTargetGroupReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("AWSTargetGroup"),
+		Scheme: mgr.GetScheme(),
+	}
+
+	if err = awsTargetGroupReconciler.SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "AWSTargetGroup")
+		return err
+	}
+
 	awsALBConfigReconciler := &controllers.AWSApplicationLoadBalancerConfigReconciler{
 		Client: mgr.GetClient(),
 		Log:    ctrl.Log.WithName("controllers").WithName("AWSApplicationLoadBalancerConfig"),
@@ -99,6 +132,28 @@ func (m *Manager) Run() error {
 		return err
 	}
 
+	awsRoute53RecordConfigReconciler := &controllers.AWSRoute53WeightedRecordConfigReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("AWSRoute53WeightedRecordConfig"),
+		Scheme: mgr.GetScheme(),
+	}
+
+	if err = awsRoute53RecordConfigReconciler.SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "AWSRoute53WeightedRecordConfig")
+		return err
+	}
+
+	awsGlobalAcceleratorConfigReconciler := &controllers.AWSGlobalAcceleratorConfigReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("AWSGlobalAcceleratorConfig"),
+		Scheme: mgr.GetScheme(),
+	}
+
+	if err = awsGlobalAcceleratorConfigReconciler.SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "AWSGlobalAcceleratorConfig")
+		return err
+	}
+
 	cellReconciler := &controllers.CellReconciler{
 		Client: mgr.GetClient(),
 		Log:    ctrl.Log.WithName("controllers").WithName("Cell"),
@@ -121,6 +176,32 @@ func (m *Manager) Run() error {
 		return err
 	}
 
+	if m.XDSAddr != "" {
+		xdsServer := envoyxds.NewServer(m.XDSAddr)
+
+		if err = mgr.Add(xdsServer); err != nil {
+			setupLog.Error(err, "unable to add xDS server")
+			return err
+		}
+
+		if err = mgr.Add(&envoyxds.Syncer{Cache: mgr.GetCache(), Server: xdsServer}); err != nil {
+			setupLog.Error(err, "unable to add xDS syncer")
+			return err
+		}
+
+		envoyRouteConfigReconciler := &controllers.EnvoyRouteConfigReconciler{
+			Client: mgr.GetClient(),
+			Log:    ctrl.Log.WithName("controllers").WithName("EnvoyRouteConfig"),
+			Scheme: mgr.GetScheme(),
+			Server: xdsServer,
+		}
+
+		if err = envoyRouteConfigReconciler.SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "EnvoyRouteConfig")
+			return err
+		}
+	}
+
 	// +kubebuilder:scaffold:builder
 
 	setupLog.Info("starting manager")
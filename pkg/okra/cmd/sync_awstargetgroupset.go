@@ -59,9 +59,12 @@ func syncAWSTargetGroupSetCommand() *cobra.Command {
 	flag.StringVar(&c.ClusterName, "cluster-name", "", "ArgoCD Cluster name on which we find TargetGroupBinding")
 	flag.StringVar(&c.NS, "namespace", "", "Namespace of the ArgoCD Cluster and the generated AWSTargetGroup resources")
 	flag.StringVar(&bindingSelector, "targetgroupbinding-selector", "", "Comma-separated KEY=VALUE pairs of TargetGroupBinding resource labels")
+	flag.StringVar(&c.SetName, "set-name", "", "Name of the AWSTargetGroupSet to label the AWSTargetGroup resources with. When set, only the AWSTargetGroup resources labeled with it are deleted when outdated")
 	flag.StringSliceVar(&labelKVs, "labels", nil, "Comma-separated KEY=VALUE pairs of AWSTargetGroup labels")
+	flag.StringSliceVar(&c.LabelsFromCluster, "labels-from-cluster", nil, "Comma-separated keys of the cluster secret labels to copy onto AWSTargetGroup resources")
 	flag.BoolVar(&create, "create", true, "Sync by creating missing AWSTargetGroup resources")
 	flag.BoolVar(&delete, "delete", true, "Sync by deleting outdated AWSTargetGroup resources")
+	flag.BoolVar(&c.ForceDelete, "force-delete", false, "Delete outdated AWSTargetGroup resources even when they still receive traffic from ALBs")
 
 	return cmd
 }
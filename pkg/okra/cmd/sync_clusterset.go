@@ -11,10 +11,12 @@ func syncClusterSetCommand() *cobra.Command {
 	var c clusterset.SyncInput
 
 	var (
-		eksTags  []string
-		labelKVs []string
-		create   bool
-		delete   bool
+		eksTags      []string
+		labelKVs     []string
+		capiLabelKVs []string
+		capiNS       string
+		create       bool
+		delete       bool
 	)
 
 	cmd := &cobra.Command{
@@ -36,15 +38,35 @@ func syncClusterSetCommand() *cobra.Command {
 
 			c.Labels = labels
 
+			if len(capiLabelKVs) > 0 {
+				capiLabels := map[string]string{}
+				for _, kv := range capiLabelKVs {
+					split := strings.Split(kv, "=")
+					capiLabels[split[0]] = split[1]
+				}
+
+				ns := capiNS
+				if ns == "" {
+					ns = c.NS
+				}
+
+				c.ClusterAPI = &clusterset.ClusterAPISelector{
+					NS:          ns,
+					MatchLabels: capiLabels,
+				}
+			}
+
+			var err error
+
 			if create && delete {
-				return clusterset.Sync(c)
+				_, err = clusterset.Sync(c)
 			} else if create {
-				return clusterset.CreateMissingClusters(c)
+				_, err = clusterset.CreateMissingClusters(c)
 			} else if delete {
-				return clusterset.DeleteOutdatedClusters(c)
+				_, err = clusterset.DeleteOutdatedClusters(c)
 			}
 
-			return nil
+			return err
 		},
 	}
 
@@ -53,9 +75,14 @@ func syncClusterSetCommand() *cobra.Command {
 	flag.BoolVar(&c.DryRun, "dry-run", false, "")
 	flag.StringVar(&c.NS, "namespace", "", "")
 	flag.StringSliceVar(&eksTags, "eks-tags", nil, "Comma-separated KEY=VALUE pairs of EKS control-plane tags")
+	flag.StringSliceVar(&capiLabelKVs, "cluster-api-labels", nil, "Comma-separated KEY=VALUE pairs of Cluster API cluster labels. When specified, cluster secrets are generated from Cluster API clusters instead of EKS clusters")
+	flag.StringVar(&capiNS, "cluster-api-namespace", "", "The namespace of Cluster API clusters. Defaults to --namespace")
 	flag.StringSliceVar(&labelKVs, "labels", nil, "Comma-separated KEY=VALUE pairs of cluster secret labels")
+	flag.IntVar(&c.EKSConcurrency, "eks-concurrency", clusterset.DefaultEKSConcurrency, "The number of concurrent EKS DescribeCluster calls per region and role")
+	flag.Float32Var(&c.EKSQPS, "eks-qps", clusterset.DefaultEKSQPS, "The rate limit of EKS API calls per region and role")
 	flag.BoolVar(&create, "create", true, "Sync by creating missing clusters")
 	flag.BoolVar(&delete, "delete", true, "Sync by deleting outdated clusters")
+	flag.BoolVar(&c.ForceDelete, "force-delete", false, "Delete outdated clusters even when they still receive traffic from ALBs")
 
 	return cmd
 }
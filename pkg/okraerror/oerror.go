@@ -26,6 +26,11 @@ func (e Error) Error() string {
 	return e.err.Error()
 }
 
+// Unwrap returns the wrapped error, so that errors.Is and errors.As see through it
+func (e Error) Unwrap() error {
+	return e.err
+}
+
 type stack []uintptr
 
 func callers() *stack {
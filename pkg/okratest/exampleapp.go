@@ -38,7 +38,7 @@ func NewExampleApp(ctx context.Context, clusterName, ns, serviceName string) (*E
 	// 	fmt.Fprintf(os.Stderr, "%s=%s\n", k, v)
 	// }
 
-	client, err := clclient.NewFromClusterSecret(*secret)
+	client, err := clclient.ClusterClient(*secret)
 	if err != nil {
 		return nil, err
 	}
@@ -7,6 +7,7 @@ import (
 	"github.com/mumoshu/okra/api/elbv2/v1beta1"
 	"github.com/mumoshu/okra/pkg/clclient"
 	"github.com/mumoshu/okra/pkg/okraerror"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -38,7 +39,7 @@ func List(input ListInput) ([]v1beta1.TargetGroupBinding, error) {
 	// 	fmt.Fprintf(os.Stderr, "%s=%s\n", k, v)
 	// }
 
-	client, err := clclient.NewFromClusterSecret(*secret)
+	client, err := clclient.ClusterClient(*secret)
 	if err != nil {
 		return nil, err
 	}
@@ -61,23 +62,27 @@ type ApplyInput struct {
 	Namespace        string
 	TargetGroupARN   string
 	Labels           map[string]string
-	DryRun           bool
+	// ServiceRef and TargetType are set to the binding only when set
+	ServiceRef *v1beta1.ServiceReference
+	TargetType *v1beta1.TargetType
+	DryRun     bool
+
+	// Context defaults to context.Background()
+	Context context.Context
+	// Client reads the cluster secret from the management cluster.
+	// Defaults to an uncached client created from the kubeconfig or the in-cluster config.
+	Client runtimeclient.Client
 }
 
 func Apply(input ApplyInput) (*v1beta1.TargetGroupBinding, error) {
-	clientset, err := clclient.NewClientSet()
-	if err != nil {
-		return nil, okraerror.New(err)
-	}
-
-	ctx := context.Background()
+	ctx := contextOrBackground(input.Context)
 
-	secret, err := clientset.CoreV1().Secrets(input.ClusterNamespace).Get(ctx, input.ClusterName, metav1.GetOptions{})
+	secret, err := getClusterSecret(ctx, input.Client, input.ClusterNamespace, input.ClusterName)
 	if err != nil {
 		return nil, okraerror.New(err)
 	}
 
-	client, err := clclient.NewFromClusterSecret(*secret)
+	client, err := clclient.ClusterClient(*secret)
 	if err != nil {
 		return nil, err
 	}
@@ -98,13 +103,21 @@ func Apply(input ApplyInput) (*v1beta1.TargetGroupBinding, error) {
 	binding.Labels = input.Labels
 	binding.Spec.TargetGroupARN = input.TargetGroupARN
 
+	if input.ServiceRef != nil {
+		binding.Spec.ServiceRef = *input.ServiceRef
+	}
+
+	if input.TargetType != nil {
+		binding.Spec.TargetType = input.TargetType
+	}
+
 	var dryRun []string
 
 	if input.DryRun {
 		dryRun = []string{metav1.DryRunAll}
 	}
 	if bindingExists {
-		if err := client.Update(ctx, &binding); err != nil {
+		if err := client.Update(ctx, &binding, &runtimeclient.UpdateOptions{DryRun: dryRun}); err != nil {
 			return nil, okraerror.New(fmt.Errorf("updating binding: %w", err))
 		}
 	} else {
@@ -115,3 +128,88 @@ func Apply(input ApplyInput) (*v1beta1.TargetGroupBinding, error) {
 
 	return &binding, nil
 }
+
+type DeleteInput struct {
+	ClusterName      string
+	ClusterNamespace string
+	Name             string
+	Namespace        string
+	DryRun           bool
+
+	// Context defaults to context.Background()
+	Context context.Context
+	// Client reads the cluster secret from the management cluster.
+	// Defaults to an uncached client created from the kubeconfig or the in-cluster config.
+	Client runtimeclient.Client
+}
+
+// Delete deletes the binding in the cluster.
+// It does nothing when either the cluster secret or the binding is already gone.
+func Delete(input DeleteInput) error {
+	ctx := contextOrBackground(input.Context)
+
+	secret, err := getClusterSecret(ctx, input.Client, input.ClusterNamespace, input.ClusterName)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+
+		return okraerror.New(err)
+	}
+
+	client, err := clclient.ClusterClient(*secret)
+	if err != nil {
+		return err
+	}
+
+	var dryRun []string
+
+	if input.DryRun {
+		dryRun = []string{metav1.DryRunAll}
+	}
+
+	binding := v1beta1.TargetGroupBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: input.Namespace,
+			Name:      input.Name,
+		},
+	}
+
+	if err := client.Delete(ctx, &binding, &runtimeclient.DeleteOptions{DryRun: dryRun}); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+
+		return okraerror.New(fmt.Errorf("deleting binding: %w", err))
+	}
+
+	return nil
+}
+
+// getClusterSecret reads the cluster secret with the client, or a new uncached client when none is given.
+func getClusterSecret(ctx context.Context, c runtimeclient.Client, ns, name string) (*corev1.Secret, error) {
+	if c == nil {
+		var err error
+
+		c, err = clclient.New()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var secret corev1.Secret
+
+	if err := c.Get(ctx, types.NamespacedName{Namespace: ns, Name: name}, &secret); err != nil {
+		return nil, err
+	}
+
+	return &secret, nil
+}
+
+func contextOrBackground(ctx context.Context) context.Context {
+	if ctx != nil {
+		return ctx
+	}
+
+	return context.Background()
+}